@@ -6,11 +6,13 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"syscall"
 
 	"northstar/internal/config"
 	"northstar/internal/server"
+	"northstar/internal/store"
 	"northstar/internal/util"
 )
 
@@ -18,6 +20,7 @@ var (
 	port    = flag.Int("port", 0, "服务端口 (config.toml 优先；仅当未显式配置 port 时生效)")
 	devMode = flag.Bool("dev", false, "开发模式")
 	dataDir = flag.String("dataDir", "", "数据目录 (覆盖配置文件)")
+	showMig = flag.Bool("migrations", false, "打印数据库结构版本与待执行迁移后退出")
 )
 
 func main() {
@@ -54,6 +57,13 @@ func main() {
 		cfg.Data.DataDir = *dataDir
 	}
 
+	if *showMig {
+		if err := printMigrations(cfg); err != nil {
+			log.Fatalf("检查数据库迁移失败: %v", err)
+		}
+		return
+	}
+
 	// 确保数据目录存在
 	dataDir, err := config.EnsureDataDir(cfg)
 	if err != nil {
@@ -98,4 +108,35 @@ func main() {
 	if err := srv.SaveNow(); err != nil {
 		log.Printf("退出前保存失败: %v", err)
 	}
+	if err := srv.Close(); err != nil {
+		log.Printf("关闭数据库失败: %v", err)
+	}
+}
+
+// printMigrations 打印数据库结构版本与待执行迁移（只读，不会升级数据库）
+func printMigrations(cfg *config.AppConfig) error {
+	dir, err := config.EnsureDataDir(cfg)
+	if err != nil {
+		return err
+	}
+	dbPath := filepath.Join(dir, "northstar.db")
+
+	status, err := store.InspectMigrations(dbPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("数据库: %s\n", dbPath)
+	fmt.Printf("当前版本: %d  最新版本: %d\n", status.CurrentVersion, status.LatestVersion)
+	for _, m := range status.Applied {
+		fmt.Printf("  [已执行] %04d %s (%s)\n", m.Version, m.Name, m.AppliedAt.Format("2006-01-02 15:04:05"))
+	}
+	if len(status.Pending) == 0 {
+		fmt.Println("没有待执行的迁移")
+		return nil
+	}
+	for _, m := range status.Pending {
+		fmt.Printf("  [待执行] %04d %s\n", m.Version, m.Name)
+	}
+	return nil
 }
@@ -0,0 +1,73 @@
+package v3
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/backup"
+	"northstar/internal/store"
+)
+
+// autoBackup 在写入工作数据前生成自动快照；方案数据不备份。
+// 备份失败时写入 500 并返回 false，调用方应中止本次写入。
+func (h *Handler) autoBackup(c *gin.Context, st *store.Store, reason string) bool {
+	if h.backups == nil || st != h.store {
+		return true
+	}
+	if _, err := h.backups.AutoSnapshot(reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "自动备份失败: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// ListBackups 列出数据库快照
+// GET /api/backups
+func (h *Handler) ListBackups(c *gin.Context) {
+	items, err := h.backups.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "autoBackup": h.backups.Enabled()})
+}
+
+// CreateBackup 手动生成数据库快照
+// POST /api/backups
+func (h *Handler) CreateBackup(c *gin.Context) {
+	b, err := h.backups.Snapshot(backup.ReasonManual)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, b)
+}
+
+// RestoreBackup 用快照覆盖当前数据库（覆盖前自动保存一份 pre_restore 快照）
+// POST /api/backups/:id/restore
+func (h *Handler) RestoreBackup(c *gin.Context) {
+	restored, safety, err := h.backups.Restore(c.Param("id"))
+	// 恢复后的数据库带有较旧的 data_version，可能与缓存中的版本号相同而被误判为未变化，需显式丢弃指标缓存
+	h.indicators.Invalidate()
+	if err != nil {
+		if errors.Is(err, backup.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "备份不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	year, month, err := h.store.GetCurrentYearMonth()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取当前年月失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"restored":   restored,
+		"safetyCopy": safety,
+		"year":       year,
+		"month":      month,
+	})
+}
@@ -0,0 +1,118 @@
+package v3
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/calculator"
+	"northstar/internal/rules"
+	"northstar/internal/store"
+)
+
+// ListCollisions 检测当前月份企业增速/零销比雷同
+// GET /api/collisions
+func (h *Handler) ListCollisions(c *gin.Context) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	year, month, err := st.GetCurrentYearMonth()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取当前年月失败"})
+		return
+	}
+
+	wr, err := st.GetWRByYearMonth(store.WRQueryOptions{DataYear: &year, DataMonth: &month})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"year":   year,
+		"month":  month,
+		"groups": rules.DetectCollisions(wr),
+	})
+}
+
+type dedupRequest struct {
+	MaxStep int `json:"maxStep"`
+}
+
+// DedupCollisions 去雷同：按最小整数幅度微调本月销售额/零售额，保持行业合计不变
+// POST /api/collisions/dedup
+func (h *Handler) DedupCollisions(c *gin.Context) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	var req dedupRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式错误"})
+			return
+		}
+	}
+
+	year, month, err := st.GetCurrentYearMonth()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取当前年月失败"})
+		return
+	}
+
+	wr, err := st.GetWRByYearMonth(store.WRQueryOptions{DataYear: &year, DataMonth: &month})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := rules.PlanDedup(wr, req.MaxStep)
+	if _, err := st.Track(changeMeta(c, store.ChangeSourceDedup, ""), year, month, func(tx *store.Store) error {
+		return applyNudges(tx, result.Nudges)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := recalcDerivedFields(st, year, month); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重算衍生字段失败"})
+		return
+	}
+
+	groups, err := calculator.NewCalculator(st).CalculateAll(year, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算指标失败"})
+		return
+	}
+	roundIndicatorGroupsInPlace(groups)
+
+	c.JSON(http.StatusOK, gin.H{
+		"year":       year,
+		"month":      month,
+		"nudges":     result.Nudges,
+		"remaining":  result.Remaining,
+		"unresolved": result.Unresolved,
+		"groups":     groups,
+	})
+}
+
+// applyNudges 在单个事务内写回去雷同微调
+func applyNudges(st *store.Store, nudges []rules.Nudge) error {
+	if len(nudges) == 0 {
+		return nil
+	}
+	tx, err := st.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, n := range nudges {
+		// 字段名来自 rules 包内固定集合
+		if _, err := tx.Exec("UPDATE wholesale_retail SET "+n.Field+" = ? WHERE id = ?", n.After, n.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
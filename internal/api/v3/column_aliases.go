@@ -0,0 +1,124 @@
+package v3
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/model"
+	"northstar/internal/parser"
+	"northstar/internal/store"
+)
+
+// columnAliasRequest 新增/修改列别名请求
+type columnAliasRequest struct {
+	Pattern   string `json:"pattern"`
+	MatchType string `json:"matchType"` // 默认 contains
+	Scope     string `json:"scope"`
+	DBField   string `json:"dbField"`
+	TimeType  string `json:"timeType"`
+	Note      string `json:"note"`
+}
+
+// bindColumnAlias 解析并校验请求；失败时已写入 400 响应
+func bindColumnAlias(c *gin.Context) (model.ColumnAlias, bool) {
+	var req columnAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式错误"})
+		return model.ColumnAlias{}, false
+	}
+	a := model.ColumnAlias{
+		Pattern:   strings.TrimSpace(req.Pattern),
+		MatchType: strings.TrimSpace(req.MatchType),
+		Scope:     strings.TrimSpace(req.Scope),
+		DBField:   strings.TrimSpace(req.DBField),
+		TimeType:  strings.TrimSpace(req.TimeType),
+		Note:      req.Note,
+	}
+	if a.MatchType == "" {
+		a.MatchType = model.AliasMatchContains
+	}
+	if err := parser.ValidateColumnAlias(a); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return model.ColumnAlias{}, false
+	}
+	return a, true
+}
+
+func parseAliasID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alias id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeColumnAliasError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrColumnAliasNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "列别名不存在"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// ListColumnAliases 列出用户列别名
+// GET /api/column-aliases
+func (h *Handler) ListColumnAliases(c *gin.Context) {
+	items, err := h.store.ListColumnAliases()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// CreateColumnAlias 新增列别名（对之后的导入与解析预览生效）
+// POST /api/column-aliases
+func (h *Handler) CreateColumnAlias(c *gin.Context) {
+	a, ok := bindColumnAlias(c)
+	if !ok {
+		return
+	}
+	created, err := h.store.CreateColumnAlias(a)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateColumnAlias 修改列别名
+// PUT /api/column-aliases/:id
+func (h *Handler) UpdateColumnAlias(c *gin.Context) {
+	id, ok := parseAliasID(c)
+	if !ok {
+		return
+	}
+	a, ok := bindColumnAlias(c)
+	if !ok {
+		return
+	}
+	updated, err := h.store.UpdateColumnAlias(id, a)
+	if err != nil {
+		writeColumnAliasError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteColumnAlias 删除列别名
+// DELETE /api/column-aliases/:id
+func (h *Handler) DeleteColumnAlias(c *gin.Context) {
+	id, ok := parseAliasID(c)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteColumnAlias(id); err != nil {
+		writeColumnAliasError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
@@ -0,0 +1,67 @@
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+func TestColumnAliases_CRUD(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/column-aliases", `{"pattern":"商品总额","dbField":"sales"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status=%d body=%s", w.Code, w.Body.String())
+	}
+	var created model.ColumnAlias
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.ID == 0 || created.MatchType != model.AliasMatchContains {
+		t.Fatalf("unexpected alias: %+v", created)
+	}
+
+	if w := do(http.MethodPost, "/api/column-aliases", `{"pattern":"x","dbField":"no_such_field"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid field status=%d", w.Code)
+	}
+
+	path := fmt.Sprintf("/api/column-aliases/%d", created.ID)
+	w = do(http.MethodPut, path, `{"pattern":"商品总额","matchType":"exact","dbField":"sales","timeType":"current_month"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status=%d body=%s", w.Code, w.Body.String())
+	}
+	items, err := st.ListColumnAliases()
+	if err != nil || len(items) != 1 || items[0].MatchType != model.AliasMatchExact || items[0].TimeType != "current_month" {
+		t.Fatalf("aliases after update: %+v err=%v", items, err)
+	}
+
+	if w := do(http.MethodDelete, path, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete status=%d", w.Code)
+	}
+	if w := do(http.MethodDelete, path, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("second delete status=%d", w.Code)
+	}
+}
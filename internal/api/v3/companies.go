@@ -35,6 +35,7 @@ type companyRow struct {
 	SalesLastYearCumulative     *float64 `json:"salesLastYearCumulative,omitempty"`
 	SalesMonthRate              *float64 `json:"salesMonthRate,omitempty"`
 	SalesCumulativeRate         *float64 `json:"salesCumulativeRate,omitempty"`
+	SalesMomRate                *float64 `json:"salesMomRate,omitempty"` // 环比（相对上月），上月为 0 时为空
 
 	RetailCurrentMonth           *float64 `json:"retailCurrentMonth,omitempty"`
 	RetailLastYearMonth          *float64 `json:"retailLastYearMonth,omitempty"`
@@ -43,6 +44,7 @@ type companyRow struct {
 	RetailLastYearCumulative     *float64 `json:"retailLastYearCumulative,omitempty"`
 	RetailMonthRate              *float64 `json:"retailMonthRate,omitempty"`
 	RetailCumulativeRate         *float64 `json:"retailCumulativeRate,omitempty"`
+	RetailMomRate                *float64 `json:"retailMomRate,omitempty"`
 	RetailRatio                  *float64 `json:"retailRatio,omitempty"`
 
 	// AC
@@ -53,6 +55,7 @@ type companyRow struct {
 	RevenueLastYearCumulative *float64 `json:"revenueLastYearCumulative,omitempty"`
 	RevenueMonthRate          *float64 `json:"revenueMonthRate,omitempty"`
 	RevenueCumulativeRate     *float64 `json:"revenueCumulativeRate,omitempty"`
+	RevenueMomRate            *float64 `json:"revenueMomRate,omitempty"`
 
 	RoomPrevMonth          *float64 `json:"roomPrevMonth,omitempty"`
 	RoomCurrentMonth       *float64 `json:"roomCurrentMonth,omitempty"`
@@ -83,7 +86,12 @@ type listCompaniesResponse struct {
 // ListCompanies 查询企业列表（合并批零/住餐）
 // GET /api/companies
 func (h *Handler) ListCompanies(c *gin.Context) {
-	year, month, err := h.store.GetCurrentYearMonth()
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	year, month, err := st.GetCurrentYearMonth()
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{"items": []companyRow{}, "total": 0, "page": 1, "pageSize": 0})
 		return
@@ -104,7 +112,7 @@ func (h *Handler) ListCompanies(c *gin.Context) {
 		pageSize = 2000
 	}
 
-	items, err := h.loadCompanies(year, month, industryType, keyword)
+	items, err := loadCompanies(st, year, month, industryType, keyword)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -131,6 +139,11 @@ func (h *Handler) ListCompanies(c *gin.Context) {
 // GetCompany 获取企业详情
 // GET /api/companies/:id
 func (h *Handler) GetCompany(c *gin.Context) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
 	id := c.Param("id")
 	kind, numericID, ok := parseCompanyID(id)
 	if !ok {
@@ -140,14 +153,14 @@ func (h *Handler) GetCompany(c *gin.Context) {
 
 	switch kind {
 	case "wr":
-		rec, err := h.store.GetWRByID(numericID)
+		rec, err := st.GetWRByID(numericID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, toCompanyRowWR(*rec))
 	case "ac":
-		rec, err := h.store.GetACByID(numericID)
+		rec, err := st.GetACByID(numericID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
@@ -161,7 +174,12 @@ func (h *Handler) GetCompany(c *gin.Context) {
 // UpdateCompany 更新企业数据（微调后联动计算）
 // PATCH /api/companies/:id
 func (h *Handler) UpdateCompany(c *gin.Context) {
-	year, month, err := h.store.GetCurrentYearMonth()
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	year, month, err := st.GetCurrentYearMonth()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "system not initialized"})
 		return
@@ -180,76 +198,137 @@ func (h *Handler) UpdateCompany(c *gin.Context) {
 		return
 	}
 
-	switch kind {
-	case "wr":
-		existing, err := h.store.GetWRByID(numericID)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
+	meta := changeMeta(c, store.ChangeSourceManual, id)
+	if !isDryRun(c) {
+		code, resp := updateCompany(st, h.indicatorCache(st), year, month, kind, numericID, patch, meta)
+		c.JSON(code, resp)
+		return
+	}
 
-		updates := pickWRUpdates(patch)
-		if rateUpdates, err := buildWRRateDrivenUpdates(*existing, patch); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		} else {
+	code, resp, err := dryRun(st, year, month, func(preview *store.Store) (int, gin.H) {
+		return updateCompany(preview, nil, year, month, kind, numericID, patch, meta)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(code, resp)
+}
+
+// updateCompany 写入企业修改并重算衍生字段与指标。
+// cache 非空（工作数据）时按该企业修改前后的差量增量更新指标；预演、方案数据传 nil 全量计算。
+func updateCompany(st *store.Store, cache *calculator.Cache, year, month int, kind string, numericID int64, patch map[string]interface{}, meta store.ChangeMeta) (int, gin.H) {
+	if kind != "wr" && kind != "ac" {
+		return http.StatusBadRequest, gin.H{"error": "invalid id"}
+	}
+
+	code := http.StatusInternalServerError
+	var company companyRow
+	edit := func(tx *store.Store, d *calculator.Delta) error {
+		switch kind {
+		case "wr":
+			existing, err := tx.GetWRByID(numericID)
+			if err != nil {
+				code = http.StatusNotFound
+				return err
+			}
+			if existing.DataYear != year || existing.DataMonth != month {
+				code = http.StatusConflict
+				return fmt.Errorf("企业不属于当前月份 %d-%02d，请刷新后重试", year, month)
+			}
+			updates := pickWRUpdates(patch)
+			rateUpdates, err := buildWRRateDrivenUpdates(*existing, patch)
+			if err != nil {
+				code = http.StatusBadRequest
+				return err
+			}
 			for k, v := range rateUpdates {
 				updates[k] = v
 			}
-		}
-
-		if err := h.store.UpdateWR(numericID, updates); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		if err := recalcDerivedFields(h.store, year, month); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		rec, err := h.store.GetWRByID(numericID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		groups, _ := calculator.NewCalculator(h.store).CalculateAll(year, month)
-		roundIndicatorGroupsInPlace(groups)
-		c.JSON(http.StatusOK, gin.H{"company": toCompanyRowWR(*rec), "groups": groups})
-	case "ac":
-		existing, err := h.store.GetACByID(numericID)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-
-		updates := pickACUpdates(patch)
-		if rateUpdates, err := buildACRateDrivenUpdates(*existing, patch); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		} else {
+			if _, err := tx.TrackRows(meta, year, month, "wholesale_retail", []int64{numericID}, func(t *store.Store) error {
+				return t.UpdateWR(numericID, updates)
+			}); err != nil {
+				return err
+			}
+			if err := recalcCompanyDerivedFields(tx, kind, numericID); err != nil {
+				return err
+			}
+			rec, err := tx.GetWRByID(numericID)
+			if err != nil {
+				return err
+			}
+			d.WR(existing, rec)
+			company = toCompanyRowWR(*rec)
+		case "ac":
+			existing, err := tx.GetACByID(numericID)
+			if err != nil {
+				code = http.StatusNotFound
+				return err
+			}
+			if existing.DataYear != year || existing.DataMonth != month {
+				code = http.StatusConflict
+				return fmt.Errorf("企业不属于当前月份 %d-%02d，请刷新后重试", year, month)
+			}
+			updates := pickACUpdates(patch)
+			rateUpdates, err := buildACRateDrivenUpdates(*existing, patch)
+			if err != nil {
+				code = http.StatusBadRequest
+				return err
+			}
 			for k, v := range rateUpdates {
 				updates[k] = v
 			}
+			if _, err := tx.TrackRows(meta, year, month, "accommodation_catering", []int64{numericID}, func(t *store.Store) error {
+				return t.UpdateAC(numericID, updates)
+			}); err != nil {
+				return err
+			}
+			if err := recalcCompanyDerivedFields(tx, kind, numericID); err != nil {
+				return err
+			}
+			rec, err := tx.GetACByID(numericID)
+			if err != nil {
+				return err
+			}
+			d.AC(existing, rec)
+			company = toCompanyRowAC(*rec)
 		}
+		return nil
+	}
 
-		if err := h.store.UpdateAC(numericID, updates); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		if err := recalcDerivedFields(h.store, year, month); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		rec, err := h.store.GetACByID(numericID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		groups, _ := calculator.NewCalculator(h.store).CalculateAll(year, month)
-		roundIndicatorGroupsInPlace(groups)
-		c.JSON(http.StatusOK, gin.H{"company": toCompanyRowAC(*rec), "groups": groups})
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	var err error
+	if cache != nil {
+		err = cache.Edit(year, month, edit)
+	} else {
+		err = st.Atomic(func(tx *store.Store) error { return edit(tx, &calculator.Delta{}) })
+	}
+	if err != nil {
+		return code, gin.H{"error": err.Error()}
+	}
+
+	groups, _ := calculateIndicators(st, cache, year, month)
+	roundIndicatorGroupsInPlace(groups)
+	return http.StatusOK, gin.H{"company": company, "groups": groups}
+}
+
+// calculateIndicators 计算某月指标；cache 非空时读取增量缓存
+func calculateIndicators(st *store.Store, cache *calculator.Cache, year, month int) ([]calculator.IndicatorGroup, error) {
+	if cache != nil {
+		return cache.Get(year, month)
+	}
+	return calculator.NewCalculator(st).CalculateAll(year, month)
+}
+
+// calculateSocialRetail 社零额（定）计算过程；cache 非空时与指标共用缓存
+func calculateSocialRetail(st *store.Store, cache *calculator.Cache, year, month int) (calculator.SocialRetail, error) {
+	if cache != nil {
+		return cache.SocialRetail(year, month)
+	}
+	totals, err := calculator.NewCalculator(st).Totals(year, month)
+	if err != nil {
+		return calculator.SocialRetail{}, err
 	}
+	return totals.SocialRetail(), nil
 }
 
 func buildWRRateDrivenUpdates(existing model.WholesaleRetail, patch map[string]interface{}) (map[string]interface{}, error) {
@@ -475,7 +554,12 @@ func parseFloatFromPatch(patch map[string]interface{}, keys ...string) (float64,
 // ResetCompanies 重置企业数据到导入原始值
 // POST /api/companies/reset
 func (h *Handler) ResetCompanies(c *gin.Context) {
-	year, month, err := h.store.GetCurrentYearMonth()
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	year, month, err := st.GetCurrentYearMonth()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "system not initialized"})
 		return
@@ -486,25 +570,23 @@ func (h *Handler) ResetCompanies(c *gin.Context) {
 	}
 	_ = c.BindJSON(&body)
 
-	if len(body.CompanyIDs) == 0 {
-		if err := resetAllForMonth(h.store, year, month); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	} else {
-		if err := resetByIDs(h.store, body.CompanyIDs); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+	if _, err := st.Track(changeMeta(c, store.ChangeSourceReset, strings.Join(body.CompanyIDs, ",")), year, month, func(tx *store.Store) error {
+		if len(body.CompanyIDs) == 0 {
+			return resetAllForMonth(tx, year, month)
 		}
+		return resetByIDs(tx, body.CompanyIDs)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	_ = recalcDerivedFields(h.store, year, month)
-	groups, _ := calculator.NewCalculator(h.store).CalculateAll(year, month)
+	_ = recalcDerivedFields(st, year, month)
+	groups, _ := calculator.NewCalculator(st).CalculateAll(year, month)
 	roundIndicatorGroupsInPlace(groups)
 	c.JSON(http.StatusOK, gin.H{"groups": groups})
 }
 
-func (h *Handler) loadCompanies(year, month int, industryType, keyword string) ([]companyRow, error) {
+func loadCompanies(st *store.Store, year, month int, industryType, keyword string) ([]companyRow, error) {
 	var wrRows []*model.WholesaleRetail
 	var acRows []*model.AccommodationCatering
 
@@ -514,7 +596,7 @@ func (h *Handler) loadCompanies(year, month int, industryType, keyword string) (
 			tptr = &industryType
 		}
 		opts := store.WRQueryOptions{DataYear: &year, DataMonth: &month, IndustryType: tptr}
-		rows, err := h.store.GetWRByYearMonth(opts)
+		rows, err := st.GetWRByYearMonth(opts)
 		if err != nil {
 			return nil, err
 		}
@@ -526,7 +608,7 @@ func (h *Handler) loadCompanies(year, month int, industryType, keyword string) (
 			tptr = &industryType
 		}
 		opts := store.ACQueryOptions{DataYear: &year, DataMonth: &month, IndustryType: tptr}
-		rows, err := h.store.GetACByYearMonth(opts)
+		rows, err := st.GetACByYearMonth(opts)
 		if err != nil {
 			return nil, err
 		}
@@ -579,6 +661,7 @@ func toCompanyRowWR(r model.WholesaleRetail) companyRow {
 	out.SalesLastYearCumulative = floatPtr(r.SalesLastYearCumulative)
 	out.SalesMonthRate = floatPtrNullable(r.SalesMonthRate)
 	out.SalesCumulativeRate = floatPtrNullable(r.SalesCumulativeRate)
+	out.SalesMomRate = floatPtrNullable(momRate(r.SalesCurrentMonth, r.SalesPrevMonth))
 
 	out.RetailCurrentMonth = floatPtr(r.RetailCurrentMonth)
 	out.RetailLastYearMonth = floatPtr(r.RetailLastYearMonth)
@@ -587,6 +670,7 @@ func toCompanyRowWR(r model.WholesaleRetail) companyRow {
 	out.RetailLastYearCumulative = floatPtr(r.RetailLastYearCumulative)
 	out.RetailMonthRate = floatPtrNullable(r.RetailMonthRate)
 	out.RetailCumulativeRate = floatPtrNullable(r.RetailCumulativeRate)
+	out.RetailMomRate = floatPtrNullable(momRate(r.RetailCurrentMonth, r.RetailPrevMonth))
 	out.RetailRatio = floatPtrNullable(r.RetailRatio)
 	return out
 }
@@ -613,6 +697,7 @@ func toCompanyRowAC(r model.AccommodationCatering) companyRow {
 	out.RevenueLastYearCumulative = floatPtr(r.RevenueLastYearCumulative)
 	out.RevenueMonthRate = floatPtrNullable(r.RevenueMonthRate)
 	out.RevenueCumulativeRate = floatPtrNullable(r.RevenueCumulativeRate)
+	out.RevenueMomRate = floatPtrNullable(momRate(r.RevenueCurrentMonth, r.RevenuePrevMonth))
 
 	out.RoomPrevMonth = floatPtr(r.RoomPrevMonth)
 	out.RoomCurrentMonth = floatPtr(r.RoomCurrentMonth)
@@ -634,6 +719,7 @@ func toCompanyRowAC(r model.AccommodationCatering) companyRow {
 
 	out.RetailCurrentMonth = floatPtr(r.RetailCurrentMonth)
 	out.RetailLastYearMonth = floatPtr(r.RetailLastYearMonth)
+	out.RetailMomRate = floatPtrNullable(momRate(r.FoodCurrentMonth+r.GoodsCurrentMonth, r.FoodPrevMonth+r.GoodsPrevMonth))
 	return out
 }
 
@@ -642,6 +728,15 @@ func floatPtr(v float64) *float64 {
 	return &val
 }
 
+// momRate 环比增速（%），上月为 0 时无法计算返回 nil
+func momRate(current, prev float64) *float64 {
+	if prev == 0 {
+		return nil
+	}
+	v := (current - prev) / prev * 100
+	return &v
+}
+
 func floatPtrNullable(v *float64) *float64 {
 	if v == nil {
 		return nil
@@ -773,52 +868,64 @@ func pickUpdates(patch map[string]interface{}, allowed map[string]bool, aliases
 	return out
 }
 
+// wrDerivedFieldsSQL / acDerivedFieldsSQL 衍生字段（增速、零售额占比）的重算语句，%s 为 WHERE 条件
+const wrDerivedFieldsSQL = `
+	UPDATE wholesale_retail SET
+		sales_month_rate = CASE
+			WHEN sales_last_year_month = 0 THEN -100
+			ELSE (sales_current_month - sales_last_year_month) / sales_last_year_month * 100
+		END,
+		sales_cumulative_rate = CASE
+			WHEN sales_last_year_cumulative = 0 THEN -100
+			ELSE (sales_current_cumulative - sales_last_year_cumulative) / sales_last_year_cumulative * 100
+		END,
+		retail_month_rate = CASE
+			WHEN retail_last_year_month = 0 THEN -100
+			ELSE (retail_current_month - retail_last_year_month) / retail_last_year_month * 100
+		END,
+		retail_cumulative_rate = CASE
+			WHEN retail_last_year_cumulative = 0 THEN -100
+			ELSE (retail_current_cumulative - retail_last_year_cumulative) / retail_last_year_cumulative * 100
+		END,
+		retail_ratio = CASE
+			WHEN sales_current_month = 0 THEN NULL
+			ELSE retail_current_month / sales_current_month * 100
+		END
+	WHERE %s
+`
+
+const acDerivedFieldsSQL = `
+	UPDATE accommodation_catering SET
+		revenue_month_rate = CASE
+			WHEN revenue_last_year_month = 0 THEN -100
+			ELSE (revenue_current_month - revenue_last_year_month) / revenue_last_year_month * 100
+		END,
+		revenue_cumulative_rate = CASE
+			WHEN revenue_last_year_cumulative = 0 THEN -100
+			ELSE (revenue_current_cumulative - revenue_last_year_cumulative) / revenue_last_year_cumulative * 100
+		END
+	WHERE %s
+`
+
 func recalcDerivedFields(st *store.Store, year, month int) error {
 	// 复用导入后的 SQL（一次更新，保证指标一致）
-	if err := st.Exec(`
-		UPDATE wholesale_retail SET
-			sales_month_rate = CASE
-				WHEN sales_last_year_month = 0 THEN -100
-				ELSE (sales_current_month - sales_last_year_month) / sales_last_year_month * 100
-			END,
-			sales_cumulative_rate = CASE
-				WHEN sales_last_year_cumulative = 0 THEN -100
-				ELSE (sales_current_cumulative - sales_last_year_cumulative) / sales_last_year_cumulative * 100
-			END,
-			retail_month_rate = CASE
-				WHEN retail_last_year_month = 0 THEN -100
-				ELSE (retail_current_month - retail_last_year_month) / retail_last_year_month * 100
-			END,
-			retail_cumulative_rate = CASE
-				WHEN retail_last_year_cumulative = 0 THEN -100
-				ELSE (retail_current_cumulative - retail_last_year_cumulative) / retail_last_year_cumulative * 100
-			END,
-			retail_ratio = CASE
-				WHEN sales_current_month = 0 THEN NULL
-				ELSE retail_current_month / sales_current_month * 100
-			END
-		WHERE data_year = ? AND data_month = ?
-	`, year, month); err != nil {
+	if err := st.Exec(fmt.Sprintf(wrDerivedFieldsSQL, "data_year = ? AND data_month = ?"), year, month); err != nil {
 		return err
 	}
-
-	if err := st.Exec(`
-		UPDATE accommodation_catering SET
-			revenue_month_rate = CASE
-				WHEN revenue_last_year_month = 0 THEN -100
-				ELSE (revenue_current_month - revenue_last_year_month) / revenue_last_year_month * 100
-			END,
-			revenue_cumulative_rate = CASE
-				WHEN revenue_last_year_cumulative = 0 THEN -100
-				ELSE (revenue_current_cumulative - revenue_last_year_cumulative) / revenue_last_year_cumulative * 100
-			END
-		WHERE data_year = ? AND data_month = ?
-	`, year, month); err != nil {
+	if err := st.Exec(fmt.Sprintf(acDerivedFieldsSQL, "data_year = ? AND data_month = ?"), year, month); err != nil {
 		return err
 	}
 	return nil
 }
 
+// recalcCompanyDerivedFields 只重算单家企业的衍生字段（其他企业的衍生字段不受其影响）
+func recalcCompanyDerivedFields(st *store.Store, kind string, numericID int64) error {
+	if kind == "ac" {
+		return st.Exec(fmt.Sprintf(acDerivedFieldsSQL, "id = ?"), numericID)
+	}
+	return st.Exec(fmt.Sprintf(wrDerivedFieldsSQL, "id = ?"), numericID)
+}
+
 func resetAllForMonth(st *store.Store, year, month int) error {
 	if err := st.Exec(`
 		UPDATE wholesale_retail SET
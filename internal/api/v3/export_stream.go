@@ -23,7 +23,12 @@ type exportProgressEvent struct {
 // ExportStream 导出 Excel（SSE 进度 + 完成后提供下载地址）
 // POST /api/export/stream
 func (h *Handler) ExportStream(c *gin.Context) {
-	year, month, err := h.store.GetCurrentYearMonth()
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	year, month, err := st.GetCurrentYearMonth()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取当前年月失败"})
 		return
@@ -59,7 +64,7 @@ func (h *Handler) ExportStream(c *gin.Context) {
 		Timestamp: time.Now(),
 	})
 
-	exp := exporter.NewExporter(h.store, h.templatePath)
+	exp := exporter.NewExporter(st, h.templatePath)
 
 	lastPercent := -1
 	progressFn := func(p exporter.ProgressEvent) {
@@ -1,7 +1,12 @@
 package v3
 
 import (
+	"path/filepath"
+
 	"github.com/gin-gonic/gin"
+	"northstar/internal/backup"
+	"northstar/internal/calculator"
+	"northstar/internal/config"
 	"northstar/internal/store"
 )
 
@@ -10,6 +15,12 @@ type Handler struct {
 	store        *store.Store
 	templatePath string
 	downloads    *exportDownloadStore
+	business     config.BusinessConfig
+	backups      *backup.Manager
+	imports      *importJobs
+	keepImports  bool
+	// indicators 工作数据的增量指标缓存
+	indicators *calculator.Cache
 }
 
 // NewHandler 创建 V3 API 处理器
@@ -18,7 +29,35 @@ func NewHandler(store *store.Store, templatePath string) *Handler {
 		store:        store,
 		templatePath: templatePath,
 		downloads:    newExportDownloadStore(),
+		imports:      &importJobs{},
+		indicators:   calculator.NewCache(store),
+		business:     config.DefaultConfig().Business,
+		// 默认仅支持手动快照，自动备份由 SetBackupManager 按配置开启
+		backups: backup.NewManager(store, filepath.Join(filepath.Dir(store.Path()), "backups"), backup.DefaultKeep, false),
+	}
+}
+
+// SetBusinessConfig 设置业务配置（增速上下限等）
+func (h *Handler) SetBusinessConfig(cfg config.BusinessConfig) {
+	h.business = cfg
+}
+
+// SetKeepImportFiles 设置是否保留导入的原始工作簿
+func (h *Handler) SetKeepImportFiles(keep bool) {
+	h.keepImports = keep
+}
+
+// SetBackupManager 设置数据库快照管理器
+func (h *Handler) SetBackupManager(m *backup.Manager) {
+	h.backups = m
+}
+
+// indicatorCache 工作数据使用增量指标缓存；方案数据返回 nil
+func (h *Handler) indicatorCache(st *store.Store) *calculator.Cache {
+	if st == h.store {
+		return h.indicators
 	}
+	return nil
 }
 
 // RegisterRoutes 注册 V3 API 路由
@@ -35,6 +74,24 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 
 	// 数据导入
 	router.POST("/import", h.Import)
+	router.POST("/import/resolve", h.ResolveImport)
+	router.POST("/import/commit", h.CommitImport)
+	router.POST("/import/:id/cancel", h.CancelImport)
+	router.GET("/imports", h.ListImports)
+	router.GET("/imports/:id", h.GetImport)
+	router.POST("/imports/:id/rerun", h.RerunImport)
+	router.GET("/imports/:id/errors", h.GetImportErrors)
+	router.GET("/imports/:id/errors/download", h.DownloadImportErrors)
+
+	// 列别名（导入字段映射）
+	router.GET("/column-aliases", h.ListColumnAliases)
+	router.POST("/column-aliases", h.CreateColumnAlias)
+	router.PUT("/column-aliases/:id", h.UpdateColumnAlias)
+	router.DELETE("/column-aliases/:id", h.DeleteColumnAlias)
+	// Sheet 识别档案
+	router.GET("/sheet-profiles", h.ListSheetProfiles)
+	router.PUT("/sheet-profiles/:id", h.UpdateSheetProfile)
+	router.DELETE("/sheet-profiles/:id", h.DeleteSheetProfile)
 
 	// 企业数据查询
 	router.GET("/companies", h.ListCompanies)
@@ -44,9 +101,30 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 
 	// 指标查询
 	router.GET("/indicators", h.GetIndicators)
+	// 业务规则校验
+	router.GET("/validation", h.GetValidation)
+	router.GET("/collisions", h.ListCollisions)
+	router.POST("/collisions/dedup", h.DedupCollisions)
 	// 智能调整
 	router.POST("/optimize", h.Optimize)
 
+	// 修改历史
+	router.GET("/history", h.GetHistory)
+	router.POST("/history/undo", h.UndoHistory)
+	router.POST("/history/redo", h.RedoHistory)
+
+	// 命名方案（其他接口通过 ?scenario=<name> 作用于指定方案）
+	router.GET("/scenarios", h.ListScenarios)
+	router.POST("/scenarios", h.CreateScenario)
+	router.GET("/scenarios/diff", h.DiffScenarios)
+	router.DELETE("/scenarios/:name", h.DeleteScenario)
+	router.POST("/scenarios/:name/promote", h.PromoteScenario)
+
+	// 数据库快照
+	router.GET("/backups", h.ListBackups)
+	router.POST("/backups", h.CreateBackup)
+	router.POST("/backups/:id/restore", h.RestoreBackup)
+
 	// 数据导出
 	router.POST("/export", h.Export)
 	router.POST("/export/stream", h.ExportStream)
@@ -0,0 +1,126 @@
+package v3
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/calculator"
+	"northstar/internal/store"
+)
+
+// changeMeta 构造修改批次来源信息；操作人取 X-User 请求头，缺省为客户端地址
+func changeMeta(c *gin.Context, source, note string) store.ChangeMeta {
+	actor := c.GetHeader("X-User")
+	if actor == "" {
+		actor = c.ClientIP()
+	}
+	return store.ChangeMeta{Source: source, Note: note, Actor: actor}
+}
+
+// GetHistory 查询修改历史
+// GET /api/history?batchId=&companyId=&limit=&offset=
+func (h *Handler) GetHistory(c *gin.Context) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	limit := parseIntWithDefault(c.Query("limit"), 50)
+	offset := parseIntWithDefault(c.Query("offset"), 0)
+
+	if v := c.Query("batchId"); v != "" {
+		batchID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batchId"})
+			return
+		}
+		batch, err := st.GetChangeBatch(batchID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		changes, err := st.ListChanges(store.ChangeQueryOptions{BatchID: &batchID, Limit: limit, Offset: offset})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"batch": batch, "changes": changes})
+		return
+	}
+
+	if id := c.Query("companyId"); id != "" {
+		kind, numericID, ok := parseCompanyID(id)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid companyId"})
+			return
+		}
+		table := "wholesale_retail"
+		if kind == "ac" {
+			table = "accommodation_catering"
+		}
+		changes, err := st.ListChanges(store.ChangeQueryOptions{Table: table, RowID: &numericID, Limit: limit, Offset: offset})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"changes": changes})
+		return
+	}
+
+	batches, err := st.ListChangeBatches(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"batches": batches})
+}
+
+// UndoHistory 撤销最近一个修改批次
+// POST /api/history/undo
+func (h *Handler) UndoHistory(c *gin.Context) {
+	h.replayHistory(c, func(st *store.Store, actor string) (*store.ChangeBatch, error) {
+		return st.UndoLastBatch(actor)
+	})
+}
+
+// RedoHistory 重做最近一次撤销的批次
+// POST /api/history/redo
+func (h *Handler) RedoHistory(c *gin.Context) {
+	h.replayHistory(c, func(st *store.Store, actor string) (*store.ChangeBatch, error) {
+		return st.RedoLastBatch(actor)
+	})
+}
+
+func (h *Handler) replayHistory(c *gin.Context, replay func(st *store.Store, actor string) (*store.ChangeBatch, error)) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	batch, err := replay(st, changeMeta(c, "", "").Actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNothingToUndo), errors.Is(err, store.ErrNothingToRedo):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, store.ErrImportNotUndoable):
+			c.JSON(http.StatusConflict, gin.H{"error": "最近一次修改为数据导入，无法撤销"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if err := recalcDerivedFields(st, batch.DataYear, batch.DataMonth); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重算衍生字段失败"})
+		return
+	}
+	groups, err := calculator.NewCalculator(st).CalculateAll(batch.DataYear, batch.DataMonth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算指标失败"})
+		return
+	}
+	roundIndicatorGroupsInPlace(groups)
+	c.JSON(http.StatusOK, gin.H{"batch": batch, "groups": groups})
+}
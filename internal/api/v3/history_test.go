@@ -0,0 +1,149 @@
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/store"
+)
+
+func TestHistory_UndoRedoOptimizeBatch(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	do := func(method, path string, payload any) *httptest.ResponseRecorder {
+		t.Helper()
+		var body []byte
+		if payload != nil {
+			body, _ = json.Marshal(payload)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", "tester")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	sum := func() float64 {
+		t.Helper()
+		var v float64
+		if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&v); err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		return v
+	}
+
+	if w := do(http.MethodPost, "/api/optimize", map[string]any{
+		"targets": map[string]float64{"wholesale_month_rate": 20},
+	}); w.Code != http.StatusOK {
+		t.Fatalf("optimize: %d %s", w.Code, w.Body.String())
+	}
+	if got := sum(); got != 360 {
+		t.Fatalf("optimize sum=%.0f", got)
+	}
+
+	w := do(http.MethodGet, "/api/history", nil)
+	var list struct {
+		Batches []store.ChangeBatch `json:"batches"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Batches) != 1 || list.Batches[0].Source != store.ChangeSourceOptimize ||
+		list.Batches[0].Actor != "tester" || list.Batches[0].ChangeCount == 0 {
+		t.Fatalf("unexpected history: %+v", list.Batches)
+	}
+
+	if w := do(http.MethodPost, "/api/history/undo", nil); w.Code != http.StatusOK {
+		t.Fatalf("undo: %d %s", w.Code, w.Body.String())
+	}
+	if got := sum(); got != 325 {
+		t.Fatalf("undo should restore values, sum=%.0f", got)
+	}
+	if w := do(http.MethodPost, "/api/history/redo", nil); w.Code != http.StatusOK {
+		t.Fatalf("redo: %d %s", w.Code, w.Body.String())
+	}
+	if got := sum(); got != 360 {
+		t.Fatalf("redo should re-apply values, sum=%.0f", got)
+	}
+
+	// 撤销后再手工修改，重做历史失效
+	if w := do(http.MethodPost, "/api/history/undo", nil); w.Code != http.StatusOK {
+		t.Fatalf("undo: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPatch, "/api/companies/wr:1", map[string]any{"salesCurrentMonth": 110}); w.Code != http.StatusOK {
+		t.Fatalf("patch: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/api/history/redo", nil); w.Code != http.StatusConflict {
+		t.Fatalf("redo after new edit should be refused: %d %s", w.Code, w.Body.String())
+	}
+
+	var v float64
+	if err := st.QueryRow("SELECT sales_current_month FROM wholesale_retail WHERE id = 1").Scan(&v); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if v != 110 {
+		t.Fatalf("manual edit lost: %.0f", v)
+	}
+}
+
+func TestHistory_ManualEditTracksOnlyEditedRow(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	body, _ := json.Marshal(map[string]any{"salesCurrentMonth": 130})
+	req := httptest.NewRequest(http.MethodPatch, "/api/companies/wr:2", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("patch: %d %s", w.Code, w.Body.String())
+	}
+
+	changes, err := st.ListChanges(store.ChangeQueryOptions{})
+	if err != nil {
+		t.Fatalf("list changes: %v", err)
+	}
+	if len(changes) == 0 {
+		t.Fatalf("manual edit not recorded")
+	}
+	for _, c := range changes {
+		if c.CompanyID != "wr:2" {
+			t.Fatalf("change outside edited row: %+v", c)
+		}
+	}
+
+	// 行级快照之外的修改不进入批次
+	batch, err := st.TrackRows(store.ChangeMeta{Source: store.ChangeSourceManual}, 2025, 12, "wholesale_retail", []int64{1}, func(tx *store.Store) error {
+		return tx.Exec("UPDATE wholesale_retail SET sales_current_month = 999 WHERE id = 3")
+	})
+	if err != nil {
+		t.Fatalf("track rows: %v", err)
+	}
+	if batch != nil {
+		t.Fatalf("untouched rows should not be recorded: %+v", batch)
+	}
+
+	if _, err := st.UndoLastBatch("tester"); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	var v float64
+	if err := st.QueryRow("SELECT sales_current_month FROM wholesale_retail WHERE id = 2").Scan(&v); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if v != 125 {
+		t.Fatalf("undo should restore edited row, got %.0f", v)
+	}
+}
@@ -1,15 +1,20 @@
 package v3
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"northstar/internal/backup"
 	"northstar/internal/importer"
+	"northstar/internal/parser"
 )
 
 // ImportRequest 导入请求
@@ -18,7 +23,7 @@ type ImportRequest struct {
 	UpdateConfigYM bool `json:"updateConfigYM"` // 是否更新当前年月
 }
 
-// Import 导入 Excel 数据 (SSE 流式响应)
+// Import 导入 Excel 数据 (SSE 流式响应)，支持 .xlsx、.xls 与 CSV（可一次上传多个 CSV，每个文件一个 Sheet）
 // POST /api/import
 func (h *Handler) Import(c *gin.Context) {
 	// 解析 multipart form
@@ -34,49 +39,140 @@ func (h *Handler) Import(c *gin.Context) {
 		return
 	}
 
-	uploadedFile := files[0]
-
-	// 保存到临时目录
-	tempDir := os.TempDir()
-	tempFilePath := filepath.Join(tempDir, fmt.Sprintf("northstar_import_%d_%s", time.Now().Unix(), uploadedFile.Filename))
-
-	if err := c.SaveUploadedFile(uploadedFile, tempFilePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败"})
+	filename, err := uploadFilename(files)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 清理临时文件
-	defer os.Remove(tempFilePath)
+	// 保存上传文件（开启保留时存入数据目录，便于重新导入）
+	filePath, keep := h.importFilePath(filename)
+	if err := saveUpload(c, files, filePath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 解析导入选项
 	clearExisting := c.DefaultPostForm("clearExisting", "true") == "true"
 	updateConfigYM := c.DefaultPostForm("updateConfigYM", "true") == "true"
 
-	// 设置 SSE 响应头
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("X-Accel-Buffering", "no")
+	// 与此前成功导入的文件相同时需显式确认（会覆盖之后的修改）
+	if c.DefaultPostForm("confirmOverwrite", "false") != "true" && h.rejectDuplicateUpload(c, filePath) {
+		_ = os.Remove(filePath)
+		return
+	}
 
-	// 创建导入协调器
-	coordinator := importer.NewCoordinator(h.store)
+	started := h.streamImport(c, importer.ImportOptions{
+		FilePath:         filePath,
+		OriginalFilename: filename,
+		ClearExisting:    clearExisting,
+		UpdateConfigYM:   updateConfigYM,
+		CalculateFields:  true,
+	})
+	// 未保留或导入未执行时清理文件
+	if !keep || !started {
+		_ = os.Remove(filePath)
+	}
+}
+
+// uploadFilename 上传文件名；多个文件时须均为 CSV，打包后以首个文件命名
+func uploadFilename(files []*multipart.FileHeader) (string, error) {
+	name := filepath.Base(files[0].Filename)
+	if len(files) == 1 {
+		return name, nil
+	}
+	for _, fh := range files {
+		if ext := strings.ToLower(filepath.Ext(fh.Filename)); ext != ".csv" {
+			return "", fmt.Errorf("多文件上传仅支持 CSV：%s", fh.Filename)
+		}
+	}
+	return fmt.Sprintf("%s等%d个CSV.zip", strings.TrimSuffix(name, filepath.Ext(name)), len(files)), nil
+}
+
+// saveUpload 保存上传文件到 path；多个 CSV 打包为 zip 保存
+func saveUpload(c *gin.Context, files []*multipart.FileHeader, path string) error {
+	if len(files) == 1 {
+		if err := c.SaveUploadedFile(files[0], path); err != nil {
+			return fmt.Errorf("保存文件失败")
+		}
+		return nil
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("保存文件失败")
+	}
+	names := make([]string, len(files))
+	for i, fh := range files {
+		names[i] = fh.Filename
+	}
+	err = parser.BundleCSVFiles(out, names, func(i int) (io.ReadCloser, error) {
+		return files[i].Open()
+	})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("保存文件失败: %w", err)
+	}
+	return nil
+}
 
-	// 开始导入
-	progressChan := coordinator.Import(importer.ImportOptions{
-		FilePath:          tempFilePath,
-		OriginalFilename:  uploadedFile.Filename,
-		ClearExisting:     clearExisting,
-		UpdateConfigYM:    updateConfigYM,
-		CalculateFields:   true,
+// rejectDuplicateUpload 文件与此前成功导入的文件相同时返回 409 及重复信息（导入月份、之后的修改），
+// 返回值表示是否已写入响应
+func (h *Handler) rejectDuplicateUpload(c *gin.Context, path string) bool {
+	dup, err := importer.NewCoordinator(h.store).CheckDuplicate(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if dup == nil {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":           dup.Message(),
+		"duplicate":       dup,
+		"requiresConfirm": true,
 	})
+	return true
+}
+
+// streamImport 执行导入并以 SSE 推送进度事件；导入随请求断开或取消接口而中止并回滚。
+// 已有导入进行中或自动备份失败时直接返回错误响应，返回值表示导入是否已执行。
+func (h *Handler) streamImport(c *gin.Context, opts importer.ImportOptions) bool {
+	runCtx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	job, runningID, ok := h.imports.begin(cancel)
+	if !ok {
+		c.JSON(http.StatusConflict, gin.H{"error": "已有导入正在进行，请等待其完成或取消后重试", "importId": runningID})
+		return false
+	}
+	defer h.imports.finish(job)
+	opts.OnStart = func(id int64) { h.imports.setID(job, id) }
 
-	// 流式发送进度事件
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "不支持流式响应"})
-		return
+		return false
 	}
 
+	// 清空导入会覆盖现有数据，先做快照
+	if opts.ClearExisting && !h.autoBackup(c, h.store, backup.ReasonImport) {
+		return false
+	}
+
+	// 设置 SSE 响应头
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// 创建导入协调器并开始导入
+	progressChan := importer.NewCoordinator(h.store).ImportWithContext(runCtx, opts)
+
+	// 流式发送进度事件；读完全部事件（导入结束或回滚完成）后才释放导入锁
 	for event := range progressChan {
 		// 序列化事件为 JSON
 		eventData, err := json.Marshal(event)
@@ -88,4 +184,5 @@ func (h *Handler) Import(c *gin.Context) {
 		fmt.Fprintf(c.Writer, "data: %s\n\n", eventData)
 		flusher.Flush()
 	}
+	return true
 }
@@ -0,0 +1,79 @@
+package v3
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/importer"
+	"northstar/internal/model"
+)
+
+// loadImportLog 解析路径中的导入 ID 并读取导入日志；失败时已写入响应
+func (h *Handler) loadImportLog(c *gin.Context) (*model.ImportLog, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import id"})
+		return nil, false
+	}
+	log, err := h.store.GetImportLog(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if log == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "导入记录不存在"})
+		return nil, false
+	}
+	return log, true
+}
+
+// GetImportErrors 获取导入的行级问题（Sheet、行号、列字母、列名、原始值、问题说明）
+// GET /api/imports/:id/errors?limit=&offset=
+func (h *Handler) GetImportErrors(c *gin.Context) {
+	log, ok := h.loadImportLog(c)
+	if !ok {
+		return
+	}
+	limit := parseIntWithDefault(c.Query("limit"), 100)
+	offset := parseIntWithDefault(c.Query("offset"), 0)
+
+	issues, total, err := h.store.ListImportIssues(log.ID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"importId": log.ID,
+		"filename": log.Filename,
+		"status":   log.Status,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+		"issues":   issues,
+	})
+}
+
+// DownloadImportErrors 下载带问题标注的工作簿（原始文件不存在时仅含问题清单）
+// GET /api/imports/:id/errors/download
+func (h *Handler) DownloadImportErrors(c *gin.Context) {
+	log, ok := h.loadImportLog(c)
+	if !ok {
+		return
+	}
+	issues, _, err := h.store.ListImportIssues(log.ID, 0, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := importer.WriteAnnotatedWorkbook(log.FilePath, issues, &buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"import-%d-errors.xlsx\"", log.ID))
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", buf.Bytes())
+}
@@ -0,0 +1,74 @@
+package v3
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importJobs 正在进行的导入：同一时间只允许一个导入，可按导入 ID（import_logs.id）取消
+type importJobs struct {
+	mu      sync.Mutex
+	running *importJob
+}
+
+type importJob struct {
+	id     int64
+	cancel context.CancelFunc
+}
+
+// begin 登记新的导入；已有导入进行中时返回 false 与其 ID
+func (j *importJobs) begin(cancel context.CancelFunc) (*importJob, int64, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.running != nil {
+		return nil, j.running.id, false
+	}
+	j.running = &importJob{cancel: cancel}
+	return j.running, 0, true
+}
+
+// setID 导入日志创建后记录导入 ID
+func (j *importJobs) setID(job *importJob, id int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job.id = id
+}
+
+// finish 导入结束（含回滚）后释放
+func (j *importJobs) finish(job *importJob) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.running == job {
+		j.running = nil
+	}
+}
+
+// cancelByID 取消指定 ID 的导入
+func (j *importJobs) cancelByID(id int64) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.running == nil || j.running.id != id {
+		return false
+	}
+	j.running.cancel()
+	return true
+}
+
+// CancelImport 取消正在进行的导入（已写入的数据整体回滚）
+// POST /api/import/:id/cancel
+func (h *Handler) CancelImport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import id"})
+		return
+	}
+	if !h.imports.cancelByID(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "导入不存在或已结束"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"importId": id, "cancelling": true})
+}
@@ -0,0 +1,70 @@
+package v3
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/store"
+)
+
+func TestImport_RejectsConcurrentImportAndCancelsByID(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	// 模拟一个进行中的导入
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	job, _, ok := h.imports.begin(cancel)
+	if !ok {
+		t.Fatal("begin should succeed")
+	}
+	h.imports.setID(job, 42)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "a.xlsx")
+	_, _ = fw.Write([]byte("not really xlsx"))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("concurrent import status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/import/7/cancel", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("cancel unknown import status=%d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/import/42/cancel", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("cancel running import status=%d body=%s", w.Code, w.Body.String())
+	}
+	if runCtx.Err() == nil {
+		t.Fatal("running import context should be canceled")
+	}
+
+	h.imports.finish(job)
+	if _, _, ok := h.imports.begin(cancel); !ok {
+		t.Fatal("import slot should be free after finish")
+	}
+}
@@ -0,0 +1,201 @@
+package v3
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/importer"
+	"northstar/internal/model"
+)
+
+// pendingUploadTTL 解析后未提交的上传文件保留时长
+const pendingUploadTTL = 24 * time.Hour
+
+var uploadTokenPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// uploadDir 待提交上传文件目录（数据目录下的 uploads）
+func (h *Handler) uploadDir() string {
+	return filepath.Join(filepath.Dir(h.store.Path()), "uploads")
+}
+
+// findPendingUpload 按 token 查找解析阶段保存的上传文件，返回路径与原始文件名
+func (h *Handler) findPendingUpload(token string) (string, string, bool) {
+	if !uploadTokenPattern.MatchString(token) {
+		return "", "", false
+	}
+	matches, _ := filepath.Glob(filepath.Join(h.uploadDir(), token+"_*"))
+	if len(matches) == 0 {
+		return "", "", false
+	}
+	return matches[0], strings.TrimPrefix(filepath.Base(matches[0]), token+"_"), true
+}
+
+// cleanupPendingUploads 删除超过保留时长仍未提交的上传文件
+func (h *Handler) cleanupPendingUploads() {
+	entries, err := os.ReadDir(h.uploadDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || len(name) < 33 || !uploadTokenPattern.MatchString(name[:32]) {
+			continue
+		}
+		if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > pendingUploadTTL {
+			_ = os.Remove(filepath.Join(h.uploadDir(), name))
+		}
+	}
+}
+
+// ResolveImport 解析上传的工作簿但不写入数据：返回各 Sheet 识别结果、数据年月与字段映射，
+// 以及用于提交导入的 token
+// POST /api/import/resolve
+func (h *Handler) ResolveImport(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未找到上传文件"})
+		return
+	}
+	files := form.File["file"]
+	filename, err := uploadFilename(files)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.cleanupPendingUploads()
+	if err := os.MkdirAll(h.uploadDir(), 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建上传目录失败"})
+		return
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成 token 失败"})
+		return
+	}
+	token := hex.EncodeToString(buf)
+	path := filepath.Join(h.uploadDir(), token+"_"+filename)
+	if err := saveUpload(c, files, path); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := importer.NewCoordinator(h.store).Resolve(path)
+	if err != nil {
+		_ = os.Remove(path)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 重复上传仅提示，提交时需确认
+	duplicate, err := importer.NewCoordinator(h.store).CheckDuplicate(path)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"filename":   filename,
+		"year":       preview.Year,
+		"month":      preview.Month,
+		"sheets":     preview.Sheets,
+		"resolution": preview.Resolution,
+		"duplicate":  duplicate,
+	})
+}
+
+// importCommitRequest 提交导入请求：resolve 返回的 token + 人工修正（Sheet 类型、年月、列映射）
+type importCommitRequest struct {
+	Token string `json:"token"`
+	model.ResolveRequest
+	ClearExisting    *bool `json:"clearExisting"`    // 默认 true
+	UpdateConfigYM   *bool `json:"updateConfigYM"`   // 默认 true
+	ConfirmOverwrite bool  `json:"confirmOverwrite"` // 确认重复上传覆盖之后的修改
+}
+
+// CommitImport 按人工确认的参数执行导入（SSE 流式响应）
+// POST /api/import/commit
+func (h *Handler) CommitImport(c *gin.Context) {
+	var req importCommitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式错误"})
+		return
+	}
+	path, filename, ok := h.findPendingUpload(req.Token)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "上传文件不存在或已过期，请重新解析"})
+		return
+	}
+	if req.Year > 0 && req.Month == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "指定年份时必须同时指定月份"})
+		return
+	}
+
+	overrides, err := importer.OverridesFromRequest(req.ResolveRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (overrides.Month > 0 && overrides.Year == 0) || len(overrides.Columns) > 0 {
+		preview, err := importer.NewCoordinator(h.store).Resolve(path)
+		if err != nil {
+			msg := err.Error()
+			if overrides.Month > 0 && overrides.Year == 0 {
+				msg = "无法识别数据年份，请同时指定 year"
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		// 列映射按各 Sheet 对应的表校验，未知字段直接拒绝
+		if err := overrides.ValidateColumns(preview); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if overrides.Month > 0 && overrides.Year == 0 {
+			// 仅修正月份时沿用识别出的年份
+			if preview.Year == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无法识别数据年份，请同时指定 year"})
+				return
+			}
+			overrides.Year = preview.Year
+		}
+	}
+
+	if !req.ConfirmOverwrite && h.rejectDuplicateUpload(c, path) {
+		return
+	}
+
+	clearExisting := req.ClearExisting == nil || *req.ClearExisting
+	updateConfigYM := req.UpdateConfigYM == nil || *req.UpdateConfigYM
+
+	// 开启保留时移入 imports 目录，导入记录指向保留的文件
+	importPath := path
+	if dst, keep := h.importFilePath(filename); keep && os.Rename(path, dst) == nil {
+		importPath = dst
+	}
+
+	started := h.streamImport(c, importer.ImportOptions{
+		FilePath:         importPath,
+		OriginalFilename: filename,
+		ClearExisting:    clearExisting,
+		UpdateConfigYM:   updateConfigYM,
+		CalculateFields:  true,
+		Overrides:        overrides,
+	})
+	switch {
+	case !started && importPath != path:
+		// 导入未执行：放回待提交目录，可稍后重试
+		_ = os.Rename(importPath, path)
+	case started && importPath == path:
+		_ = os.Remove(path)
+	}
+}
@@ -0,0 +1,191 @@
+package v3
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/importer"
+	"northstar/internal/model"
+	"northstar/internal/parser"
+)
+
+// importsDir 保留的导入原始工作簿目录（数据目录下的 imports）
+func (h *Handler) importsDir() string {
+	return filepath.Join(filepath.Dir(h.store.Path()), "imports")
+}
+
+// importFilePath 导入文件保存位置：开启保留时位于 imports 目录，否则为临时文件；
+// 第二个返回值表示导入结束后是否保留
+func (h *Handler) importFilePath(filename string) (string, bool) {
+	name := time.Now().Format("20060102-150405.000000") + "_" + filepath.Base(filename)
+	if h.keepImports {
+		if err := os.MkdirAll(h.importsDir(), 0755); err == nil {
+			return filepath.Join(h.importsDir(), name), true
+		}
+	}
+	return filepath.Join(os.TempDir(), "northstar_import_"+name), false
+}
+
+// importSummary 导入记录摘要
+type importSummary struct {
+	model.ImportLog
+	DurationMs        int64 `json:"durationMs"`
+	OriginalAvailable bool  `json:"originalAvailable"` // 原始工作簿仍保留，可重新导入
+}
+
+// importSheet 导入记录中的 Sheet 明细
+type importSheet struct {
+	ID           int64                 `json:"id"`
+	SheetName    string                `json:"sheetName"`
+	SheetType    string                `json:"sheetType"`
+	Confidence   float64               `json:"confidence"`
+	TotalRows    int                   `json:"totalRows"`
+	TotalColumns int                   `json:"totalColumns"`
+	HeaderRows   [2]int                `json:"headerRows"` // 表头首行/末行（Excel 行号，未记录时为 0）
+	ImportedRows int                   `json:"importedRows"`
+	Status       string                `json:"status"`
+	ErrorMessage string                `json:"errorMessage,omitempty"`
+	Columns      []string              `json:"columns"`
+	Mappings     []parser.FieldMapping `json:"mappings"`
+}
+
+func (h *Handler) summarizeImport(log model.ImportLog) importSummary {
+	s := importSummary{ImportLog: log}
+	if log.CompletedAt != nil {
+		s.DurationMs = log.CompletedAt.Sub(log.StartedAt).Milliseconds()
+	}
+	s.OriginalAvailable = h.isRetainedImportFile(log.FilePath)
+	return s
+}
+
+// isRetainedImportFile 文件是否为 imports 目录中仍存在的原始工作簿
+func (h *Handler) isRetainedImportFile(path string) bool {
+	if path == "" || !strings.HasPrefix(filepath.Clean(path), h.importsDir()+string(filepath.Separator)) {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ListImports 导入历史（最新在前）
+// GET /api/imports?limit=&offset=
+func (h *Handler) ListImports(c *gin.Context) {
+	limit := parseIntWithDefault(c.Query("limit"), 20)
+	offset := parseIntWithDefault(c.Query("offset"), 0)
+
+	logs, total, err := h.store.ListImportLogs(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	items := make([]importSummary, 0, len(logs))
+	for _, l := range logs {
+		items = append(items, h.summarizeImport(l))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "total": total, "limit": limit, "offset": offset})
+}
+
+// GetImport 导入详情：各 Sheet 识别结果、列映射、行数、耗时与文件哈希
+// GET /api/imports/:id
+func (h *Handler) GetImport(c *gin.Context) {
+	log, ok := h.loadImportLog(c)
+	if !ok {
+		return
+	}
+	metas, err := h.store.ListSheetMetasByImport(log.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	_, issueCount, err := h.store.ListImportIssues(log.ID, 1, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sheets := make([]importSheet, 0, len(metas))
+	for _, m := range metas {
+		s := importSheet{
+			ID:           m.ID,
+			SheetName:    m.SheetName,
+			SheetType:    m.SheetType,
+			Confidence:   m.Confidence,
+			TotalRows:    m.TotalRows,
+			TotalColumns: m.TotalColumns,
+			HeaderRows:   [2]int{m.HeaderRowStart, m.HeaderRowEnd},
+			ImportedRows: m.ImportedRows,
+			Status:       m.Status,
+			ErrorMessage: m.ErrorMessage,
+			Columns:      []string{},
+			Mappings:     []parser.FieldMapping{},
+		}
+		if m.ColumnsJSON != "" {
+			_ = json.Unmarshal([]byte(m.ColumnsJSON), &s.Columns)
+		}
+		if m.ColumnMappingJSON != "" {
+			_ = json.Unmarshal([]byte(m.ColumnMappingJSON), &s.Mappings)
+		}
+		sheets = append(sheets, s)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"import":     h.summarizeImport(*log),
+		"sheets":     sheets,
+		"issueCount": issueCount,
+	})
+}
+
+// rerunImportRequest 重新导入选项
+type rerunImportRequest struct {
+	ClearExisting  *bool `json:"clearExisting"`  // 默认 true
+	UpdateConfigYM *bool `json:"updateConfigYM"` // 默认 true
+}
+
+// RerunImport 用保留的原始工作簿重新执行导入（SSE 流式响应），产生新的导入记录
+// POST /api/imports/:id/rerun
+func (h *Handler) RerunImport(c *gin.Context) {
+	log, ok := h.loadImportLog(c)
+	if !ok {
+		return
+	}
+	var req rerunImportRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式错误"})
+			return
+		}
+	}
+	if !h.isRetainedImportFile(log.FilePath) {
+		c.JSON(http.StatusGone, gin.H{"error": "原始工作簿未保留，无法重新导入"})
+		return
+	}
+
+	// 沿用原导入人工确认的 Sheet 类型、年月与列映射
+	var overrides *importer.Overrides
+	raw, err := h.store.GetImportLogOverrides(log.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if raw != "" {
+		overrides = &importer.Overrides{}
+		if err := json.Unmarshal([]byte(raw), overrides); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "导入参数记录无效"})
+			return
+		}
+	}
+
+	h.streamImport(c, importer.ImportOptions{
+		FilePath:         log.FilePath,
+		OriginalFilename: log.Filename,
+		ClearExisting:    req.ClearExisting == nil || *req.ClearExisting,
+		UpdateConfigYM:   req.UpdateConfigYM == nil || *req.UpdateConfigYM,
+		CalculateFields:  true,
+		Overrides:        overrides,
+	})
+}
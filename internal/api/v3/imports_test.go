@@ -0,0 +1,309 @@
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"northstar/internal/store"
+)
+
+// testImportWorkbook 生成只含一家批发企业的工作簿
+func testImportWorkbook(t *testing.T) []byte {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	_ = f.SetSheetName("Sheet1", "批发")
+	_ = f.SetSheetRow("批发", "A1", &[]interface{}{"统一社会信用代码", "单位详细名称", "行业代码", "2025年12月销售额"})
+	_ = f.SetSheetRow("批发", "A2", &[]interface{}{"91A", "甲公司", "5111", 100})
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// resolveUpload 上传工作簿进行解析预览，返回提交用的 token
+func resolveUpload(t *testing.T, r *gin.Engine, xlsx []byte) string {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "月报.xlsx")
+	_, _ = fw.Write(xlsx)
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/import/resolve", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("resolve status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resolved struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resolved); err != nil {
+		t.Fatal(err)
+	}
+	return resolved.Token
+}
+
+// postImport 以 multipart 表单上传工作簿
+func postImport(r *gin.Engine, xlsx []byte, fields map[string]string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "月报.xlsx")
+	_, _ = fw.Write(xlsx)
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestImports_HistoryDetailAndRerun(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	h.SetKeepImportFiles(true)
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	w := postImport(r, testImportWorkbook(t), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	var list struct {
+		Items []importSummary `json:"items"`
+		Total int             `json:"total"`
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/imports", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list: %v body=%s", err, w.Body.String())
+	}
+	if list.Total != 1 || list.Items[0].Status != "completed" || !list.Items[0].OriginalAvailable || list.Items[0].FileHash == "" {
+		t.Fatalf("unexpected history: %+v", list)
+	}
+	id := list.Items[0].ID
+
+	var detail struct {
+		Sheets []importSheet `json:"sheets"`
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/imports/%d", id), nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("decode detail: %v", err)
+	}
+	if len(detail.Sheets) != 1 || detail.Sheets[0].ImportedRows != 1 || len(detail.Sheets[0].Mappings) == 0 {
+		t.Fatalf("unexpected sheets: %+v", detail.Sheets)
+	}
+
+	var status StatusResponse
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	_ = json.Unmarshal(w.Body.Bytes(), &status)
+	if status.LastImportTime == "" {
+		t.Fatalf("lastImportTime should be set: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/imports/%d/rerun", id), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("rerun status=%d body=%s", w.Code, w.Body.String())
+	}
+	logs, total, err := st.ListImportLogs(10, 0)
+	if err != nil || total != 2 || logs[0].FilePath != logs[1].FilePath || logs[0].Status != "completed" {
+		t.Fatalf("rerun should reuse the retained file: total=%d logs=%+v err=%v", total, logs, err)
+	}
+}
+
+func TestImport_DuplicateUploadRequiresConfirmation(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	xlsx := testImportWorkbook(t)
+	if w := postImport(r, xlsx, nil); w.Code != http.StatusOK {
+		t.Fatalf("first import status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	// 导入后手工修改一个字段
+	if _, err := st.Track(store.ChangeMeta{Source: store.ChangeSourceManual}, 2025, 12, func(tx *store.Store) error {
+		return tx.Exec("UPDATE wholesale_retail SET sales_current_month = 120 WHERE name = '甲公司'")
+	}); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+
+	w := postImport(r, xlsx, nil)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("duplicate upload status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Duplicate struct {
+			Previous struct {
+				DataYear  int `json:"dataYear"`
+				DataMonth int `json:"dataMonth"`
+			} `json:"previous"`
+			Edits        []json.RawMessage `json:"edits"`
+			EditedFields int               `json:"editedFields"`
+		} `json:"duplicate"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	d := resp.Duplicate
+	if d.Previous.DataYear != 2025 || d.Previous.DataMonth != 12 || len(d.Edits) != 1 || d.EditedFields != 1 {
+		t.Fatalf("unexpected duplicate info: %s", w.Body.String())
+	}
+
+	if w := postImport(r, xlsx, map[string]string{"confirmOverwrite": "true"}); w.Code != http.StatusOK {
+		t.Fatalf("confirmed import status=%d body=%s", w.Code, w.Body.String())
+	}
+	var sales float64
+	if err := st.DB().QueryRow("SELECT sales_current_month FROM wholesale_retail WHERE name = '甲公司'").Scan(&sales); err != nil {
+		t.Fatal(err)
+	}
+	if sales != 100 {
+		t.Fatalf("confirmed import should overwrite edits, got %v", sales)
+	}
+}
+
+func TestImports_RerunKeepsCommitOverrides(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	h.SetKeepImportFiles(true)
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	token := resolveUpload(t, r, testImportWorkbook(t))
+
+	// 人工将数据月份修正为 11 月（工作簿列名识别为 12 月）
+	payload, _ := json.Marshal(map[string]any{
+		"token":     token,
+		"year":      2025,
+		"month":     11,
+		"overrides": map[string]string{"批发": "wholesale"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/import/commit", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("commit status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	type row struct {
+		Year, Month int
+		Sales       float64
+	}
+	rows := func() []row {
+		t.Helper()
+		rs, err := st.Query("SELECT data_year, data_month, sales_current_month FROM wholesale_retail ORDER BY data_year, data_month")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rs.Close()
+		var out []row
+		for rs.Next() {
+			var x row
+			if err := rs.Scan(&x.Year, &x.Month, &x.Sales); err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, x)
+		}
+		return out
+	}
+	committed := rows()
+	if len(committed) != 1 || committed[0].Month != 11 || committed[0].Sales != 100 {
+		t.Fatalf("unexpected committed rows: %+v", committed)
+	}
+
+	logs, _, err := st.ListImportLogs(1, 0)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("list logs: %v %+v", err, logs)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/imports/%d/rerun", logs[0].ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("rerun status=%d body=%s", w.Code, w.Body.String())
+	}
+	rerun := rows()
+	if len(rerun) != len(committed) || rerun[0] != committed[0] {
+		t.Fatalf("rerun should reuse commit overrides: got %+v, want %+v", rerun, committed)
+	}
+}
+
+func TestImportCommit_RejectsUnknownColumnFields(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+	token := resolveUpload(t, r, testImportWorkbook(t))
+
+	for _, tc := range []struct {
+		name      string
+		overrides map[string]string
+		field     string
+	}{
+		{"拼写错误", nil, "sales_curent_month"},
+		{"住餐字段（识别为批发）", nil, "revenue_current_month"},
+		{"住餐字段（指定为批发）", map[string]string{"批发": "wholesale"}, "revenue_current_month"},
+	} {
+		payload, _ := json.Marshal(map[string]any{
+			"token":     token,
+			"overrides": tc.overrides,
+			"columns":   map[string]map[int]string{"批发": {3: tc.field}},
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/import/commit", bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status=%d body=%s", tc.name, w.Code, w.Body.String())
+		}
+	}
+
+	var n int
+	if err := st.QueryRow("SELECT COUNT(*) FROM wholesale_retail").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("rejected commits must not import rows, got %d", n)
+	}
+}
@@ -100,7 +100,7 @@ func (h *Handler) SelectMonth(c *gin.Context) {
 			TotalCompanies: wrCount + acCount,
 			WRCount:        wrCount,
 			ACCount:        acCount,
-			LastImportTime: "",
+			LastImportTime: h.lastImportTime(),
 		},
 		"groups": groups,
 	})
@@ -1,24 +1,52 @@
 package v3
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
 	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"northstar/internal/backup"
 	"northstar/internal/calculator"
+	"northstar/internal/model"
+	"northstar/internal/rules"
 	"northstar/internal/store"
 )
 
 type OptimizeRequest struct {
-	Targets map[string]float64 `json:"targets"`
+	Targets     map[string]float64         `json:"targets"`
+	Constraints *model.OptimizeConstraints `json:"constraints"`
+	// Mode 求解模式：proportional（默认，等比例分摊）、min_change（总变动量最小）、min_rows（调整企业最少）
+	Mode string `json:"mode"`
+	// CompanyIDs 仅允许调整的企业（wr:<id>/ac:<id>）
+	CompanyIDs []string `json:"companyIds"`
+	// PriorityOnly 仅允许调整 constraints.priorityIndustries 中的行业
+	PriorityOnly bool `json:"priorityOnly"`
+	// DryRun 仅预演：在回滚事务内求解并返回逐企业变化，不写入数据（也可用 ?dryRun=true）
+	DryRun bool `json:"dryRun"`
 }
 
-// Optimize 执行智能调整（按目标指标反推并写回企业数据）
+// targetOutcome 单个目标的达成情况
+type targetOutcome struct {
+	ID       string  `json:"id"`
+	Target   float64 `json:"target"`
+	Achieved float64 `json:"achieved"`
+	Residual float64 `json:"residual"` // achieved - target
+	Reached  bool    `json:"reached"`
+}
+
+// Optimize 执行智能调整（联合求解所有目标指标并写回企业数据）
 // POST /api/optimize
 func (h *Handler) Optimize(c *gin.Context) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
 	var req OptimizeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式错误"})
@@ -34,607 +62,274 @@ func (h *Handler) Optimize(c *gin.Context) {
 		req.Targets[k] = math.Round(v)
 	}
 
-	year, month, err := h.store.GetCurrentYearMonth()
+	year, month, err := st.GetCurrentYearMonth()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取当前年月失败"})
 		return
 	}
 
-	ordered := orderTargets(req.Targets)
-	for _, item := range ordered {
-		if err := applyIndicatorTarget(h.store, year, month, item.ID, item.Value); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "indicatorId": item.ID})
-			return
-		}
-	}
-
-	if err := recalcDerivedFields(h.store, year, month); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "重算衍生字段失败"})
+	band, err := h.growthBandFor(req.Constraints)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	calc := calculator.NewCalculator(h.store)
-	groups, err := calc.CalculateAll(year, month)
+	opts, err := optimizeOptionsFor(req, band)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算指标失败"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	roundIndicatorGroupsInPlace(groups)
 
-	c.JSON(http.StatusOK, gin.H{
-		"year":   year,
-		"month":  month,
-		"groups": groups,
-	})
-}
-
-type orderedTarget struct {
-	ID    string
-	Value float64
-}
-
-func orderTargets(targets map[string]float64) []orderedTarget {
-	knownOrder := []string{
-		"limitAbove_month_value",
-		"limitAbove_month_rate",
-		"limitAbove_cumulative_value",
-		"limitAbove_cumulative_rate",
-		"eatWearUse_month_rate",
-		"microSmall_month_rate",
-		"wholesale_month_rate",
-		"wholesale_cumulative_rate",
-		"retail_month_rate",
-		"retail_cumulative_rate",
-		"accommodation_month_rate",
-		"accommodation_cumulative_rate",
-		"catering_month_rate",
-		"catering_cumulative_rate",
-		"totalSocial_cumulative_value",
-		"totalSocial_cumulative_rate",
-	}
-
-	out := make([]orderedTarget, 0, len(targets))
-	seen := map[string]bool{}
-	for _, id := range knownOrder {
-		if v, ok := targets[id]; ok {
-			out = append(out, orderedTarget{ID: id, Value: v})
-			seen[id] = true
-		}
-	}
-	var rest []string
-	for id := range targets {
-		if !seen[id] {
-			rest = append(rest, id)
+	meta := changeMeta(c, store.ChangeSourceOptimize, describeTargets(req.Targets))
+	if !req.DryRun && !isDryRun(c) {
+		if !h.autoBackup(c, st, backup.ReasonOptimize) {
+			return
 		}
-	}
-	sort.Strings(rest)
-	for _, id := range rest {
-		out = append(out, orderedTarget{ID: id, Value: targets[id]})
-	}
-	return out
-}
-
-func applyIndicatorTarget(st *store.Store, year, month int, id string, target float64) error {
-	if math.IsNaN(target) || math.IsInf(target, 0) {
-		return fmt.Errorf("无效目标值: %s", id)
-	}
-
-	switch id {
-	case "limitAbove_month_value":
-		return adjustLimitAboveMonthValue(st, year, month, target)
-	case "limitAbove_month_rate":
-		return adjustLimitAboveMonthRate(st, year, month, target)
-	case "limitAbove_cumulative_value":
-		return adjustLimitAboveCumulativeValue(st, year, month, target)
-	case "limitAbove_cumulative_rate":
-		return adjustLimitAboveCumulativeRate(st, year, month, target)
-	case "eatWearUse_month_rate":
-		return adjustWRSpecialRate(st, year, month, "is_eat_wear_use", target)
-	case "microSmall_month_rate":
-		return adjustWRSpecialRate(st, year, month, "is_small_micro", target)
-	case "wholesale_month_rate":
-		return adjustWRIndustryRate(st, year, month, "wholesale", "sales_current_month", "sales_last_year_month", target)
-	case "wholesale_cumulative_rate":
-		return adjustWRIndustryRate(st, year, month, "wholesale", "sales_current_cumulative", "sales_last_year_cumulative", target)
-	case "retail_month_rate":
-		return adjustWRIndustryRate(st, year, month, "retail", "sales_current_month", "sales_last_year_month", target)
-	case "retail_cumulative_rate":
-		return adjustWRIndustryRate(st, year, month, "retail", "sales_current_cumulative", "sales_last_year_cumulative", target)
-	case "accommodation_month_rate":
-		return adjustACIndustryRate(st, year, month, "accommodation", "revenue_current_month", "revenue_last_year_month", target)
-	case "accommodation_cumulative_rate":
-		return adjustACIndustryRate(st, year, month, "accommodation", "revenue_current_cumulative", "revenue_last_year_cumulative", target)
-	case "catering_month_rate":
-		return adjustACIndustryRate(st, year, month, "catering", "revenue_current_month", "revenue_last_year_month", target)
-	case "catering_cumulative_rate":
-		return adjustACIndustryRate(st, year, month, "catering", "revenue_current_cumulative", "revenue_last_year_cumulative", target)
-	case "totalSocial_cumulative_value":
-		return adjustTotalSocialCumulativeValue(st, year, month, target)
-	case "totalSocial_cumulative_rate":
-		return adjustTotalSocialCumulativeRate(st, year, month, target)
-	default:
-		return fmt.Errorf("不支持的指标: %s", id)
-	}
-}
-
-func adjustLimitAboveMonthValue(st *store.Store, year, month int, target float64) error {
-	if target < 0 {
-		target = 0
-	}
-
-	wrSum, wrCount, err := sumAndCountWR(st, year, month, "", "", "retail_current_month")
-	if err != nil {
-		return err
-	}
-	acSum, acCount, err := sumAndCountACDerivedRetailMonth(st, year, month, "", false)
-	if err != nil {
-		return err
-	}
-
-	return scaleAcrossWRAndACDerivedRetail(st, year, month, "retail_current_month", "food_current_month", "goods_current_month", wrSum, wrCount, acSum, acCount, target)
-}
-
-func adjustLimitAboveMonthRate(st *store.Store, year, month int, targetRate float64) error {
-	lastYearSumWR, _, err := sumAndCountWR(st, year, month, "", "", "retail_last_year_month")
-	if err != nil {
-		return err
-	}
-	lastYearSumAC, _, err := sumAndCountACDerivedRetailMonth(st, year, month, "", true)
-	if err != nil {
-		return err
-	}
-
-	desired := lastYearSumWR + lastYearSumAC
-	desired = desired * (1 + targetRate/100)
-	if desired < 0 {
-		desired = 0
-	}
-
-	wrSum, wrCount, err := sumAndCountWR(st, year, month, "", "", "retail_current_month")
-	if err != nil {
-		return err
-	}
-	acSum, acCount, err := sumAndCountACDerivedRetailMonth(st, year, month, "", false)
-	if err != nil {
-		return err
+		code, resp := runOptimize(st, year, month, req.Targets, opts, meta)
+		c.JSON(code, resp)
+		return
 	}
 
-	return scaleAcrossWRAndACDerivedRetail(st, year, month, "retail_current_month", "food_current_month", "goods_current_month", wrSum, wrCount, acSum, acCount, desired)
-}
-
-func adjustLimitAboveCumulativeValue(st *store.Store, year, month int, target float64) error {
-	if target < 0 {
-		target = 0
-	}
-	wrSum, wrCount, err := sumAndCountWR(st, year, month, "", "", "retail_current_cumulative")
-	if err != nil {
-		return err
-	}
-	acSum, acCount, err := sumAndCountACDerivedRetailCumulative(st, year, month, "", false)
+	code, resp, err := dryRun(st, year, month, func(preview *store.Store) (int, gin.H) {
+		return runOptimize(preview, year, month, req.Targets, opts, meta)
+	})
 	if err != nil {
-		return err
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	return scaleAcrossWRAndACDerivedRetail(st, year, month, "retail_current_cumulative", "food_current_cumulative", "goods_current_cumulative", wrSum, wrCount, acSum, acCount, target)
+	c.JSON(code, resp)
 }
 
-func adjustLimitAboveCumulativeRate(st *store.Store, year, month int, targetRate float64) error {
-	lastYearSumWR, _, err := sumAndCountWR(st, year, month, "", "", "retail_last_year_cumulative")
-	if err != nil {
-		return err
+// runOptimize 求解、写回并重算指标，返回响应状态码与内容
+func runOptimize(st *store.Store, year, month int, targets map[string]float64, opts optimizeOptions, meta store.ChangeMeta) (int, gin.H) {
+	plan, err := planTargets(st, year, month, targets, opts)
+	if err != nil {
+		var infeasible *infeasibleError
+		var conflict *targetConflictError
+		switch {
+		case errors.As(err, &infeasible):
+			return http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "indicatorId": infeasible.IndicatorID, "infeasible": infeasible}
+		case errors.As(err, &conflict):
+			return http.StatusConflict, gin.H{"error": err.Error(), "conflicts": conflict.Pairs}
+		default:
+			return http.StatusBadRequest, gin.H{"error": err.Error()}
+		}
 	}
-	lastYearSumAC, _, err := sumAndCountACDerivedRetailCumulative(st, year, month, "", true)
+	batch, err := st.Track(meta, year, month, plan.apply)
 	if err != nil {
-		return err
+		return http.StatusInternalServerError, gin.H{"error": err.Error()}
 	}
 
-	lastYearSum := lastYearSumWR + lastYearSumAC
-	desired := lastYearSum * (1 + targetRate/100)
-	if desired < 0 {
-		desired = 0
+	if err := recalcDerivedFields(st, year, month); err != nil {
+		return http.StatusInternalServerError, gin.H{"error": "重算衍生字段失败"}
 	}
 
-	wrSum, wrCount, err := sumAndCountWR(st, year, month, "", "", "retail_current_cumulative")
-	if err != nil {
-		return err
-	}
-	acSum, acCount, err := sumAndCountACDerivedRetailCumulative(st, year, month, "", false)
-	if err != nil {
-		return err
-	}
-	return scaleAcrossWRAndACDerivedRetail(st, year, month, "retail_current_cumulative", "food_current_cumulative", "goods_current_cumulative", wrSum, wrCount, acSum, acCount, desired)
-}
-
-func adjustWRSpecialRate(st *store.Store, year, month int, flagField string, targetRate float64) error {
-	currentSum, count, err := sumAndCountWR(st, year, month, "", flagField, "retail_current_month")
-	if err != nil {
-		return err
-	}
-	lastYearSum, _, err := sumAndCountWR(st, year, month, "", flagField, "retail_last_year_month")
+	calc := calculator.NewCalculator(st)
+	groups, err := calc.CalculateAll(year, month)
 	if err != nil {
-		return err
-	}
-
-	desired := lastYearSum * (1 + targetRate/100)
-	if desired < 0 {
-		desired = 0
+		return http.StatusInternalServerError, gin.H{"error": "计算指标失败"}
 	}
+	outcomes, warnings := evaluateTargets(groups, orderTargets(targets))
+	roundIndicatorGroupsInPlace(groups)
+	adjustments := plan.adjustments()
 
-	return scaleWRField(st, year, month, "", flagField, "retail_current_month", currentSum, count, desired)
-}
-
-func adjustWRIndustryRate(st *store.Store, year, month int, industryType, currentField, lastYearField string, targetRate float64) error {
-	currentSum, count, err := sumAndCountWR(st, year, month, industryType, "", currentField)
-	if err != nil {
-		return err
+	resp := gin.H{
+		"year":        year,
+		"month":       month,
+		"mode":        opts.mode,
+		"groups":      groups,
+		"targets":     outcomes,
+		"adjustments": adjustments,
+		"summary":     summarizeAdjustments(adjustments),
+		"warnings":    append(plan.warnings, warnings...),
 	}
-	lastYearSum, _, err := sumAndCountWR(st, year, month, industryType, "", lastYearField)
-	if err != nil {
-		return err
+	if report, err := rules.NewEngine(st).Evaluate(year, month); err == nil {
+		resp["validation"] = report.Summary
 	}
-
-	desired := lastYearSum * (1 + targetRate/100)
-	if desired < 0 {
-		desired = 0
+	if batch != nil {
+		resp["batchId"] = batch.ID
 	}
-
-	return scaleWRField(st, year, month, industryType, "", currentField, currentSum, count, desired)
+	return http.StatusOK, resp
 }
 
-func adjustACIndustryRate(st *store.Store, year, month int, industryType, currentField, lastYearField string, targetRate float64) error {
-	currentSum, count, err := sumAndCountAC(st, year, month, industryType, currentField)
-	if err != nil {
-		return err
-	}
-
-	lastYearSum, _, err := sumAndCountAC(st, year, month, industryType, lastYearField)
-	if err != nil {
-		return err
-	}
+// targetTolerance 目标达成判定容差（指标单位）
+const targetTolerance = 0.5
 
-	desired := lastYearSum * (1 + targetRate/100)
-	if desired < 0 {
-		desired = 0
+// evaluateTargets 按重算后的指标计算各目标的达成值与残差
+func evaluateTargets(groups []calculator.IndicatorGroup, ordered []orderedTarget) ([]targetOutcome, []string) {
+	outcomes := make([]targetOutcome, 0, len(ordered))
+	warnings := []string{}
+	for _, item := range ordered {
+		achieved, _ := lookupIndicator(groups, item.ID)
+		residual := achieved - item.Value
+		outcome := targetOutcome{
+			ID:       item.ID,
+			Target:   item.Value,
+			Achieved: math.Round(achieved*100) / 100,
+			Residual: math.Round(residual*100) / 100,
+			Reached:  math.Abs(residual) <= targetTolerance,
+		}
+		if !outcome.Reached {
+			warnings = append(warnings, fmt.Sprintf("%s 未完全达成：目标 %.2f，实际 %.2f", item.ID, item.Value, achieved))
+		}
+		outcomes = append(outcomes, outcome)
 	}
-
-	return scaleACField(st, year, month, industryType, currentField, currentSum, count, desired)
+	return outcomes, warnings
 }
 
-func adjustTotalSocialCumulativeValue(st *store.Store, year, month int, target float64) error {
-	if target < 0 {
-		target = 0
-	}
-
-	limitBelowLastYear, err := st.GetConfigFloat("last_year_limit_below_cumulative")
-	if err != nil {
-		limitBelowLastYear = 0
-	}
-
-	microRate, err := computeMicroSmallRate(st, year, month)
-	if err != nil {
-		return err
-	}
-
-	limitBelowEstimated := limitBelowLastYear * (1 + microRate/100)
-	desiredLimitAbove := target - limitBelowEstimated
-	if desiredLimitAbove < 0 {
-		desiredLimitAbove = 0
-	}
-
-	wrSum, wrCount, err := sumAndCountWR(st, year, month, "", "", "retail_current_cumulative")
-	if err != nil {
-		return err
-	}
-	acSum, acCount, err := sumAndCountACDerivedRetailCumulative(st, year, month, "", false)
-	if err != nil {
-		return err
+func lookupIndicator(groups []calculator.IndicatorGroup, id string) (float64, bool) {
+	for _, g := range groups {
+		for _, it := range g.Indicators {
+			if it.ID == id {
+				return it.Value, true
+			}
+		}
 	}
-	return scaleAcrossWRAndACDerivedRetail(st, year, month, "retail_current_cumulative", "food_current_cumulative", "goods_current_cumulative", wrSum, wrCount, acSum, acCount, desiredLimitAbove)
+	return 0, false
 }
 
-func adjustTotalSocialCumulativeRate(st *store.Store, year, month int, targetRate float64) error {
-	limitBelowLastYear, err := st.GetConfigFloat("last_year_limit_below_cumulative")
-	if err != nil {
-		limitBelowLastYear = 0
-	}
-
-	microRate, err := computeMicroSmallRate(st, year, month)
-	if err != nil {
-		return err
-	}
-
-	retailLastYearCumWR, _, err := sumAndCountWR(st, year, month, "", "", "retail_last_year_cumulative")
-	if err != nil {
-		return err
+// growthBandFor 合并业务配置与请求约束得到单企业增速区间
+func (h *Handler) growthBandFor(constraints *model.OptimizeConstraints) (growthBand, error) {
+	band := unboundedBand()
+	if h.business.MaxGrowth > h.business.MinGrowth {
+		band.Min = h.business.MinGrowth
+		band.Max = h.business.MaxGrowth
 	}
-	retailLastYearCumAC, _, err := sumAndCountACDerivedRetailCumulative(st, year, month, "", true)
-	if err != nil {
-		return err
-	}
-	retailLastYearCumulativeSum := retailLastYearCumWR + retailLastYearCumAC
 
-	targetFraction := targetRate / 100
-	desiredLimitAbove := retailLastYearCumulativeSum*(1+targetFraction) + limitBelowLastYear*(targetFraction-microRate/100)
-	if desiredLimitAbove < 0 {
-		desiredLimitAbove = 0
-	}
-
-	wrSum, wrCount, err := sumAndCountWR(st, year, month, "", "", "retail_current_cumulative")
-	if err != nil {
-		return err
-	}
-	acSum, acCount, err := sumAndCountACDerivedRetailCumulative(st, year, month, "", false)
-	if err != nil {
-		return err
+	if constraints != nil {
+		// 上下限各自生效：只给出一侧时另一侧沿用业务配置
+		if constraints.MinIndividualRate != nil {
+			band.Min = math.Max(band.Min, *constraints.MinIndividualRate)
+		}
+		if constraints.MaxIndividualRate != nil {
+			band.Max = math.Min(band.Max, *constraints.MaxIndividualRate)
+		}
+		if constraints.MinIndividualMomRate != nil {
+			band.MomMin = math.Max(band.MomMin, *constraints.MinIndividualMomRate)
+		}
+		if constraints.MaxIndividualMomRate != nil {
+			band.MomMax = math.Min(band.MomMax, *constraints.MaxIndividualMomRate)
+		}
+		if len(constraints.PriorityIndustries) > 0 {
+			band.Priority = map[string]bool{}
+			for _, industry := range constraints.PriorityIndustries {
+				band.Priority[industry] = true
+			}
+		}
 	}
-	return scaleAcrossWRAndACDerivedRetail(st, year, month, "retail_current_cumulative", "food_current_cumulative", "goods_current_cumulative", wrSum, wrCount, acSum, acCount, desiredLimitAbove)
-}
 
-func computeMicroSmallRate(st *store.Store, year, month int) (float64, error) {
-	currentSum, _, err := sumAndCountWR(st, year, month, "", "is_small_micro", "retail_current_month")
-	if err != nil {
-		return 0, err
+	if band.Min > band.Max {
+		return band, fmt.Errorf("增速区间无效: 下限 %.1f%% 高于上限 %.1f%%", band.Min*100, band.Max*100)
 	}
-	lastYearSum, _, err := sumAndCountWR(st, year, month, "", "is_small_micro", "retail_last_year_month")
-	if err != nil {
-		return 0, err
-	}
-	if lastYearSum == 0 {
-		return 0, nil
+	if band.MomMin > band.MomMax {
+		return band, fmt.Errorf("环比增速区间无效: 下限 %.1f%% 高于上限 %.1f%%", band.MomMin*100, band.MomMax*100)
 	}
-	return (currentSum - lastYearSum) / lastYearSum * 100, nil
+	return band, nil
 }
 
-func sumAndCountWR(st *store.Store, year, month int, industryType string, flagField string, field string) (float64, int, error) {
-	where := "data_year = ? AND data_month = ?"
-	args := []interface{}{year, month}
-
-	if industryType != "" {
-		where += " AND industry_type = ?"
-		args = append(args, industryType)
-	}
-	if flagField != "" {
-		where += fmt.Sprintf(" AND %s = 1", flagField)
+// optimizeOptionsFor 校验求解模式与可调整企业范围
+func optimizeOptionsFor(req OptimizeRequest, band growthBand) (optimizeOptions, error) {
+	opts := optimizeOptions{band: band, mode: req.Mode, priorityOnly: req.PriorityOnly}
+	switch opts.mode {
+	case "":
+		opts.mode = optimizeModeProportional
+	case optimizeModeProportional, optimizeModeMinChange, optimizeModeMinRows:
+	default:
+		return opts, fmt.Errorf("不支持的求解模式: %s", req.Mode)
 	}
 
-	query := fmt.Sprintf("SELECT COALESCE(SUM(%s), 0), COUNT(1) FROM wholesale_retail WHERE %s", field, where)
-	var sum float64
-	var count int
-	if err := st.QueryRow(query, args...).Scan(&sum, &count); err != nil {
-		return 0, 0, err
+	if opts.priorityOnly && len(band.Priority) == 0 {
+		return opts, fmt.Errorf("priorityOnly 需要指定 constraints.priorityIndustries")
 	}
-	return sum, count, nil
-}
 
-func sumAndCountAC(st *store.Store, year, month int, industryType string, field string) (float64, int, error) {
-	where := "data_year = ? AND data_month = ?"
-	args := []interface{}{year, month}
-	if industryType != "" {
-		where += " AND industry_type = ?"
-		args = append(args, industryType)
-	}
-	query := fmt.Sprintf("SELECT COALESCE(SUM(%s), 0), COUNT(1) FROM accommodation_catering WHERE %s", field, where)
-	var sum float64
-	var count int
-	if err := st.QueryRow(query, args...).Scan(&sum, &count); err != nil {
-		return 0, 0, err
+	if len(req.CompanyIDs) > 0 {
+		opts.companyIDs = map[string]bool{}
+		for _, id := range req.CompanyIDs {
+			if _, _, ok := parseCompanyID(id); !ok {
+				return opts, fmt.Errorf("无效的企业ID: %s", id)
+			}
+			opts.companyIDs[id] = true
+		}
 	}
-	return sum, count, nil
+	return opts, nil
 }
 
-func sumAndCountACDerivedRetailMonth(st *store.Store, year, month int, industryType string, lastYear bool) (float64, int, error) {
-	where := "data_year = ? AND data_month = ?"
-	args := []interface{}{year, month}
-	if industryType != "" {
-		where += " AND industry_type = ?"
-		args = append(args, industryType)
-	}
-
-	expr := "food_current_month + goods_current_month"
-	if lastYear {
-		expr = "food_last_year_month + goods_last_year_month"
+// describeTargets 目标摘要（记入修改历史）
+func describeTargets(targets map[string]float64) string {
+	parts := make([]string, 0, len(targets))
+	for _, item := range orderTargets(targets) {
+		parts = append(parts, fmt.Sprintf("%s=%g", item.ID, item.Value))
 	}
-
-	query := fmt.Sprintf("SELECT COALESCE(SUM(%s), 0), COUNT(1) FROM accommodation_catering WHERE %s", expr, where)
-	var sum float64
-	var count int
-	if err := st.QueryRow(query, args...).Scan(&sum, &count); err != nil {
-		return 0, 0, err
-	}
-	return sum, count, nil
+	return strings.Join(parts, ", ")
 }
 
-func sumAndCountACDerivedRetailCumulative(st *store.Store, year, month int, industryType string, lastYear bool) (float64, int, error) {
-	where := "data_year = ? AND data_month = ?"
-	args := []interface{}{year, month}
-	if industryType != "" {
-		where += " AND industry_type = ?"
-		args = append(args, industryType)
-	}
-
-	expr := "food_current_cumulative + goods_current_cumulative"
-	if lastYear {
-		expr = "food_last_year_cumulative + goods_last_year_cumulative"
-	}
-
-	query := fmt.Sprintf("SELECT COALESCE(SUM(%s), 0), COUNT(1) FROM accommodation_catering WHERE %s", expr, where)
-	var sum float64
-	var count int
-	if err := st.QueryRow(query, args...).Scan(&sum, &count); err != nil {
-		return 0, 0, err
-	}
-	return sum, count, nil
+type orderedTarget struct {
+	ID    string
+	Value float64
+	hold  bool
 }
 
-func scaleAcrossWRAndAC(st *store.Store, year, month int, field string, wrSum float64, wrCount int, acSum float64, acCount int, target float64) error {
-	totalSum := wrSum + acSum
-	totalCount := wrCount + acCount
-	if totalCount == 0 {
-		return fmt.Errorf("没有可调整数据")
-	}
-
-	if totalSum == 0 {
-		perRow := 0.0
-		if target > 0 {
-			perRow = math.Round(target / float64(totalCount))
-		}
-		if wrCount > 0 {
-			if err := st.Exec(
-				fmt.Sprintf("UPDATE wholesale_retail SET %s = ? WHERE data_year = ? AND data_month = ?", field),
-				perRow, year, month,
-			); err != nil {
-				return err
-			}
-		}
-		if acCount > 0 {
-			if err := st.Exec(
-				fmt.Sprintf("UPDATE accommodation_catering SET %s = ? WHERE data_year = ? AND data_month = ?", field),
-				perRow, year, month,
-			); err != nil {
-				return err
-			}
+func orderTargets(targets map[string]float64) []orderedTarget {
+	out := make([]orderedTarget, 0, len(targets))
+	seen := map[string]bool{}
+	for _, def := range calculator.Registry {
+		if v, ok := targets[def.ID]; ok {
+			out = append(out, orderedTarget{ID: def.ID, Value: v})
+			seen[def.ID] = true
 		}
-		return nil
 	}
-
-	factor := target / totalSum
-	if wrCount > 0 {
-		if err := st.Exec(
-			fmt.Sprintf("UPDATE wholesale_retail SET %s = ROUND(%s * ?, 0) WHERE data_year = ? AND data_month = ?", field, field),
-			factor, year, month,
-		); err != nil {
-			return err
+	var rest []string
+	for id := range targets {
+		if !seen[id] {
+			rest = append(rest, id)
 		}
 	}
-	if acCount > 0 {
-		if err := st.Exec(
-			fmt.Sprintf("UPDATE accommodation_catering SET %s = ROUND(%s * ?, 0) WHERE data_year = ? AND data_month = ?", field, field),
-			factor, year, month,
-		); err != nil {
-			return err
-		}
+	sort.Strings(rest)
+	for _, id := range rest {
+		out = append(out, orderedTarget{ID: id, Value: targets[id]})
 	}
-	return nil
+	return out
 }
 
-func scaleAcrossWRAndACDerivedRetail(st *store.Store, year, month int, wrField string, acFoodField string, acGoodsField string, wrSum float64, wrCount int, acSum float64, acCount int, target float64) error {
-	totalSum := wrSum + acSum
-	totalCount := wrCount + acCount
-	if totalCount == 0 {
-		return fmt.Errorf("没有可调整数据")
-	}
-
-	// 全部为 0：按“人均”分摊到 WR 的目标字段，并把 AC 分配到 food 字段（goods 置 0）
-	if totalSum == 0 {
-		perRow := 0.0
-		if target > 0 {
-			perRow = math.Round(target / float64(totalCount))
-		}
-		if wrCount > 0 {
-			if err := st.Exec(
-				fmt.Sprintf("UPDATE wholesale_retail SET %s = ? WHERE data_year = ? AND data_month = ?", wrField),
-				perRow, year, month,
-			); err != nil {
-				return err
-			}
-		}
-		if acCount > 0 {
-			if err := st.Exec(
-				fmt.Sprintf("UPDATE accommodation_catering SET %s = ?, %s = 0 WHERE data_year = ? AND data_month = ?", acFoodField, acGoodsField),
-				perRow, year, month,
-			); err != nil {
-				return err
-			}
-		}
-		return nil
-	}
-
-	factor := target / totalSum
-	if wrCount > 0 {
-		if err := st.Exec(
-			fmt.Sprintf("UPDATE wholesale_retail SET %s = ROUND(%s * ?, 0) WHERE data_year = ? AND data_month = ?", wrField, wrField),
-			factor, year, month,
-		); err != nil {
-			return err
-		}
-	}
-	if acCount > 0 {
-		if err := st.Exec(
-			fmt.Sprintf(
-				"UPDATE accommodation_catering SET %s = ROUND(%s * ?, 0), %s = ROUND(%s * ?, 0) WHERE data_year = ? AND data_month = ?",
-				acFoodField, acFoodField, acGoodsField, acGoodsField,
-			),
-			factor, factor, year, month,
-		); err != nil {
-			return err
-		}
+// applyIndicatorTarget 不限制单企业增速，反推单个目标指标并写回
+func applyIndicatorTarget(st *store.Store, year, month int, id string, target float64) error {
+	plan, err := planTargets(st, year, month, map[string]float64{id: target}, optimizeOptions{band: unboundedBand()})
+	if err != nil {
+		return err
 	}
-	return nil
+	return plan.apply(st)
 }
 
-func scaleWRField(st *store.Store, year, month int, industryType string, flagField string, field string, currentSum float64, count int, target float64) error {
-	if count == 0 {
-		return fmt.Errorf("没有可调整数据")
+// buildEquation 按指标定义将目标转换为线性约束：分子汇总项的企业字段合计 = Desired
+func buildEquation(totals calculator.Totals, year, month int, id string, target float64, social calculator.SocialRetailInputs) (*targetEquation, error) {
+	if math.IsNaN(target) || math.IsInf(target, 0) {
+		return nil, fmt.Errorf("无效目标值: %s", id)
 	}
-	where := "data_year = ? AND data_month = ?"
-	args := []interface{}{year, month}
-
-	if industryType != "" {
-		where += " AND industry_type = ?"
-		args = append(args, industryType)
+	def, ok := calculator.Lookup(id)
+	if !ok {
+		return nil, fmt.Errorf("不支持的指标: %s", id)
 	}
-	if flagField != "" {
-		where += fmt.Sprintf(" AND %s = 1", flagField)
+
+	eq := &targetEquation{ID: id, Target: target, Field: def.Numerator[0].FieldList()[0]}
+	for _, term := range def.Numerator {
+		eq.Selectors = append(eq.Selectors, termSelector(year, month, term))
 	}
 
-	if currentSum == 0 {
-		perRow := 0.0
-		if target > 0 {
-			perRow = math.Round(target / float64(count))
+	if def.SocialRetailCell != "" {
+		// 社零额（定）：K21 = E18 + K15 + K16，K23 = (K21 - E20) / E20；限下估算 K15 不含本月限上（K16）
+		sr := social.Compute()
+		total := target
+		if def.IsRate() {
+			total = social.E20 * (1 + target/100)
 		}
-		argsWithValue := append([]interface{}{perRow}, args...)
-		return st.Exec(
-			fmt.Sprintf("UPDATE wholesale_retail SET %s = ? WHERE %s", field, where),
-			argsWithValue...,
-		)
-	}
-
-	factor := target / currentSum
-	argsWithFactor := append([]interface{}{factor}, args...)
-	return st.Exec(
-		fmt.Sprintf("UPDATE wholesale_retail SET %s = ROUND(%s * ?, 0) WHERE %s", field, field, where),
-		argsWithFactor...,
-	)
-}
-
-func scaleACField(st *store.Store, year, month int, industryType string, field string, currentSum float64, count int, target float64) error {
-	if count == 0 {
-		return fmt.Errorf("没有可调整数据")
+		eq.Desired = math.Max(0, (total-social.E18-sr.K15)*10) // 万元 → 千元
+		return eq, nil
 	}
-
-	where := "data_year = ? AND data_month = ?"
-	args := []interface{}{year, month}
-
-	if industryType != "" {
-		where += " AND industry_type = ?"
-		args = append(args, industryType)
+	if !def.IsRate() {
+		eq.Desired = math.Max(0, target)
+		return eq, nil
 	}
-
-	if currentSum == 0 {
-		perRow := 0.0
-		if target > 0 {
-			perRow = math.Round(target / float64(count))
-		}
-		argsWithValue := append([]interface{}{perRow}, args...)
-		return st.Exec(
-			fmt.Sprintf("UPDATE accommodation_catering SET %s = ? WHERE %s", field, where),
-			argsWithValue...,
-		)
-	}
-
-	factor := target / currentSum
-	argsWithFactor := append([]interface{}{factor}, args...)
-	return st.Exec(
-		fmt.Sprintf("UPDATE accommodation_catering SET %s = ROUND(%s * ?, 0) WHERE %s", field, field, where),
-		argsWithFactor...,
-	)
+	// 分子 / 分母 = 1 + target/100
+	eq.Desired = math.Max(0, totals.Sum(def.Denominator...)*(1+target/100))
+	return eq, nil
 }
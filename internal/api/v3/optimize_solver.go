@@ -0,0 +1,862 @@
+package v3
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"northstar/internal/calculator"
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+// growthBand 单企业增速区间（小数口径，0.5 表示 +50%）
+type growthBand struct {
+	Min      float64
+	Max      float64
+	MomMin   float64         // 环比增速下限（相对上月）
+	MomMax   float64         // 环比增速上限
+	Priority map[string]bool // 优先调整的行业；为空表示不区分
+}
+
+// unboundedBand 不限制增速（仅保证金额非负）
+func unboundedBand() growthBand {
+	return growthBand{Min: -1, Max: math.Inf(1), MomMin: -1, MomMax: math.Inf(1)}
+}
+
+// bounds 按上年同期（同比）与上月（环比）计算企业可调整区间，取两者交集；无对应基数时不限制该项，且金额非负
+func (b growthBand) bounds(base, prev float64) (float64, float64) {
+	lo, hi := 0.0, math.Inf(1)
+	if base > 0 {
+		lo = math.Max(lo, base*(1+b.Min))
+		hi = math.Min(hi, base*(1+b.Max))
+	}
+	if prev > 0 {
+		lo = math.Max(lo, prev*(1+b.MomMin))
+		hi = math.Min(hi, prev*(1+b.MomMax))
+	}
+	return lo, hi
+}
+
+// infeasibleError 目标在增速区间内不可达
+type infeasibleError struct {
+	IndicatorID  string  `json:"indicatorId,omitempty"`
+	Field        string  `json:"field"`
+	Target       float64 `json:"target"`
+	MinReachable float64 `json:"minReachable"`
+	MaxReachable float64 `json:"maxReachable"`
+	MinRate      float64 `json:"minRate"` // %
+	MaxRate      float64 `json:"maxRate"` // %
+	Companies    int     `json:"companies"`
+}
+
+func (e *infeasibleError) Error() string {
+	maxReachable := "∞"
+	if !math.IsInf(e.MaxReachable, 1) {
+		maxReachable = fmt.Sprintf("%.0f", e.MaxReachable)
+	}
+	maxRate := "∞"
+	if !math.IsInf(e.MaxRate, 1) {
+		maxRate = fmt.Sprintf("%.1f%%", e.MaxRate)
+	}
+	return fmt.Sprintf(
+		"目标不可达：单企业增速区间 [%.1f%%, %s] 内 %s 合计可达范围为 [%.0f, %s]，目标为 %.0f",
+		e.MinRate, maxRate, e.Field, e.MinReachable, maxReachable, e.Target,
+	)
+}
+
+// adjustRow 可调整的企业行（住餐零售额口径为 food + goods 两个字段）
+type adjustRow struct {
+	table    string
+	id       int64
+	name     string
+	industry string
+	fields   []string
+	parts    []float64
+	base     float64
+	prev     float64 // 上月值（仅当月字段有）
+}
+
+func (r *adjustRow) companyID() string {
+	if r.table == "accommodation_catering" {
+		return fmt.Sprintf("ac:%d", r.id)
+	}
+	return fmt.Sprintf("wr:%d", r.id)
+}
+
+func (r *adjustRow) value() float64 {
+	var v float64
+	for _, p := range r.parts {
+		v += p
+	}
+	return v
+}
+
+func loadAdjustRows(st *store.Store, table, where string, args []interface{}, fields []string) ([]*adjustRow, error) {
+	baseExpr := make([]string, 0, len(fields))
+	prevExpr := make([]string, 0, len(fields))
+	for _, f := range fields {
+		baseExpr = append(baseExpr, calculator.LastYearField(f))
+		if p := calculator.PrevMonthField(f); p != "" {
+			prevExpr = append(prevExpr, "COALESCE("+p+", 0)")
+		}
+	}
+	if len(prevExpr) < len(fields) {
+		prevExpr = []string{"0"}
+	}
+	query := fmt.Sprintf(
+		"SELECT id, COALESCE(name, ''), COALESCE(industry_type, ''), %s, %s, %s FROM %s WHERE %s",
+		strings.Join(fields, ", "), strings.Join(baseExpr, " + "), strings.Join(prevExpr, " + "), table, where,
+	)
+	rs, err := st.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	var out []*adjustRow
+	for rs.Next() {
+		r := &adjustRow{table: table, fields: fields, parts: make([]float64, len(fields))}
+		dest := []interface{}{&r.id, &r.name, &r.industry}
+		for i := range r.parts {
+			dest = append(dest, &r.parts[i])
+		}
+		dest = append(dest, &r.base, &r.prev)
+		if err := rs.Scan(dest...); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rs.Err()
+}
+
+func writeAdjustRows(st *store.Store, rows []*adjustRow, values []float64) error {
+	tx, err := st.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, r := range rows {
+		old := r.value()
+		if values[i] == old {
+			continue
+		}
+		parts := splitParts(r.parts, values[i])
+		setClauses := make([]string, 0, len(r.fields))
+		args := make([]interface{}, 0, len(r.fields)+1)
+		for j, f := range r.fields {
+			setClauses = append(setClauses, f+" = ?")
+			args = append(args, parts[j])
+		}
+		args = append(args, r.id)
+		if _, err := tx.Exec(
+			fmt.Sprintf("UPDATE %s SET %s WHERE id = ?", r.table, strings.Join(setClauses, ", ")),
+			args...,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// splitParts 按原构成比例拆分新值；原值全为 0 时全部计入第一个字段
+func splitParts(parts []float64, total float64) []float64 {
+	out := make([]float64, len(parts))
+	var old float64
+	for _, p := range parts {
+		old += p
+	}
+	if old == 0 {
+		out[0] = total
+		return out
+	}
+	var assigned float64
+	for j := 0; j < len(parts)-1; j++ {
+		out[j] = math.Round(parts[j] * total / old)
+		assigned += out[j]
+	}
+	out[len(parts)-1] = total - assigned
+	return out
+}
+
+// rowSelector 目标指标涉及的企业行与字段
+type rowSelector struct {
+	table  string
+	where  string
+	args   []interface{}
+	fields []string
+}
+
+// termSelector 指标汇总项对应的企业行与字段
+func termSelector(year, month int, term calculator.Term) rowSelector {
+	where := "data_year = ? AND data_month = ?"
+	args := []interface{}{year, month}
+	if term.IndustryType != "" {
+		where += " AND industry_type = ?"
+		args = append(args, term.IndustryType)
+	}
+	if term.Flag != "" {
+		where += fmt.Sprintf(" AND %s = 1", term.Flag)
+	}
+	return rowSelector{table: term.Table, where: where, args: args, fields: term.FieldList()}
+}
+
+// targetEquation 目标指标对应的线性约束
+type targetEquation struct {
+	ID        string
+	Target    float64
+	Field     string
+	Desired   float64
+	Selectors []rowSelector
+	members   []int
+	hold      bool // 仅为保持社零总额方程线性而固定的合计，不检查可达性
+}
+
+// targetConflict 作用于同一组企业字段但要求不同合计的两个目标
+type targetConflict struct {
+	A        string  `json:"a"`
+	B        string  `json:"b"`
+	DesiredA float64 `json:"desiredA"`
+	DesiredB float64 `json:"desiredB"`
+}
+
+// targetConflictError 目标相互矛盾
+type targetConflictError struct {
+	Pairs []targetConflict
+}
+
+func (e *targetConflictError) Error() string {
+	names := make([]string, 0, len(e.Pairs))
+	for _, p := range e.Pairs {
+		names = append(names, p.A+" / "+p.B)
+	}
+	return "目标相互矛盾，无法同时满足: " + strings.Join(names, "; ")
+}
+
+// optimizePlan 联合求解结果（尚未写回）
+type optimizePlan struct {
+	vars     []*adjustRow
+	values   []float64
+	warnings []string
+}
+
+func (p *optimizePlan) apply(st *store.Store) error {
+	return writeAdjustRows(st, p.vars, p.values)
+}
+
+// adjustments 列出值发生变化的企业字段
+func (p *optimizePlan) adjustments() []model.CompanyAdjustment {
+	out := []model.CompanyAdjustment{}
+	for i, r := range p.vars {
+		orig := r.value()
+		if p.values[i] == orig {
+			continue
+		}
+		adj := model.CompanyAdjustment{
+			CompanyID:     r.companyID(),
+			CompanyName:   r.name,
+			Field:         strings.Join(r.fields, "+"),
+			OriginalValue: orig,
+			AdjustedValue: p.values[i],
+		}
+		if orig != 0 {
+			adj.ChangePercent = (p.values[i] - orig) / orig
+		}
+		out = append(out, adj)
+	}
+	return out
+}
+
+// summarizeAdjustments 汇总调整企业数、总变动量与平均变动幅度
+func summarizeAdjustments(adjustments []model.CompanyAdjustment) model.OptimizeSummary {
+	var summary model.OptimizeSummary
+	companies := map[string]bool{}
+	for _, a := range adjustments {
+		companies[a.CompanyID] = true
+		summary.TotalAdjustment += math.Abs(a.AdjustedValue - a.OriginalValue)
+		summary.AverageChangePercent += a.ChangePercent
+	}
+	summary.AdjustedCount = len(companies)
+	if len(adjustments) > 0 {
+		summary.AverageChangePercent /= float64(len(adjustments))
+	}
+	return summary
+}
+
+// sumTolerance 合计层面的容差（千元，来自整数取整）
+const sumTolerance = 0.5
+
+// 智能调整求解模式
+const (
+	optimizeModeProportional = "proportional" // 按当前值等比例分摊（最小二乘）
+	optimizeModeMinChange    = "min_change"   // 最小化总变动量
+	optimizeModeMinRows      = "min_rows"     // 最少调整企业数
+)
+
+// optimizeOptions 求解选项
+type optimizeOptions struct {
+	band         growthBand
+	mode         string
+	companyIDs   map[string]bool // 仅调整这些企业（wr:<id>/ac:<id>）；为空表示不限
+	priorityOnly bool            // 仅调整 band.Priority 中的行业
+}
+
+// planTargets 将所有目标转换为线性约束并在增速区间内联合求解
+func planTargets(st *store.Store, year, month int, targets map[string]float64, opts optimizeOptions) (*optimizePlan, error) {
+	totals, err := calculator.NewCalculator(st).Totals(year, month)
+	if err != nil {
+		return nil, err
+	}
+	// 限下社零额估算所用增速：同时设定了该指标目标时按目标值，
+	// 否则在社零总额目标下保持其分子合计不变（本月限上调整不改变限下估算，方程保持线性）
+	social := totals.SocialRetailInputs()
+	var holds []string
+	for _, id := range calculator.SocialRetailRates {
+		if v, ok := targets[id]; ok {
+			social.OverrideRate(id, v)
+		} else {
+			holds = append(holds, id)
+		}
+	}
+	if !hasSocialRetailTarget(targets) {
+		holds = nil
+	}
+
+	plan := &optimizePlan{warnings: []string{}}
+	index := map[string]int{}
+	var eqs []*targetEquation
+	items := orderTargets(targets)
+	for _, id := range holds {
+		def, _ := calculator.Lookup(id)
+		items = append(items, orderedTarget{ID: id, Value: totals.Evaluate(def), hold: true})
+	}
+	for _, item := range items {
+		eq, err := buildEquation(totals, year, month, item.ID, item.Value, social)
+		if err != nil {
+			return nil, err
+		}
+		if item.hold {
+			def, _ := calculator.Lookup(item.ID)
+			eq.Desired, eq.hold = totals.Sum(def.Numerator...), true
+		}
+		seen := map[int]bool{}
+		for _, sel := range eq.Selectors {
+			rows, err := loadAdjustRows(st, sel.table, sel.where, sel.args, sel.fields)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rows {
+				key := fmt.Sprintf("%s:%d:%s", r.table, r.id, strings.Join(r.fields, "+"))
+				idx, ok := index[key]
+				if !ok {
+					idx = len(plan.vars)
+					index[key] = idx
+					plan.vars = append(plan.vars, r)
+				}
+				if !seen[idx] {
+					seen[idx] = true
+					eq.members = append(eq.members, idx)
+				}
+			}
+		}
+		if len(eq.members) == 0 {
+			return nil, fmt.Errorf("没有可调整数据: %s", eq.ID)
+		}
+		sort.Ints(eq.members)
+		eqs = append(eqs, eq)
+	}
+
+	if err := detectConflicts(eqs); err != nil {
+		return nil, err
+	}
+
+	band := opts.band
+	lo := make([]float64, len(plan.vars))
+	hi := make([]float64, len(plan.vars))
+	eligible := make([]bool, len(plan.vars))
+	for i, r := range plan.vars {
+		lo[i], hi[i] = band.bounds(r.base, r.prev)
+		eligible[i] = len(opts.companyIDs) == 0 || opts.companyIDs[r.companyID()]
+		// 同比与环比区间无交集的企业保持原值
+		eligible[i] = eligible[i] && lo[i] <= hi[i]
+		if opts.priorityOnly && len(band.Priority) > 0 {
+			eligible[i] = eligible[i] && band.Priority[r.industry]
+		}
+	}
+	for _, eq := range eqs {
+		if eq.hold {
+			continue
+		}
+		if err := checkReachable(plan.vars, eq, eligible, lo, hi, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	solve := func(free []bool) ([]float64, []float64) {
+		switch opts.mode {
+		case optimizeModeMinChange:
+			return solveMinimal(plan.vars, eqs, free, lo, hi, false)
+		case optimizeModeMinRows:
+			return solveMinimal(plan.vars, eqs, free, lo, hi, true)
+		default:
+			return solveJoint(plan.vars, eqs, free, lo, hi)
+		}
+	}
+
+	var residuals []float64
+	if len(band.Priority) > 0 && !opts.priorityOnly {
+		free := make([]bool, len(plan.vars))
+		for i, r := range plan.vars {
+			free[i] = eligible[i] && band.Priority[r.industry]
+		}
+		plan.values, residuals = solve(free)
+		if maxAbs(residuals) <= sumTolerance {
+			return plan, nil
+		}
+	}
+
+	plan.values, residuals = solve(eligible)
+	for k, eq := range eqs {
+		if math.Abs(residuals[k]) > sumTolerance {
+			plan.warnings = append(plan.warnings, fmt.Sprintf(
+				"%s 与其他目标或增速区间无法同时满足，%s 合计偏差 %.0f",
+				eq.ID, eq.Field, residuals[k],
+			))
+		}
+	}
+	return plan, nil
+}
+
+// hasSocialRetailTarget 是否设定了按 社零额（定） 计算的指标目标
+func hasSocialRetailTarget(targets map[string]float64) bool {
+	for id := range targets {
+		if def, ok := calculator.Lookup(id); ok && def.SocialRetailCell != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReachable 在可调整范围内检查单个目标是否可达；不可调整的企业保持原值
+func checkReachable(vars []*adjustRow, eq *targetEquation, eligible []bool, lo, hi []float64, opts optimizeOptions) error {
+	var loSum, hiSum float64
+	companies := 0
+	for _, i := range eq.members {
+		v := vars[i].value()
+		if !eligible[i] {
+			loSum += v
+			hiSum += v
+			continue
+		}
+		companies++
+		if opts.mode == optimizeModeMinChange || opts.mode == optimizeModeMinRows {
+			// 最小变动模式下未触及的企业保持原值，即便原值已在区间外
+			loSum += math.Min(lo[i], v)
+			hiSum += math.Max(hi[i], v)
+		} else {
+			loSum += lo[i]
+			hiSum += hi[i]
+		}
+	}
+	if companies > 0 && eq.Desired >= loSum-sumTolerance && eq.Desired <= hiSum+sumTolerance {
+		return nil
+	}
+	return &infeasibleError{
+		IndicatorID:  eq.ID,
+		Field:        eq.Field,
+		Target:       eq.Desired,
+		MinReachable: loSum,
+		MaxReachable: hiSum,
+		MinRate:      opts.band.Min * 100,
+		MaxRate:      opts.band.Max * 100,
+		Companies:    companies,
+	}
+}
+
+// detectConflicts 作用于同一组变量但合计要求不同的目标视为矛盾
+func detectConflicts(eqs []*targetEquation) error {
+	var pairs []targetConflict
+	for a := 0; a < len(eqs); a++ {
+		for b := a + 1; b < len(eqs); b++ {
+			if !sameMembers(eqs[a].members, eqs[b].members) {
+				continue
+			}
+			if math.Abs(eqs[a].Desired-eqs[b].Desired) > sumTolerance {
+				pairs = append(pairs, targetConflict{
+					A: eqs[a].ID, B: eqs[b].ID,
+					DesiredA: math.Round(eqs[a].Desired), DesiredB: math.Round(eqs[b].Desired),
+				})
+			}
+		}
+	}
+	if len(pairs) > 0 {
+		return &targetConflictError{Pairs: pairs}
+	}
+	return nil
+}
+
+func sameMembers(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// solveJoint 最小加权变动求解：min Σ (x_i-c_i)²/w_i，s.t. 各目标合计，lo ≤ x ≤ hi。
+// 无界时解为 x_i = c_i + w_i·Σλ_k（w_i 取当前值，单目标即等比例缩放）；越界变量固定在边界后重解。
+func solveJoint(vars []*adjustRow, eqs []*targetEquation, free []bool, lo, hi []float64) ([]float64, []float64) {
+	n, k := len(vars), len(eqs)
+	current := make([]float64, n)
+	weight := make([]float64, n)
+	for i, r := range vars {
+		current[i] = r.value()
+		switch {
+		case current[i] > 0:
+			weight[i] = current[i]
+		case r.base > 0:
+			weight[i] = r.base
+		default:
+			weight[i] = 1
+		}
+	}
+
+	varEqs := make([][]int, n)
+	for e, eq := range eqs {
+		for _, i := range eq.members {
+			varEqs[i] = append(varEqs[i], e)
+		}
+	}
+
+	values := append([]float64(nil), current...)
+	fixed := make([]bool, n)
+	for iter := 0; iter <= n; iter++ {
+		m := make([][]float64, k)
+		r := make([]float64, k)
+		for e, eq := range eqs {
+			m[e] = make([]float64, k)
+			r[e] = eq.Desired
+			for _, i := range eq.members {
+				if free[i] && !fixed[i] {
+					r[e] -= current[i]
+				} else {
+					r[e] -= values[i]
+				}
+			}
+		}
+		for i := 0; i < n; i++ {
+			if !free[i] || fixed[i] {
+				continue
+			}
+			for _, a := range varEqs[i] {
+				for _, b := range varEqs[i] {
+					m[a][b] += weight[i]
+				}
+			}
+		}
+
+		lambda := solveRegularized(m, r)
+
+		violated := false
+		for i := 0; i < n; i++ {
+			if !free[i] || fixed[i] {
+				continue
+			}
+			v := current[i]
+			for _, e := range varEqs[i] {
+				v += weight[i] * lambda[e]
+			}
+			switch {
+			case v < lo[i]:
+				values[i], fixed[i], violated = lo[i], true, true
+			case v > hi[i]:
+				values[i], fixed[i], violated = hi[i], true, true
+			default:
+				values[i] = v
+			}
+		}
+		if !violated {
+			break
+		}
+	}
+
+	roundJoint(values, eqs, varEqs, free, lo, hi)
+
+	residuals := make([]float64, k)
+	for e, eq := range eqs {
+		var sum float64
+		for _, i := range eq.members {
+			sum += values[i]
+		}
+		residuals[e] = sum - eq.Desired
+	}
+	return values, residuals
+}
+
+// solveMinimal 最小变动求解：按成员数从少到多依次处理各目标，仅在尚未被其他目标锁定的企业中分摊差额，
+// 且所有调整同向（总变动量即为差额本身）。minRows 为 true 时优先调整可调空间最大的企业以减少调整家数，
+// 否则按当前值比例分摊。未被调整的企业保持原值。
+func solveMinimal(vars []*adjustRow, eqs []*targetEquation, free []bool, lo, hi []float64, minRows bool) ([]float64, []float64) {
+	n := len(vars)
+	values := make([]float64, n)
+	for i, r := range vars {
+		values[i] = r.value()
+	}
+
+	order := make([]int, len(eqs))
+	for e := range order {
+		order[e] = e
+	}
+	sort.SliceStable(order, func(a, b int) bool { return len(eqs[order[a]].members) < len(eqs[order[b]].members) })
+
+	locked := make([]bool, n)
+	for _, e := range order {
+		eq := eqs[e]
+		var sum float64
+		var cand []int
+		for _, i := range eq.members {
+			sum += values[i]
+			if free[i] && !locked[i] {
+				cand = append(cand, i)
+			}
+		}
+		diff := math.Round(eq.Desired - sum)
+		if diff != 0 && len(cand) > 0 {
+			sign := 1.0
+			if diff < 0 {
+				sign = -1
+			}
+			capacity := make([]float64, len(cand))
+			for j, i := range cand {
+				if sign > 0 {
+					capacity[j] = math.Max(0, math.Floor(hi[i]-values[i]))
+				} else {
+					capacity[j] = math.Max(0, math.Floor(values[i]-lo[i]))
+				}
+			}
+			var delta []float64
+			if minRows {
+				delta = fillLargestFirst(capacity, math.Abs(diff))
+			} else {
+				weight := make([]float64, len(cand))
+				for j, i := range cand {
+					weight[j] = math.Max(values[i], 1)
+				}
+				delta = fillProportional(capacity, weight, math.Abs(diff))
+			}
+			for j, i := range cand {
+				values[i] += sign * delta[j]
+			}
+		}
+		for _, i := range eq.members {
+			locked[i] = true
+		}
+	}
+
+	residuals := make([]float64, len(eqs))
+	for e, eq := range eqs {
+		var sum float64
+		for _, i := range eq.members {
+			sum += values[i]
+		}
+		residuals[e] = sum - eq.Desired
+	}
+	return values, residuals
+}
+
+// fillLargestFirst 按可调空间从大到小依次填满，返回各企业的整数调整量
+func fillLargestFirst(capacity []float64, amount float64) []float64 {
+	idx := make([]int, len(capacity))
+	for j := range idx {
+		idx[j] = j
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return capacity[idx[a]] > capacity[idx[b]] })
+
+	out := make([]float64, len(capacity))
+	for _, j := range idx {
+		if amount <= 0 {
+			break
+		}
+		take := math.Min(capacity[j], amount)
+		out[j] = take
+		amount -= take
+	}
+	return out
+}
+
+// fillProportional 按权重分摊并以可调空间为上限（注水法），最大余数法取整
+func fillProportional(capacity, weight []float64, amount float64) []float64 {
+	out := make([]float64, len(capacity))
+	var active []int
+	for j := range capacity {
+		if capacity[j] > 0 {
+			active = append(active, j)
+		}
+	}
+	remaining := amount
+	for len(active) > 0 && remaining > 0 {
+		var wsum float64
+		for _, j := range active {
+			wsum += weight[j]
+		}
+		var next []int
+		spent := 0.0
+		for _, j := range active {
+			if remaining*weight[j]/wsum >= capacity[j] {
+				out[j] = capacity[j]
+				spent += capacity[j]
+			} else {
+				next = append(next, j)
+			}
+		}
+		if len(next) == len(active) {
+			for _, j := range active {
+				out[j] = remaining * weight[j] / wsum
+			}
+			remaining = 0
+			break
+		}
+		remaining -= spent
+		active = next
+	}
+
+	target := amount - remaining
+	var assigned float64
+	type frac struct {
+		j int
+		f float64
+	}
+	var fracs []frac
+	for j := range out {
+		whole := math.Floor(out[j])
+		fracs = append(fracs, frac{j, out[j] - whole})
+		out[j] = whole
+		assigned += whole
+	}
+	sort.SliceStable(fracs, func(a, b int) bool { return fracs[a].f > fracs[b].f })
+	left := math.Round(target - assigned)
+	for _, fr := range fracs {
+		if left < 1 {
+			break
+		}
+		if out[fr.j]+1 <= capacity[fr.j] {
+			out[fr.j]++
+			left--
+		}
+	}
+	return out
+}
+
+// roundJoint 变量取整后，用仅属于单个目标的变量逐一 ±1 抵消取整误差
+func roundJoint(values []float64, eqs []*targetEquation, varEqs [][]int, free []bool, lo, hi []float64) {
+	for i := range values {
+		if !free[i] {
+			continue
+		}
+		v := math.Round(values[i])
+		if v < math.Ceil(lo[i]) {
+			v = math.Ceil(lo[i])
+		}
+		if v > math.Floor(hi[i]) && math.Floor(hi[i]) >= math.Ceil(lo[i]) {
+			v = math.Floor(hi[i])
+		}
+		values[i] = v
+	}
+
+	for _, eq := range eqs {
+		var sum float64
+		var exclusive []int
+		for _, i := range eq.members {
+			sum += values[i]
+			if free[i] && len(varEqs[i]) == 1 {
+				exclusive = append(exclusive, i)
+			}
+		}
+		diff := math.Round(eq.Desired - sum)
+		if diff == 0 || len(exclusive) == 0 {
+			continue
+		}
+		sort.SliceStable(exclusive, func(a, b int) bool { return values[exclusive[a]] > values[exclusive[b]] })
+		step := 1.0
+		if diff < 0 {
+			step = -1
+		}
+		for pass := 0; pass < 2 && diff != 0; pass++ {
+			for _, i := range exclusive {
+				if diff == 0 {
+					break
+				}
+				next := values[i] + step
+				if next < lo[i] || next > hi[i] {
+					continue
+				}
+				values[i] = next
+				diff -= step
+			}
+		}
+	}
+}
+
+// solveRegularized 高斯消元求解 (M + εI)λ = r；ε 保证目标重复或全部变量被固定时仍可求解
+func solveRegularized(m [][]float64, r []float64) []float64 {
+	k := len(r)
+	scale := 0.0
+	for i := 0; i < k; i++ {
+		scale = math.Max(scale, m[i][i])
+	}
+	eps := 1e-9 * math.Max(scale, 1)
+
+	a := make([][]float64, k)
+	for i := range a {
+		a[i] = make([]float64, k+1)
+		copy(a[i], m[i])
+		a[i][i] += eps
+		a[i][k] = r[i]
+	}
+
+	for col := 0; col < k; col++ {
+		pivot := col
+		for row := col + 1; row < k; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		if a[col][col] == 0 {
+			continue
+		}
+		for row := col + 1; row < k; row++ {
+			f := a[row][col] / a[col][col]
+			for c := col; c <= k; c++ {
+				a[row][c] -= f * a[col][c]
+			}
+		}
+	}
+
+	x := make([]float64, k)
+	for row := k - 1; row >= 0; row-- {
+		if a[row][row] == 0 {
+			continue
+		}
+		v := a[row][k]
+		for c := row + 1; c < k; c++ {
+			v -= a[row][c] * x[c]
+		}
+		x[row] = v / a[row][row]
+	}
+	return x
+}
+
+func maxAbs(values []float64) float64 {
+	m := 0.0
+	for _, v := range values {
+		m = math.Max(m, math.Abs(v))
+	}
+	return m
+}
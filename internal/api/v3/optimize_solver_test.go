@@ -0,0 +1,416 @@
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+func newBoundedOptimizeStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	if err := st.SetCurrentYearMonth(2025, 12); err != nil {
+		t.Fatalf("set ym: %v", err)
+	}
+
+	for i, cur := range []float64{100, 125, 100} {
+		if err := st.Exec(`
+			INSERT INTO wholesale_retail (
+				credit_code, name, industry_code, industry_type, company_scale, row_no,
+				data_year, data_month,
+				sales_current_month, sales_last_year_month,
+				source_sheet, source_file
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, "C"+string(rune('A'+i)), "企业", "5101", "wholesale", 1, i+1, 2025, 12, cur, 100, "批发", "test.xlsx"); err != nil {
+			t.Fatalf("insert wr: %v", err)
+		}
+	}
+	return st
+}
+
+func postOptimize(t *testing.T, st *store.Store, payload map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, "/api/optimize", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestOptimize_RespectsIndividualGrowthBand(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+
+	w := postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_month_rate": 20},
+		"constraints": map[string]any{"maxIndividualRate": 0.3, "minIndividualRate": 0},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	rows, err := st.Query("SELECT sales_current_month FROM wholesale_retail ORDER BY row_no")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+
+	var sum float64
+	for rows.Next() {
+		var v float64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		if v > 130 || v < 100 {
+			t.Fatalf("company value %.0f outside band [100, 130]", v)
+		}
+		sum += v
+	}
+	if sum != 360 {
+		t.Fatalf("industry total not reached: got %.0f want 360", sum)
+	}
+}
+
+func TestOptimize_SingleSidedGrowthBound(t *testing.T) {
+	// 只给上限：下限沿用业务配置（-30%），允许下调
+	st := newBoundedOptimizeStore(t)
+	w := postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_month_rate": -5},
+		"constraints": map[string]any{"maxIndividualRate": 0.3},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("max-only bound: %d body=%s", w.Code, w.Body.String())
+	}
+	var sum float64
+	if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&sum); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if sum != 285 {
+		t.Fatalf("max-only bound: sum=%.0f want 285", sum)
+	}
+
+	// 只给下限：上限沿用业务配置（+50%），允许上调
+	st = newBoundedOptimizeStore(t)
+	w = postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_month_rate": 20},
+		"constraints": map[string]any{"minIndividualRate": -0.1},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("min-only bound: %d body=%s", w.Code, w.Body.String())
+	}
+	rows, err := st.Query("SELECT sales_current_month FROM wholesale_retail")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	sum = 0
+	for rows.Next() {
+		var v float64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		if v < 90 {
+			t.Fatalf("company value %.0f below min bound 90", v)
+		}
+		sum += v
+	}
+	if sum != 360 {
+		t.Fatalf("min-only bound: sum=%.0f want 360", sum)
+	}
+}
+
+func TestOptimize_InfeasibleBandReportsRange(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+
+	w := postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_month_rate": 50},
+		"constraints": map[string]any{"maxIndividualRate": 0.3, "minIndividualRate": 0},
+	})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Infeasible infeasibleError `json:"infeasible"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Infeasible.MaxReachable != 390 || resp.Infeasible.IndicatorID != "wholesale_month_rate" {
+		t.Fatalf("unexpected infeasibility report: %+v", resp.Infeasible)
+	}
+
+	var untouched float64
+	if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&untouched); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if untouched != 325 {
+		t.Fatalf("infeasible target must not modify data, sum=%.0f", untouched)
+	}
+}
+
+func newJointOptimizeStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	if err := st.SetCurrentYearMonth(2025, 12); err != nil {
+		t.Fatalf("set ym: %v", err)
+	}
+
+	// 第 1 家为小微企业（规模 3）
+	for i, scale := range []int{3, 1, 1} {
+		if err := st.Exec(`
+			INSERT INTO wholesale_retail (
+				credit_code, name, industry_code, industry_type, company_scale, row_no,
+				data_year, data_month,
+				sales_current_month, sales_last_year_month,
+				retail_current_month, retail_last_year_month,
+				source_sheet, source_file
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, "J"+string(rune('A'+i)), "企业", "5201", "retail", scale, i+1, 2025, 12, 200, 200, 100, 100, "零售", "test.xlsx"); err != nil {
+			t.Fatalf("insert wr: %v", err)
+		}
+	}
+	return st
+}
+
+func TestOptimize_JointTargetsReachedTogether(t *testing.T) {
+	st := newJointOptimizeStore(t)
+
+	w := postOptimize(t, st, map[string]any{
+		"targets": map[string]float64{
+			"limitAbove_month_rate": 10,
+			"microSmall_month_rate": 20,
+		},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Targets []targetOutcome `json:"targets"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Targets) != 2 {
+		t.Fatalf("expected 2 target outcomes, got %+v", resp.Targets)
+	}
+	for _, o := range resp.Targets {
+		if !o.Reached {
+			t.Fatalf("target not reached jointly: %+v", o)
+		}
+	}
+}
+
+func TestOptimize_InconsistentTargetsRefused(t *testing.T) {
+	st := newJointOptimizeStore(t)
+
+	w := postOptimize(t, st, map[string]any{
+		"targets": map[string]float64{
+			"limitAbove_month_value": 500,
+			"limitAbove_month_rate":  10,
+		},
+	})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	var sum float64
+	if err := st.QueryRow("SELECT SUM(retail_current_month) FROM wholesale_retail").Scan(&sum); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if sum != 300 {
+		t.Fatalf("refused optimize must not modify data, sum=%.0f", sum)
+	}
+}
+
+func TestOptimize_MinRowsTouchesFewestCompanies(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+
+	w := postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_month_rate": 20},
+		"constraints": map[string]any{"maxIndividualRate": 0.3, "minIndividualRate": 0},
+		"mode":        "min_rows",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Adjustments []model.CompanyAdjustment `json:"adjustments"`
+		Summary     model.OptimizeSummary     `json:"summary"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// 差额 35：一家补满 30，另一家补 5
+	if resp.Summary.AdjustedCount != 2 || resp.Summary.TotalAdjustment != 35 {
+		t.Fatalf("unexpected summary: %+v adjustments=%+v", resp.Summary, resp.Adjustments)
+	}
+
+	var sum float64
+	if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&sum); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if sum != 360 {
+		t.Fatalf("industry total not reached: got %.0f want 360", sum)
+	}
+}
+
+func TestOptimize_MinChangeRestrictedToCompanyIDs(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+
+	w := postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_month_rate": 20},
+		"constraints": map[string]any{"maxIndividualRate": 0.3, "minIndividualRate": 0},
+		"mode":        "min_change",
+		"companyIds":  []string{"wr:1", "wr:3"},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	var untouched float64
+	if err := st.QueryRow("SELECT sales_current_month FROM wholesale_retail WHERE id = 2").Scan(&untouched); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if untouched != 125 {
+		t.Fatalf("company outside companyIds was adjusted: %.0f", untouched)
+	}
+
+	var sum float64
+	if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&sum); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if sum != 360 {
+		t.Fatalf("industry total not reached: got %.0f want 360", sum)
+	}
+
+	// 仅剩一家可调整时超出区间，应报告不可达
+	w = postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_month_rate": 30},
+		"constraints": map[string]any{"maxIndividualRate": 0.3, "minIndividualRate": 0},
+		"mode":        "min_change",
+		"companyIds":  []string{"wr:2"},
+	})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestOptimize_MomTargetRespectsMomBand(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+	prev := []float64{90, 120, 100}
+	for i, v := range prev {
+		if err := st.Exec("UPDATE wholesale_retail SET sales_prev_month = ? WHERE row_no = ?", v, i+1); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+
+	// 环比目标 10%：上月合计 310 → 本月合计 341；单企业环比不超过 12%
+	w := postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_mom_rate": 10},
+		"constraints": map[string]any{"maxIndividualMomRate": 0.12, "minIndividualMomRate": 0},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	var sum float64
+	for i, p := range prev {
+		var v float64
+		if err := st.QueryRow("SELECT sales_current_month FROM wholesale_retail WHERE row_no = ?", i+1).Scan(&v); err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		if v < p || v > p*1.12 {
+			t.Fatalf("row %d value %.0f outside mom band [%.0f, %.1f]", i+1, v, p, p*1.12)
+		}
+		sum += v
+	}
+	if sum != 341 {
+		t.Fatalf("mom target not reached: got %.0f want 341", sum)
+	}
+
+	rec, err := st.GetWRByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := toCompanyRowWR(*rec)
+	if row.SalesMomRate == nil || *row.SalesMomRate != math.Round((rec.SalesCurrentMonth-90)/90*100) {
+		t.Fatalf("salesMomRate = %v", row.SalesMomRate)
+	}
+	if row.RetailMomRate != nil {
+		t.Fatalf("retailMomRate without prev month should be empty, got %v", *row.RetailMomRate)
+	}
+}
+
+func TestOptimize_SingleSidedMomBound(t *testing.T) {
+	// 只给环比上限：不应禁止环比下降。上月 {130, 150, 120}，目标环比 -10% → 360
+	st := newBoundedOptimizeStore(t)
+	for i, v := range []float64{130, 150, 120} {
+		if err := st.Exec("UPDATE wholesale_retail SET sales_prev_month = ? WHERE row_no = ?", v, i+1); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+	w := postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_mom_rate": -10},
+		"constraints": map[string]any{"maxIndividualMomRate": 0.2},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("max-only mom bound: %d body=%s", w.Code, w.Body.String())
+	}
+	var sum float64
+	if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&sum); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if sum != 360 {
+		t.Fatalf("max-only mom bound: sum=%.0f want 360", sum)
+	}
+
+	// 只给环比下限：不应封顶环比增长。上月 {90, 100, 100}，目标环比 +20% → 348
+	st = newBoundedOptimizeStore(t)
+	for i, v := range []float64{90, 100, 100} {
+		if err := st.Exec("UPDATE wholesale_retail SET sales_prev_month = ? WHERE row_no = ?", v, i+1); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+	w = postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_mom_rate": 20},
+		"constraints": map[string]any{"minIndividualMomRate": -0.05},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("min-only mom bound: %d body=%s", w.Code, w.Body.String())
+	}
+	if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&sum); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if sum != 348 {
+		t.Fatalf("min-only mom bound: sum=%.0f want 348", sum)
+	}
+}
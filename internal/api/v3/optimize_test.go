@@ -79,3 +79,80 @@ func abs(v float64) float64 {
 	}
 	return v
 }
+
+// 智能调整与指标计算共用同一份指标定义：逐个指标设定目标，调整后重算应恰好达成
+func TestOptimize_EveryRegistryIndicatorReachable(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+	if err := st.SetCurrentYearMonth(2025, 12); err != nil {
+		t.Fatalf("set ym: %v", err)
+	}
+	for key, v := range map[string]float64{
+		"history_social_e18": 3000000, "history_social_e19": 2900000, "history_social_e20": 3350000,
+		"history_social_e21": 2700000, "history_social_e22": 2650000, "history_social_e23": 2900000,
+		"weight_small_micro": 0.3, "weight_eat_wear_use": 0.3, "weight_sample": 0.4,
+	} {
+		if err := st.SetConfigFloat(key, v); err != nil {
+			t.Fatalf("set config: %v", err)
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		base := float64(100000 + i*20000)
+		if err := st.Exec(`
+			INSERT INTO wholesale_retail (
+				credit_code, name, industry_code, industry_type, company_scale, row_no,
+				data_year, data_month, is_small_micro, is_eat_wear_use,
+				sales_current_month, sales_last_year_month, sales_current_cumulative, sales_last_year_cumulative,
+				retail_current_month, retail_last_year_month, retail_current_cumulative, retail_last_year_cumulative,
+				sales_prev_month, retail_prev_month, source_sheet, source_file
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, "W"+string(rune('A'+i)), "批零企业", "5101", []string{"wholesale", "retail"}[i%2], 1, i+1, 2025, 12, i%2, (i/2)%2,
+			base*2, base*1.9, base*20, base*19, base, base*0.95, base*10, base*9.5, base*1.8, base*0.9, "批零", "test.xlsx"); err != nil {
+			t.Fatalf("insert wr: %v", err)
+		}
+		if err := st.Exec(`
+			INSERT INTO accommodation_catering (
+				credit_code, name, industry_code, industry_type, company_scale, row_no, data_year, data_month,
+				revenue_current_month, revenue_last_year_month, revenue_current_cumulative, revenue_last_year_cumulative,
+				food_current_month, food_last_year_month, food_current_cumulative, food_last_year_cumulative,
+				goods_current_month, goods_last_year_month, goods_current_cumulative, goods_last_year_cumulative,
+				revenue_prev_month, source_sheet, source_file
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, "A"+string(rune('A'+i)), "住餐企业", "6101", []string{"accommodation", "catering"}[i%2], 1, i+1, 2025, 12,
+			base, base*0.9, base*10, base*9, base*0.6, base*0.55, base*6, base*5.5, base*0.2, base*0.2, base*2, base*2, base*0.95, "住餐", "test.xlsx"); err != nil {
+			t.Fatalf("insert ac: %v", err)
+		}
+	}
+
+	for _, def := range calculator.Registry {
+		err := st.DryRun(func(preview *store.Store) error {
+			groups, err := calculator.NewCalculator(preview).CalculateAll(2025, 12)
+			if err != nil {
+				return err
+			}
+			before := findIndicatorValue(groups, def.ID)
+			target, tolerance := before+5, 0.05
+			if !def.IsRate() {
+				target, tolerance = before*1.05, 1
+			}
+			if err := applyIndicatorTarget(preview, 2025, 12, def.ID, target); err != nil {
+				return err
+			}
+			groups, err = calculator.NewCalculator(preview).CalculateAll(2025, 12)
+			if err != nil {
+				return err
+			}
+			if after := findIndicatorValue(groups, def.ID); abs(after-target) > tolerance {
+				t.Errorf("%s: before=%.4f target=%.4f after=%.4f", def.ID, before, target, after)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", def.ID, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package v3
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/store"
+)
+
+// fieldChange 单个字段的变化
+type fieldChange struct {
+	Field  string   `json:"field"`
+	Before *float64 `json:"before"`
+	After  *float64 `json:"after"`
+}
+
+// companyDiff 单家企业的预演变化
+type companyDiff struct {
+	CompanyID string        `json:"companyId"`
+	Name      string        `json:"name"`
+	Changes   []fieldChange `json:"changes"`
+}
+
+// isDryRun 解析 ?dryRun=true|1
+func isDryRun(c *gin.Context) bool {
+	switch strings.ToLower(c.Query("dryRun")) {
+	case "1", "true":
+		return true
+	}
+	return false
+}
+
+// dryRun 在回滚事务内执行 run，成功时在响应中附加 dryRun 标记与逐企业变化
+func dryRun(st *store.Store, year, month int, run func(preview *store.Store) (int, gin.H)) (int, gin.H, error) {
+	var code int
+	var resp gin.H
+	err := st.DryRun(func(preview *store.Store) error {
+		before, err := loadCompanyRows(preview, year, month)
+		if err != nil {
+			return err
+		}
+		code, resp = run(preview)
+		if code != http.StatusOK {
+			return nil
+		}
+		after, err := loadCompanyRows(preview, year, month)
+		if err != nil {
+			return err
+		}
+		resp["dryRun"] = true
+		resp["diffs"] = diffCompanyRows(before, after)
+		return nil
+	})
+	return code, resp, err
+}
+
+// loadCompanyRows 读取指定月份全部企业（按 wr:/ac: ID 索引）
+func loadCompanyRows(st *store.Store, year, month int) (map[string]companyRow, error) {
+	out := map[string]companyRow{}
+	wr, err := st.GetWRByYearMonth(store.WRQueryOptions{DataYear: &year, DataMonth: &month})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range wr {
+		row := toCompanyRowWR(*r)
+		out[row.ID] = row
+	}
+	ac, err := st.GetACByYearMonth(store.ACQueryOptions{DataYear: &year, DataMonth: &month})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range ac {
+		row := toCompanyRowAC(*r)
+		out[row.ID] = row
+	}
+	return out, nil
+}
+
+// diffCompanyRows 比较企业数值字段，返回有变化的企业（按 ID 排序）
+func diffCompanyRows(before, after map[string]companyRow) []companyDiff {
+	ids := make([]string, 0, len(after))
+	for id := range after {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	out := []companyDiff{}
+	for _, id := range ids {
+		a := after[id]
+		b := before[id]
+		changes := diffNumericFields(reflect.ValueOf(b), reflect.ValueOf(a))
+		if len(changes) == 0 {
+			continue
+		}
+		out = append(out, companyDiff{CompanyID: id, Name: a.Name, Changes: changes})
+	}
+	return out
+}
+
+func diffNumericFields(before, after reflect.Value) []fieldChange {
+	var out []fieldChange
+	t := after.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type != reflect.TypeOf((*float64)(nil)) {
+			continue
+		}
+		bv, _ := before.Field(i).Interface().(*float64)
+		av, _ := after.Field(i).Interface().(*float64)
+		if floatPtrEqual(bv, av) {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		out = append(out, fieldChange{Field: name, Before: bv, After: av})
+	}
+	return out
+}
+
+func floatPtrEqual(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,92 @@
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOptimize_DryRunLeavesDataUntouched(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+
+	w := postOptimize(t, st, map[string]any{
+		"targets":     map[string]float64{"wholesale_month_rate": 20},
+		"constraints": map[string]any{"maxIndividualRate": 0.3, "minIndividualRate": 0},
+		"dryRun":      true,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		DryRun bool          `json:"dryRun"`
+		Diffs  []companyDiff `json:"diffs"`
+		Groups []any         `json:"groups"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.DryRun || len(resp.Diffs) == 0 || len(resp.Groups) == 0 {
+		t.Fatalf("dry run should report diffs and indicators: %s", w.Body.String())
+	}
+
+	var sum float64
+	if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&sum); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if sum != 325 {
+		t.Fatalf("dry run must not modify data, sum=%.0f", sum)
+	}
+}
+
+func TestUpdateCompany_DryRun(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+	if err := recalcDerivedFields(st, 2025, 12); err != nil {
+		t.Fatalf("recalc: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	body, _ := json.Marshal(map[string]any{"salesCurrentMonth": 150})
+	req := httptest.NewRequest(http.MethodPatch, "/api/companies/wr:1?dryRun=true", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Diffs []companyDiff `json:"diffs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Diffs) != 1 || resp.Diffs[0].CompanyID != "wr:1" {
+		t.Fatalf("unexpected diffs: %+v", resp.Diffs)
+	}
+	found := false
+	for _, ch := range resp.Diffs[0].Changes {
+		if ch.Field == "salesCurrentMonth" && ch.After != nil && *ch.After == 150 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("salesCurrentMonth change missing: %+v", resp.Diffs[0].Changes)
+	}
+
+	var v float64
+	if err := st.QueryRow("SELECT sales_current_month FROM wholesale_retail WHERE id = 1").Scan(&v); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if v != 100 {
+		t.Fatalf("dry run must not modify data, got %.0f", v)
+	}
+}
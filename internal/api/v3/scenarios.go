@@ -0,0 +1,213 @@
+package v3
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/backup"
+	"northstar/internal/calculator"
+	"northstar/internal/store"
+)
+
+// storeFor 按 ?scenario=<name> 选择方案数据；未指定时使用工作数据。
+// 方案不存在时直接写入 404 并返回 false。
+func (h *Handler) storeFor(c *gin.Context) (*store.Store, bool) {
+	name := strings.TrimSpace(c.Query("scenario"))
+	st, err := h.scenarioStore(name)
+	if err != nil {
+		writeScenarioError(c, err)
+		return nil, false
+	}
+	return st, true
+}
+
+func writeScenarioError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrScenarioNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "方案不存在"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func (h *Handler) scenarioStore(name string) (*store.Store, error) {
+	if name == "" {
+		return h.store, nil
+	}
+	st, _, err := h.store.OpenScenario(name)
+	return st, err
+}
+
+// ListScenarios 列出命名方案
+// GET /api/scenarios
+func (h *Handler) ListScenarios(c *gin.Context) {
+	items, err := h.store.ListScenarios()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+type createScenarioRequest struct {
+	Name string `json:"name"`
+	From string `json:"from"` // 复制来源方案；为空表示工作数据
+}
+
+// CreateScenario 复制当前月份企业数据为命名方案
+// POST /api/scenarios
+func (h *Handler) CreateScenario(c *gin.Context) {
+	var req createScenarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name 不能为空"})
+		return
+	}
+	if _, err := h.store.GetScenario(strings.TrimSpace(req.Name)); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "方案已存在: " + req.Name})
+		return
+	}
+
+	sc, err := h.store.CreateScenario(req.Name, strings.TrimSpace(req.From))
+	if err != nil {
+		if errors.Is(err, store.ErrScenarioNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "来源方案不存在: " + req.From})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, sc)
+}
+
+// DeleteScenario 删除命名方案
+// DELETE /api/scenarios/:name
+func (h *Handler) DeleteScenario(c *gin.Context) {
+	if err := h.store.DeleteScenario(c.Param("name")); err != nil {
+		writeScenarioError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": c.Param("name")})
+}
+
+// PromoteScenario 将方案数据提升为对应月份的工作数据
+// POST /api/scenarios/:name/promote
+func (h *Handler) PromoteScenario(c *gin.Context) {
+	if _, err := h.store.GetScenario(c.Param("name")); err != nil {
+		writeScenarioError(c, err)
+		return
+	}
+	if !h.autoBackup(c, h.store, backup.ReasonPromote) {
+		return
+	}
+
+	sc, batch, err := h.store.PromoteScenario(changeMeta(c, store.ChangeSourcePromote, ""), c.Param("name"))
+	if err != nil {
+		writeScenarioError(c, err)
+		return
+	}
+
+	groups, err := calculator.NewCalculator(h.store).CalculateAll(sc.DataYear, sc.DataMonth)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算指标失败"})
+		return
+	}
+	roundIndicatorGroupsInPlace(groups)
+	c.JSON(http.StatusOK, gin.H{"scenario": sc, "groups": groups, "batch": batch})
+}
+
+// indicatorDiff 两个方案的同一指标对比
+type indicatorDiff struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Unit  string  `json:"unit"`
+	A     float64 `json:"a"`
+	B     float64 `json:"b"`
+	Delta float64 `json:"delta"` // b - a
+}
+
+// DiffScenarios 对比两个方案（为空表示工作数据）的指标与企业数值
+// GET /api/scenarios/diff?a=<name>&b=<name>
+func (h *Handler) DiffScenarios(c *gin.Context) {
+	nameA := strings.TrimSpace(c.Query("a"))
+	nameB := strings.TrimSpace(c.Query("b"))
+	if nameA == nameB {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a 与 b 不能相同"})
+		return
+	}
+
+	type side struct {
+		groups []calculator.IndicatorGroup
+		rows   map[string]companyRow
+		year   int
+		month  int
+	}
+	load := func(name string) (*side, error) {
+		st, err := h.scenarioStore(name)
+		if err != nil {
+			return nil, err
+		}
+		year, month, err := st.GetCurrentYearMonth()
+		if err != nil {
+			return nil, err
+		}
+		groups, err := calculator.NewCalculator(st).CalculateAll(year, month)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := loadCompanyRows(st, year, month)
+		if err != nil {
+			return nil, err
+		}
+		return &side{groups: groups, rows: rows, year: year, month: month}, nil
+	}
+
+	a, err := load(nameA)
+	if err != nil {
+		writeScenarioError(c, err)
+		return
+	}
+	b, err := load(nameB)
+	if err != nil {
+		writeScenarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"a":          gin.H{"scenario": nameA, "year": a.year, "month": a.month},
+		"b":          gin.H{"scenario": nameB, "year": b.year, "month": b.month},
+		"indicators": diffIndicatorGroups(a.groups, b.groups),
+		"companies":  diffCompanyRows(a.rows, b.rows),
+	})
+}
+
+// diffIndicatorGroups 按指标 ID 对齐两组指标
+func diffIndicatorGroups(a, b []calculator.IndicatorGroup) []indicatorDiff {
+	valuesB := map[string]float64{}
+	for _, g := range b {
+		for _, it := range g.Indicators {
+			valuesB[it.ID] = it.Value
+		}
+	}
+
+	out := []indicatorDiff{}
+	for _, g := range a {
+		for _, it := range g.Indicators {
+			vb := valuesB[it.ID]
+			out = append(out, indicatorDiff{
+				ID:    it.ID,
+				Name:  it.Name,
+				Unit:  it.Unit,
+				A:     roundTo2(it.Value),
+				B:     roundTo2(vb),
+				Delta: roundTo2(vb - it.Value),
+			})
+		}
+	}
+	return out
+}
+
+func roundTo2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
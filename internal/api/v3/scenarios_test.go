@@ -0,0 +1,190 @@
+package v3
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/backup"
+	"northstar/internal/store"
+)
+
+func TestScenarios_ForkOptimizeDiffPromote(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	do := func(method, path string, payload any) *httptest.ResponseRecorder {
+		t.Helper()
+		var body []byte
+		if payload != nil {
+			body, _ = json.Marshal(payload)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	workingSum := func() float64 {
+		t.Helper()
+		var sum float64
+		if err := st.QueryRow("SELECT SUM(sales_current_month) FROM wholesale_retail").Scan(&sum); err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		return sum
+	}
+
+	if w := do(http.MethodPost, "/api/scenarios", map[string]any{"name": "aggressive"}); w.Code != http.StatusCreated {
+		t.Fatalf("create scenario: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/api/scenarios", map[string]any{"name": "aggressive"}); w.Code != http.StatusConflict {
+		t.Fatalf("duplicate scenario should conflict: %d", w.Code)
+	}
+
+	w := do(http.MethodPost, "/api/optimize?scenario=aggressive", map[string]any{
+		"targets": map[string]float64{"wholesale_month_rate": 20},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("optimize scenario: %d %s", w.Code, w.Body.String())
+	}
+	if got := workingSum(); got != 325 {
+		t.Fatalf("optimizing a scenario must not touch working data, sum=%.0f", got)
+	}
+
+	w = do(http.MethodGet, "/api/scenarios/diff?b=aggressive", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("diff: %d %s", w.Code, w.Body.String())
+	}
+	var diff struct {
+		Indicators []indicatorDiff `json:"indicators"`
+		Companies  []companyDiff   `json:"companies"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &diff); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(diff.Companies) == 0 {
+		t.Fatalf("expected company differences")
+	}
+	found := false
+	for _, d := range diff.Indicators {
+		if d.ID == "wholesale_month_rate" {
+			found = true
+			if d.A != 8.33 || d.B != 20 {
+				t.Fatalf("unexpected wholesale_month_rate diff: %+v", d)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("wholesale_month_rate missing from diff")
+	}
+
+	if w := do(http.MethodPost, "/api/scenarios/aggressive/promote", nil); w.Code != http.StatusOK {
+		t.Fatalf("promote: %d %s", w.Code, w.Body.String())
+	}
+	if got := workingSum(); got != 360 {
+		t.Fatalf("promoted scenario should replace working data, sum=%.0f", got)
+	}
+
+	if w := do(http.MethodDelete, "/api/scenarios/aggressive", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/api/indicators?scenario=aggressive", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("deleted scenario should be gone: %d", w.Code)
+	}
+}
+
+func TestScenarios_PromoteCanBeUndone(t *testing.T) {
+	st := newBoundedOptimizeStore(t)
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(st, "")
+	backups := backup.NewManager(st, filepath.Join(t.TempDir(), "backups"), backup.DefaultKeep, true)
+	h.SetBackupManager(backups)
+	r := gin.New()
+	h.RegisterRoutes(r.Group("/api"))
+
+	do := func(method, path string, payload any) *httptest.ResponseRecorder {
+		t.Helper()
+		var body []byte
+		if payload != nil {
+			body, _ = json.Marshal(payload)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	sales := func() []float64 {
+		t.Helper()
+		rows, err := st.Query("SELECT sales_current_month FROM wholesale_retail ORDER BY id")
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		defer rows.Close()
+		var out []float64
+		for rows.Next() {
+			var v float64
+			if err := rows.Scan(&v); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			out = append(out, v)
+		}
+		return out
+	}
+	original := sales()
+
+	if w := do(http.MethodPost, "/api/scenarios", map[string]any{"name": "aggressive"}); w.Code != http.StatusCreated {
+		t.Fatalf("create scenario: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/api/optimize?scenario=aggressive", map[string]any{
+		"targets": map[string]float64{"wholesale_month_rate": 20},
+	}); w.Code != http.StatusOK {
+		t.Fatalf("optimize scenario: %d %s", w.Code, w.Body.String())
+	}
+	// 方案上的调整不触发工作数据的自动备份
+	before, err := backups.List()
+	if err != nil {
+		t.Fatalf("list backups: %v", err)
+	}
+
+	if w := do(http.MethodPost, "/api/scenarios/aggressive/promote", nil); w.Code != http.StatusOK {
+		t.Fatalf("promote: %d %s", w.Code, w.Body.String())
+	}
+	after, err := backups.List()
+	if err != nil {
+		t.Fatalf("list backups: %v", err)
+	}
+	if len(after) != len(before)+1 || after[0].Reason != backup.ReasonPromote {
+		t.Fatalf("promote should take an automatic backup: %+v", after)
+	}
+
+	batches, err := st.ListChangeBatches(1, 0)
+	if err != nil {
+		t.Fatalf("list batches: %v", err)
+	}
+	if len(batches) != 1 || batches[0].Source != store.ChangeSourcePromote || batches[0].ChangeCount == 0 {
+		t.Fatalf("promote should be recorded as a batch: %+v", batches)
+	}
+
+	if w := do(http.MethodPost, "/api/history/undo", nil); w.Code != http.StatusOK {
+		t.Fatalf("undo: %d %s", w.Code, w.Body.String())
+	}
+	got := sales()
+	if len(got) != len(original) {
+		t.Fatalf("rows=%d, want %d", len(got), len(original))
+	}
+	for i := range got {
+		if got[i] != original[i] {
+			t.Fatalf("undo should restore original rows: got %v, want %v", got, original)
+		}
+	}
+}
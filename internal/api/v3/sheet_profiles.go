@@ -0,0 +1,83 @@
+package v3
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/importer"
+	"northstar/internal/store"
+)
+
+func parseProfileID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeSheetProfileError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrSheetProfileNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "识别档案不存在"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// ListSheetProfiles 列出学习到的 Sheet 识别档案（导入时人工修正 Sheet 类型后自动生成）
+// GET /api/sheet-profiles
+func (h *Handler) ListSheetProfiles(c *gin.Context) {
+	items, err := h.store.ListSheetProfiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// updateSheetProfileRequest 修改识别档案请求
+type updateSheetProfileRequest struct {
+	SheetType string `json:"sheetType"`
+}
+
+// UpdateSheetProfile 修改识别档案对应的 Sheet 类型
+// PUT /api/sheet-profiles/:id
+func (h *Handler) UpdateSheetProfile(c *gin.Context) {
+	id, ok := parseProfileID(c)
+	if !ok {
+		return
+	}
+	var req updateSheetProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求格式错误"})
+		return
+	}
+	t, err := importer.ParseSheetType(req.SheetType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p, err := h.store.UpdateSheetProfileType(id, string(t))
+	if err != nil {
+		writeSheetProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// DeleteSheetProfile 删除识别档案（之后按内置规则识别）
+// DELETE /api/sheet-profiles/:id
+func (h *Handler) DeleteSheetProfile(c *gin.Context) {
+	id, ok := parseProfileID(c)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteSheetProfile(id); err != nil {
+		writeSheetProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
@@ -2,6 +2,7 @@ package v3
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"northstar/internal/store"
@@ -61,6 +62,15 @@ func (h *Handler) GetStatus(c *gin.Context) {
 		TotalCompanies: totalCompanies,
 		WRCount:        wrCount,
 		ACCount:        acCount,
-		LastImportTime: "", // TODO: 从 import_logs 获取最后导入时间
+		LastImportTime: h.lastImportTime(),
 	})
 }
+
+// lastImportTime 最近一次成功导入的完成时间（RFC3339），从未导入时为空
+func (h *Handler) lastImportTime() string {
+	t, err := h.store.GetLastImportTime()
+	if err != nil || t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -6,7 +6,6 @@ import (
 	"net/url"
 
 	"github.com/gin-gonic/gin"
-	"northstar/internal/calculator"
 	"northstar/internal/exporter"
 )
 
@@ -21,46 +20,61 @@ func buildExportContentDisposition(year, month int) string {
 	)
 }
 
-// GetIndicators 获取16项指标
+// GetIndicators 获取全部指标（16 项同比指标与环比指标）
 // GET /api/indicators
 func (h *Handler) GetIndicators(c *gin.Context) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
 	// 获取当前年月
-	year, month, err := h.store.GetCurrentYearMonth()
+	year, month, err := st.GetCurrentYearMonth()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取当前年月失败"})
 		return
 	}
 
-	// 创建计算器
-	calc := calculator.NewCalculator(h.store)
-
-	// 计算所有指标
-	groups, err := calc.CalculateAll(year, month)
+	// 计算所有指标（工作数据走增量缓存）
+	groups, err := calculateIndicators(st, h.indicatorCache(st), year, month)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算指标失败"})
 		return
 	}
 	roundIndicatorGroupsInPlace(groups)
 
+	// 社零总额的计算过程（社零额（定） 表各单元格）
+	social, err := calculateSocialRetail(st, h.indicatorCache(st), year, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算指标失败"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"year":   year,
-		"month":  month,
-		"groups": groups,
+		"year":         year,
+		"month":        month,
+		"groups":       groups,
+		"socialRetail": social,
 	})
 }
 
 // Export 导出 Excel
 // POST /api/export
 func (h *Handler) Export(c *gin.Context) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
 	// 获取当前年月
-	year, month, err := h.store.GetCurrentYearMonth()
+	year, month, err := st.GetCurrentYearMonth()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取当前年月失败"})
 		return
 	}
 
 	// 创建导出器
-	exp := exporter.NewExporter(h.store, h.templatePath)
+	exp := exporter.NewExporter(st, h.templatePath)
 
 	// 导出 Excel
 	file, err := exp.Export(exporter.ExportOptions{
@@ -0,0 +1,38 @@
+package v3
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"northstar/internal/rules"
+)
+
+// GetValidation 按业务规则校验当前月份数据
+// GET /api/validation?severity=hard|soft
+func (h *Handler) GetValidation(c *gin.Context) {
+	st, ok := h.storeFor(c)
+	if !ok {
+		return
+	}
+
+	year, month, err := st.GetCurrentYearMonth()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取当前年月失败"})
+		return
+	}
+
+	severity := rules.Severity(c.Query("severity"))
+	if severity != "" && severity != rules.SeverityHard && severity != rules.SeveritySoft {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "severity 仅支持 hard/soft"})
+		return
+	}
+
+	report, err := rules.NewEngine(st).Evaluate(year, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	report.Violations = report.Filter(severity)
+
+	c.JSON(http.StatusOK, report)
+}
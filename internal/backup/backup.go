@@ -0,0 +1,230 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"northstar/internal/store"
+)
+
+// 备份原因
+const (
+	ReasonImport    = "import"
+	ReasonOptimize  = "optimize"
+	ReasonPromote   = "promote"
+	ReasonScheduled = "scheduled"
+	ReasonManual    = "manual"
+	ReasonRestore   = "pre_restore"
+)
+
+// DefaultKeep 默认保留的备份份数
+const DefaultKeep = 10
+
+// ErrNotFound 备份不存在
+var ErrNotFound = errors.New("backup not found")
+
+const (
+	filePrefix = "northstar_"
+	fileExt    = ".db"
+	timeLayout = "20060102-150405.000000"
+)
+
+// Backup 数据库快照
+type Backup struct {
+	ID        string    `json:"id"`
+	Reason    string    `json:"reason"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+	Path      string    `json:"-"`
+}
+
+// Manager 数据库快照管理：生成、轮转、列出与恢复。
+// 快照以文件形式保存在备份目录中（不登记在数据库内，恢复后依然可见）。
+type Manager struct {
+	st   *store.Store
+	dir  string
+	keep int
+	auto bool
+
+	mu sync.Mutex
+}
+
+// NewManager 创建快照管理器；auto 为 false 时 AutoSnapshot 不生成快照
+func NewManager(st *store.Store, dir string, keep int, auto bool) *Manager {
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	return &Manager{st: st, dir: dir, keep: keep, auto: auto}
+}
+
+// Enabled 是否启用自动备份
+func (m *Manager) Enabled() bool {
+	return m.auto
+}
+
+// AutoSnapshot 自动备份（导入、调整前等）；未启用自动备份时返回 nil, nil
+func (m *Manager) AutoSnapshot(reason string) (*Backup, error) {
+	if !m.auto {
+		return nil, nil
+	}
+	return m.Snapshot(reason)
+}
+
+// Snapshot 立即生成快照并按保留份数轮转
+func (m *Manager) Snapshot(reason string) (*Backup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshotLocked(reason)
+}
+
+func (m *Manager) snapshotLocked(reason string) (*Backup, error) {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := time.Now()
+	id := now.Format(timeLayout) + "_" + reason
+	path := filepath.Join(m.dir, filePrefix+id+fileExt)
+	if err := m.st.BackupTo(path); err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	b := &Backup{ID: id, Reason: reason, CreatedAt: now, Path: path}
+	if info, err := os.Stat(path); err == nil {
+		b.Size = info.Size()
+	}
+
+	if err := m.rotateLocked(); err != nil {
+		log.Printf("备份轮转失败: %v", err)
+	}
+	return b, nil
+}
+
+// rotateLocked 删除超出保留份数的最旧快照
+func (m *Manager) rotateLocked() error {
+	items, err := m.listLocked()
+	if err != nil {
+		return err
+	}
+	for i := m.keep; i < len(items); i++ {
+		if err := os.Remove(items[i].Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List 列出快照（最新在前）
+func (m *Manager) List() ([]Backup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listLocked()
+}
+
+func (m *Manager) listLocked() ([]Backup, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Backup{}, nil
+		}
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	out := []Backup{}
+	for _, e := range entries {
+		b, ok := parseFileName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		b.Path = filepath.Join(m.dir, e.Name())
+		if info, err := e.Info(); err == nil {
+			b.Size = info.Size()
+		}
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// parseFileName 解析 northstar_<时间>_<原因>.db
+func parseFileName(name string) (Backup, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileExt) {
+		return Backup{}, false
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileExt)
+	stamp, reason, ok := strings.Cut(id, "_")
+	if !ok {
+		return Backup{}, false
+	}
+	t, err := time.ParseInLocation(timeLayout, stamp, time.Local)
+	if err != nil {
+		return Backup{}, false
+	}
+	return Backup{ID: id, Reason: reason, CreatedAt: t}, true
+}
+
+// Restore 用指定快照覆盖当前数据库；覆盖前先为当前数据生成一份 pre_restore 快照，
+// 返回被恢复的快照与覆盖前的安全快照。
+func (m *Manager) Restore(id string) (restored *Backup, safety *Backup, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items, err := m.listLocked()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range items {
+		if items[i].ID == id {
+			restored = &items[i]
+			break
+		}
+	}
+	if restored == nil {
+		return nil, nil, ErrNotFound
+	}
+
+	// 安全快照不参与本次轮转，避免恰好删除待恢复的快照
+	keep := m.keep
+	m.keep = len(items) + 1
+	safety, err = m.snapshotLocked(ReasonRestore)
+	m.keep = keep
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to snapshot before restore: %w", err)
+	}
+
+	if err := m.st.RestoreFrom(restored.Path); err != nil {
+		return nil, safety, err
+	}
+	if err := m.rotateLocked(); err != nil {
+		log.Printf("备份轮转失败: %v", err)
+	}
+	return restored, safety, nil
+}
+
+// Run 按固定间隔生成定时快照，直到 ctx 取消
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.AutoSnapshot(ReasonScheduled); err != nil {
+				log.Printf("定时备份失败: %v", err)
+			}
+		}
+	}
+}
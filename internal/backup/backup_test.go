@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"path/filepath"
+	"testing"
+
+	"northstar/internal/store"
+)
+
+func countCompanies(t *testing.T, st *store.Store) int {
+	t.Helper()
+	var n int
+	if err := st.DB().QueryRow("SELECT COUNT(*) FROM wholesale_retail").Scan(&n); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return n
+}
+
+func TestManager_SnapshotRotateAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.New(filepath.Join(dir, "northstar.db"))
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer st.Close()
+
+	insert := func(name string) {
+		t.Helper()
+		if _, err := st.DB().Exec(
+			"INSERT INTO wholesale_retail (name, data_year, data_month, row_no) VALUES (?, 2025, 12, 1)", name,
+		); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	m := NewManager(st, filepath.Join(dir, "backups"), 2, true)
+
+	insert("甲")
+	first, err := m.AutoSnapshot(ReasonImport)
+	if err != nil || first == nil {
+		t.Fatalf("AutoSnapshot: %v", err)
+	}
+
+	insert("乙")
+	insert("丙")
+	if _, err := m.Snapshot(ReasonOptimize); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	items, err := m.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(items) != 2 || items[0].Reason != ReasonOptimize || items[1].ID != first.ID {
+		t.Fatalf("unexpected backups: %+v", items)
+	}
+
+	restored, safety, err := m.Restore(first.ID)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.ID != first.ID || safety == nil || safety.Reason != ReasonRestore {
+		t.Fatalf("restored=%+v safety=%+v", restored, safety)
+	}
+	if n := countCompanies(t, st); n != 1 {
+		t.Fatalf("companies after restore=%d, want 1", n)
+	}
+
+	// 轮转后只保留最新 2 份：安全快照 + 调整前快照
+	items, _ = m.List()
+	if len(items) != 2 || items[0].ID != safety.ID {
+		t.Fatalf("unexpected backups after restore: %+v", items)
+	}
+
+	// 通过安全快照可以撤销本次恢复
+	if _, _, err := m.Restore(safety.ID); err != nil {
+		t.Fatalf("Restore safety: %v", err)
+	}
+	if n := countCompanies(t, st); n != 3 {
+		t.Fatalf("companies after undoing restore=%d, want 3", n)
+	}
+
+	if _, _, err := m.Restore("missing"); err != ErrNotFound {
+		t.Fatalf("Restore(missing) err=%v, want ErrNotFound", err)
+	}
+
+	off := NewManager(st, filepath.Join(dir, "backups"), 2, false)
+	if b, err := off.AutoSnapshot(ReasonImport); b != nil || err != nil {
+		t.Fatalf("AutoSnapshot with auto backup off = %+v, %v", b, err)
+	}
+}
@@ -0,0 +1,282 @@
+package calculator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+// Cache 按 (year, month) 缓存指标定义引用的汇总项合计与指标值。
+//
+// 依赖关系：企业字段（L0）→ 汇总项（Term）→ 指标（Registry）。
+// 通过 Edit 修改企业时按行差量更新该月汇总项，只重算依赖变化汇总项的指标；
+// 其余写库途径（含配置项、以该月为上月的下月缓存）由数据版本（Store.DataVersion）识别，下次 Get 时全量重算。
+type Cache struct {
+	store   *store.Store
+	mu      sync.Mutex
+	entries map[[2]int]*cacheEntry
+}
+
+// cacheEntry 某月的缓存内容
+type cacheEntry struct {
+	version int64
+	totals  Totals
+	values  map[string]float64
+}
+
+// NewCache 创建指标缓存；st 须为非事务 Store
+func NewCache(st *store.Store) *Cache {
+	return &Cache{store: st, entries: map[[2]int]*cacheEntry{}}
+}
+
+// Get 返回某月全部指标；缓存缺失或数据已被其他途径修改时全量重算。
+// 不能在同一 Store 的事务内调用（单连接会互相等待）。
+func (c *Cache) Get(year, month int) ([]IndicatorGroup, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, err := c.entry(year, month)
+	if err != nil {
+		return nil, err
+	}
+	return groupIndicators(e.values), nil
+}
+
+// SocialRetail 返回某月 社零额（定） 表的计算过程（与 Get 共用缓存的汇总项）
+func (c *Cache) SocialRetail(year, month int) (SocialRetail, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, err := c.entry(year, month)
+	if err != nil {
+		return SocialRetail{}, err
+	}
+	return e.totals.SocialRetail(), nil
+}
+
+// entry 取某月缓存，版本不一致时全量重算；调用方须持有 c.mu
+func (c *Cache) entry(year, month int) (*cacheEntry, error) {
+	key := [2]int{year, month}
+	version, err := c.store.DataVersion()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := c.entries[key]; ok && e.version == version {
+		return e, nil
+	}
+
+	// 在事务内读取版本与汇总，保证二者对应同一份数据
+	e := &cacheEntry{}
+	if err := c.store.Atomic(func(tx *store.Store) error {
+		var err error
+		if e.version, err = tx.DataVersion(); err != nil {
+			return err
+		}
+		e.totals, err = NewCalculator(tx).Totals(year, month)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	e.values = make(map[string]float64, len(Registry))
+	for _, d := range Registry {
+		e.values[d.ID] = e.totals.Evaluate(d)
+	}
+	c.entries[key] = e
+	return e, nil
+}
+
+// Invalidate 丢弃全部缓存（如数据库从快照恢复后）
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[[2]int]*cacheEntry{}
+}
+
+// Edit 在事务内执行 fn 修改某月的企业行，fn 通过 d 报告每行修改前后的值。
+// 提交后若缓存在 fn 开始前与数据库一致，则按差量更新汇总项并只重算受影响的指标；
+// 否则（含 fn 报告了其他月份的企业行）丢弃该月缓存。
+func (c *Cache) Edit(year, month int, fn func(tx *store.Store, d *Delta) error) error {
+	d := &Delta{year: year, month: month}
+	var from, to int64
+	if err := c.store.Atomic(func(tx *store.Store) error {
+		var err error
+		if from, err = tx.DataVersion(); err != nil {
+			return err
+		}
+		if err := fn(tx, d); err != nil {
+			return err
+		}
+		to, err = tx.DataVersion()
+		return err
+	}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := [2]int{year, month}
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if e.version != from || d.foreign {
+		delete(c.entries, key)
+		return nil
+	}
+	e.version = to
+	changed := map[Term]bool{}
+	for term, v := range d.totals.sums {
+		if v != 0 {
+			e.totals.sums[term] += v
+			changed[term] = true
+		}
+	}
+	for _, def := range affectedDefinitions(changed) {
+		e.values[def.ID] = e.totals.Evaluate(def)
+	}
+	return nil
+}
+
+// Delta 一次修改对某月汇总项的差量
+type Delta struct {
+	year, month int
+	totals      Totals
+	foreign     bool // 报告过不属于该月的企业行，差量不可用
+}
+
+// inMonth 企业行是否属于差量对应的月份；不属于时标记差量不可用
+func (d *Delta) inMonth(year, month int) bool {
+	if year != d.year || month != d.month {
+		d.foreign = true
+		return false
+	}
+	return true
+}
+
+// WR 记录一行批零企业修改前后的值（新增时 before 为 nil，删除时 after 为 nil）
+func (d *Delta) WR(before, after *model.WholesaleRetail) {
+	if (before != nil && !d.inMonth(before.DataYear, before.DataMonth)) ||
+		(after != nil && !d.inMonth(after.DataYear, after.DataMonth)) {
+		return
+	}
+	if before != nil {
+		d.addRow(TableWR, reflect.ValueOf(*before), before.IndustryType, -1)
+	}
+	if after != nil {
+		d.addRow(TableWR, reflect.ValueOf(*after), after.IndustryType, 1)
+	}
+}
+
+// AC 记录一行住餐企业修改前后的值（新增时 before 为 nil，删除时 after 为 nil）
+func (d *Delta) AC(before, after *model.AccommodationCatering) {
+	if (before != nil && !d.inMonth(before.DataYear, before.DataMonth)) ||
+		(after != nil && !d.inMonth(after.DataYear, after.DataMonth)) {
+		return
+	}
+	if before != nil {
+		d.addRow(TableAC, reflect.ValueOf(*before), before.IndustryType, -1)
+	}
+	if after != nil {
+		d.addRow(TableAC, reflect.ValueOf(*after), after.IndustryType, 1)
+	}
+}
+
+// addRow 按 sign（±1）累加一行企业对各汇总项的贡献，口径与 Calculator.Totals 的 SQL 一致
+func (d *Delta) addRow(table string, rec reflect.Value, industryType string, sign float64) {
+	m := rowModels[table]
+	for _, expr := range m.exprs {
+		if expr.Flag != "" && m.value(rec, expr.Flag) != 1 {
+			continue
+		}
+		var v float64
+		for _, f := range expr.FieldList() {
+			v += m.value(rec, f)
+		}
+		d.totals.add(expr, industryType, sign*v)
+	}
+}
+
+// rowModel 企业表引用的汇总项及列名到模型字段下标的映射
+type rowModel struct {
+	exprs  []Term
+	fields map[string]int
+}
+
+// rowModels 包初始化时按 Registry 构建一次
+var rowModels = map[string]rowModel{
+	TableWR: newRowModel(TableWR, reflect.TypeOf(model.WholesaleRetail{})),
+	TableAC: newRowModel(TableAC, reflect.TypeOf(model.AccommodationCatering{})),
+}
+
+// newRowModel 按 json 标签（列名 snake_case 对应 camelCase）定位字段；
+// 汇总项引用的列在模型中没有对应数值字段时 panic，避免差量静默按 0 计入
+func newRowModel(table string, t reflect.Type) rowModel {
+	byTag := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		byTag[strings.Split(t.Field(i).Tag.Get("json"), ",")[0]] = i
+	}
+
+	m := rowModel{exprs: registryExpressions(table), fields: map[string]int{}}
+	for _, expr := range m.exprs {
+		columns := expr.FieldList()
+		if expr.Flag != "" {
+			columns = append(columns, expr.Flag)
+		}
+		for _, column := range columns {
+			i, ok := byTag[camelCase(column)]
+			if !ok {
+				panic(fmt.Sprintf("calculator: column %s.%s has no field in %s", table, column, t.Name()))
+			}
+			switch t.Field(i).Type.Kind() {
+			case reflect.Float64, reflect.Int:
+			case reflect.Ptr:
+				if t.Field(i).Type.Elem().Kind() != reflect.Float64 {
+					panic(fmt.Sprintf("calculator: column %s.%s maps to non-numeric field %s", table, column, t.Field(i).Name))
+				}
+			default:
+				panic(fmt.Sprintf("calculator: column %s.%s maps to non-numeric field %s", table, column, t.Field(i).Name))
+			}
+			m.fields[column] = i
+		}
+	}
+	return m
+}
+
+func camelCase(column string) string {
+	parts := strings.Split(column, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// value 读取企业记录某列的数值（空指针按 0）
+func (m rowModel) value(rec reflect.Value, column string) float64 {
+	switch v := rec.Field(m.fields[column]).Interface().(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case *float64:
+		if v != nil {
+			return *v
+		}
+	}
+	return 0
+}
+
+// affectedDefinitions 依赖任一变化汇总项的指标
+func affectedDefinitions(changed map[Term]bool) []Definition {
+	var out []Definition
+	for _, d := range Registry {
+		for _, dep := range d.dependencies() {
+			if changed[dep] {
+				out = append(out, d)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package calculator
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+// assertMatchesFullRecompute 缓存结果须与全量重算一致
+func assertMatchesFullRecompute(t *testing.T, st *store.Store, cache *Cache, year, month int) {
+	t.Helper()
+	got, err := cache.Get(year, month)
+	if err != nil {
+		t.Fatalf("cache get: %v", err)
+	}
+	want, err := NewCalculator(st).CalculateAll(year, month)
+	if err != nil {
+		t.Fatalf("CalculateAll: %v", err)
+	}
+	for i := range want {
+		for j, w := range want[i].Indicators {
+			g := got[i].Indicators[j]
+			if g.ID != w.ID || math.Abs(g.Value-w.Value) > 1e-6*math.Max(1, math.Abs(w.Value)) {
+				t.Errorf("%s = %v, full recompute %s = %v", g.ID, g.Value, w.ID, w.Value)
+			}
+		}
+	}
+}
+
+func TestCache_EditAppliesRowDeltas(t *testing.T) {
+	st := newTestStore(t)
+	seedMonth(t, st, 2025, 12, 200)
+	cache := NewCache(st)
+	assertMatchesFullRecompute(t, st, cache, 2025, 12)
+
+	edits := []struct {
+		name string
+		fn   func(tx *store.Store, d *Delta) error
+	}{
+		{"批零零售额与小微标记", func(tx *store.Store, d *Delta) error {
+			before, err := tx.GetWRByID(1)
+			if err != nil {
+				return err
+			}
+			if err := tx.UpdateWR(1, map[string]interface{}{"retail_current_month": before.RetailCurrentMonth + 1234.5, "is_small_micro": 1 - before.IsSmallMicro}); err != nil {
+				return err
+			}
+			after, err := tx.GetWRByID(1)
+			d.WR(before, after)
+			return err
+		}},
+		{"批零行业类型", func(tx *store.Store, d *Delta) error {
+			before, err := tx.GetWRByID(2)
+			if err != nil {
+				return err
+			}
+			industryType := map[string]string{"wholesale": "retail", "retail": "wholesale"}[before.IndustryType]
+			if err := tx.UpdateWR(2, map[string]interface{}{"industry_type": industryType}); err != nil {
+				return err
+			}
+			after, err := tx.GetWRByID(2)
+			d.WR(before, after)
+			return err
+		}},
+		{"住餐营业额与餐费", func(tx *store.Store, d *Delta) error {
+			before, err := tx.GetACByID(1)
+			if err != nil {
+				return err
+			}
+			if err := tx.UpdateAC(1, map[string]interface{}{"revenue_current_month": 0.0, "food_current_cumulative": before.FoodCurrentCumulative * 2}); err != nil {
+				return err
+			}
+			after, err := tx.GetACByID(1)
+			d.AC(before, after)
+			return err
+		}},
+	}
+	for _, e := range edits {
+		if err := cache.Edit(2025, 12, e.fn); err != nil {
+			t.Fatalf("%s: %v", e.name, err)
+		}
+		version, err := st.DataVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 增量更新后缓存版本应与数据库一致（不会触发全量重算）
+		if v := cache.entries[[2]int{2025, 12}].version; v != version {
+			t.Fatalf("%s: cache version %d, store version %d", e.name, v, version)
+		}
+		assertMatchesFullRecompute(t, st, cache, 2025, 12)
+	}
+
+	// 其他途径写库后自动失效
+	if err := st.SetConfigFloat("history_social_e18", 1600000); err != nil {
+		t.Fatal(err)
+	}
+	assertMatchesFullRecompute(t, st, cache, 2025, 12)
+}
+
+func TestCache_EditIgnoresRowsFromOtherMonths(t *testing.T) {
+	st := newTestStore(t)
+	seedMonth(t, st, 2025, 11, 20)
+	seedMonth(t, st, 2025, 12, 20)
+	cache := NewCache(st)
+	assertMatchesFullRecompute(t, st, cache, 2025, 12)
+
+	var id int64
+	if err := st.QueryRow("SELECT id FROM wholesale_retail WHERE data_year = 2025 AND data_month = 11 LIMIT 1").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	// 例如月份切换后旧表格仍提交上月企业的修改
+	if err := cache.Edit(2025, 12, func(tx *store.Store, d *Delta) error {
+		before, err := tx.GetWRByID(id)
+		if err != nil {
+			return err
+		}
+		if err := tx.UpdateWR(id, map[string]interface{}{"sales_current_month": before.SalesCurrentMonth + 5000}); err != nil {
+			return err
+		}
+		after, err := tx.GetWRByID(id)
+		d.WR(before, after)
+		return err
+	}); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if _, ok := cache.entries[[2]int{2025, 12}]; ok {
+		t.Fatalf("cache entry should be dropped after an edit outside the month")
+	}
+	assertMatchesFullRecompute(t, st, cache, 2025, 12)
+}
+
+func TestCache_DetectsWritesFromOtherConnections(t *testing.T) {
+	st := newTestStore(t)
+	seedMonth(t, st, 2025, 12, 50)
+	cache := NewCache(st)
+	assertMatchesFullRecompute(t, st, cache, 2025, 12)
+
+	// 另一连接（如重启后的新连接或其他进程）写库，缓存仍须识别
+	other, err := store.New(st.Path())
+	if err != nil {
+		t.Fatalf("open second store: %v", err)
+	}
+	defer other.Close()
+	if err := other.Exec("UPDATE wholesale_retail SET sales_current_month = sales_current_month * 2 WHERE data_year = 2025 AND data_month = 12"); err != nil {
+		t.Fatal(err)
+	}
+	assertMatchesFullRecompute(t, st, cache, 2025, 12)
+
+	// 回滚的写入不改变数据版本
+	before, err := st.DataVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = st.DryRun(func(tx *store.Store) error {
+		return tx.Exec("UPDATE config SET value = '1' WHERE key = 'weight_sample'")
+	})
+	if after, err := st.DataVersion(); err != nil || after != before {
+		t.Fatalf("data version after rollback = %d (%v), want %d", after, err, before)
+	}
+}
+
+func TestNewRowModel_PanicsOnUnmappedColumn(t *testing.T) {
+	if len(rowModels[TableWR].fields) == 0 || len(rowModels[TableAC].fields) == 0 {
+		t.Fatalf("row models not built: %+v", rowModels)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic for columns without model fields")
+		}
+	}()
+	// 批零汇总项的列在住餐模型中不存在
+	newRowModel(TableWR, reflect.TypeOf(model.AccommodationCatering{}))
+}
+
+func TestAffectedDefinitions_OnlyDependentIndicators(t *testing.T) {
+	catering := Term{Table: TableAC, Fields: "revenue_current_month", IndustryType: "catering"}
+	var ids []string
+	for _, d := range affectedDefinitions(map[Term]bool{catering: true}) {
+		ids = append(ids, d.ID)
+	}
+	// 本月营业额同时是同比与环比增速的分子
+	if len(ids) != 2 || ids[0] != "catering_month_rate" || ids[1] != "catering_mom_rate" {
+		t.Fatalf("affected=%v", ids)
+	}
+
+	// 小微零售额变化影响小微增速与社零总额（限下社零额按小微、吃穿用增速估算）
+	smallMicro := Term{Table: TableWR, Fields: "retail_last_year_month", Flag: "is_small_micro"}
+	if n := len(affectedDefinitions(map[Term]bool{smallMicro: true})); n != 3 {
+		t.Fatalf("small micro affects %d indicators, want 3", n)
+	}
+}
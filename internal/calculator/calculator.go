@@ -1,6 +1,8 @@
 package calculator
 
 import (
+	"strings"
+
 	"northstar/internal/store"
 )
 
@@ -30,408 +32,171 @@ func NewCalculator(store *store.Store) *Calculator {
 	}
 }
 
-// CalculateAll 计算所有16个指标
+// CalculateAll 计算全部指标（每张表一次分组汇总查询）
 func (c *Calculator) CalculateAll(year, month int) ([]IndicatorGroup, error) {
-	groups := []IndicatorGroup{
-		{
-			Name:       "限上社零额",
-			Indicators: []Indicator{},
-		},
-		{
-			Name:       "专项增速",
-			Indicators: []Indicator{},
-		},
-		{
-			Name:       "四大行业增速",
-			Indicators: []Indicator{},
-		},
-		{
-			Name:       "社零总额",
-			Indicators: []Indicator{},
-		},
-	}
-
-	// 计算限上社零额（4个指标）
-	limitAboveIndicators, err := c.calculateLimitAbove(year, month)
-	if err != nil {
-		return nil, err
-	}
-	groups[0].Indicators = limitAboveIndicators
-
-	// 计算专项增速（2个指标）
-	specialIndicators, err := c.calculateSpecialRates(year, month)
-	if err != nil {
-		return nil, err
-	}
-	groups[1].Indicators = specialIndicators
-
-	// 计算四大行业增速（8个指标）
-	industryIndicators, err := c.calculateIndustryRates(year, month)
-	if err != nil {
-		return nil, err
-	}
-	groups[2].Indicators = industryIndicators
-
-	// 计算社零总额（2个指标）
-	totalIndicators, err := c.calculateTotalSocial(year, month, specialIndicators)
+	totals, err := c.Totals(year, month)
 	if err != nil {
 		return nil, err
 	}
-	groups[3].Indicators = totalIndicators
-
-	return groups, nil
+	return totals.Indicators(), nil
 }
 
-// calculateLimitAbove 计算限上社零额（4个指标）
-func (c *Calculator) calculateLimitAbove(year, month int) ([]Indicator, error) {
-	// 查询所有批零企业
-	wrOpts := store.WRQueryOptions{
-		DataYear:  &year,
-		DataMonth: &month,
-	}
-	wrRecords, err := c.store.GetWRByYearMonth(wrOpts)
-	if err != nil {
-		return nil, err
-	}
-
-	// 查询所有住餐企业
-	acOpts := store.ACQueryOptions{
-		DataYear:  &year,
-		DataMonth: &month,
-	}
-	acRecords, err := c.store.GetACByYearMonth(acOpts)
-	if err != nil {
-		return nil, err
-	}
-
-	// 汇总零售额
-	var retailCurrentMonthSum float64
-	var retailLastYearMonthSum float64
-	var retailCurrentCumulativeSum float64
-	var retailLastYearCumulativeSum float64
-
-	// 批零企业零售额
-	for _, record := range wrRecords {
-		retailCurrentMonthSum += record.RetailCurrentMonth
-		retailLastYearMonthSum += record.RetailLastYearMonth
-		retailCurrentCumulativeSum += record.RetailCurrentCumulative
-		retailLastYearCumulativeSum += record.RetailLastYearCumulative
-	}
-
-	// 住餐企业零售额
-	for _, record := range acRecords {
-		// 住餐模板右侧“零售额”口径：餐费收入 + 商品销售额（与 12月月报（定）.xlsx 一致）
-		retailCurrentMonthSum += record.FoodCurrentMonth + record.GoodsCurrentMonth
-		retailLastYearMonthSum += record.FoodLastYearMonth + record.GoodsLastYearMonth
-		retailCurrentCumulativeSum += record.FoodCurrentCumulative + record.GoodsCurrentCumulative
-		retailLastYearCumulativeSum += record.FoodLastYearCumulative + record.GoodsLastYearCumulative
-	}
-
-	// 计算增速
-	monthRate := 0.0
-	if retailLastYearMonthSum != 0 {
-		monthRate = (retailCurrentMonthSum - retailLastYearMonthSum) / retailLastYearMonthSum * 100
-	}
-
-	cumulativeRate := 0.0
-	if retailLastYearCumulativeSum != 0 {
-		cumulativeRate = (retailCurrentCumulativeSum - retailLastYearCumulativeSum) / retailLastYearCumulativeSum * 100
-	}
-
-	return []Indicator{
-		{
-			ID:    "limitAbove_month_value",
-			Name:  "限上社零额（当月值）",
-			Value: retailCurrentMonthSum,
-			Unit:  "万元",
-		},
-		{
-			ID:    "limitAbove_month_rate",
-			Name:  "限上社零额增速（当月）",
-			Value: monthRate,
-			Unit:  "%",
-		},
-		{
-			ID:    "limitAbove_cumulative_value",
-			Name:  "限上社零额（累计值）",
-			Value: retailCurrentCumulativeSum,
-			Unit:  "万元",
-		},
-		{
-			ID:    "limitAbove_cumulative_rate",
-			Name:  "限上社零额增速（累计）",
-			Value: cumulativeRate,
-			Unit:  "%",
-		},
-	}, nil
+// Totals 某月指标定义引用的全部汇总项合计
+type Totals struct {
+	sums map[Term]float64
+	// prev 上月汇总项合计（社零额（定）的上月数）
+	prev map[Term]float64
+	// social 社零额（定）表中来自配置项的输入
+	social SocialRetailInputs
 }
 
-// calculateSpecialRates 计算专项增速（2个指标）
-func (c *Calculator) calculateSpecialRates(year, month int) ([]Indicator, error) {
-	// 吃穿用增速
-	eatWearUseOpts := store.WRQueryOptions{
-		DataYear:     &year,
-		DataMonth:    &month,
-		IsEatWearUse: intPtr(1),
+// Totals 每张表一次按行业分组的汇总查询，得到 Registry 引用的全部汇总项（本月与上月）
+func (c *Calculator) Totals(year, month int) (Totals, error) {
+	t := Totals{social: SocialRetailInputs{Month: month}}
+	var err error
+	if t.sums, err = c.sums(year, month); err != nil {
+		return t, err
 	}
-	eatWearUseRecords, err := c.store.GetWRByYearMonth(eatWearUseOpts)
-	if err != nil {
-		return nil, err
+	prevYear, prevMonth := year, month-1
+	if prevMonth < 1 {
+		prevYear, prevMonth = year-1, 12
 	}
-
-	var eatWearUseCurrentSum float64
-	var eatWearUseLastYearSum float64
-	for _, record := range eatWearUseRecords {
-		eatWearUseCurrentSum += record.RetailCurrentMonth
-		eatWearUseLastYearSum += record.RetailLastYearMonth
+	if t.prev, err = c.sums(prevYear, prevMonth); err != nil {
+		return t, err
 	}
 
-	eatWearUseRate := 0.0
-	if eatWearUseLastYearSum != 0 {
-		eatWearUseRate = (eatWearUseCurrentSum - eatWearUseLastYearSum) / eatWearUseLastYearSum * 100
+	// 获取配置：社零额（定）输入（未配置按 0）
+	for key, field := range socialRetailConfigKeys {
+		if v, err := c.store.GetConfigFloat(key); err == nil {
+			*field(&t.social) = v
+		}
 	}
-
-	// 小微企业增速
-	smallMicroOpts := store.WRQueryOptions{
-		DataYear:     &year,
-		DataMonth:    &month,
-		IsSmallMicro: intPtr(1),
-	}
-	smallMicroRecords, err := c.store.GetWRByYearMonth(smallMicroOpts)
-	if err != nil {
-		return nil, err
-	}
-
-	var smallMicroCurrentSum float64
-	var smallMicroLastYearSum float64
-	for _, record := range smallMicroRecords {
-		smallMicroCurrentSum += record.RetailCurrentMonth
-		smallMicroLastYearSum += record.RetailLastYearMonth
-	}
-
-	smallMicroRate := 0.0
-	if smallMicroLastYearSum != 0 {
-		smallMicroRate = (smallMicroCurrentSum - smallMicroLastYearSum) / smallMicroLastYearSum * 100
-	}
-
-	return []Indicator{
-		{
-			ID:    "eatWearUse_month_rate",
-			Name:  "吃穿用增速（当月）",
-			Value: eatWearUseRate,
-			Unit:  "%",
-		},
-		{
-			ID:    "microSmall_month_rate",
-			Name:  "小微企业增速（当月）",
-			Value: smallMicroRate,
-			Unit:  "%",
-		},
-	}, nil
+	return t, nil
 }
 
-// calculateIndustryRates 计算四大行业增速（8个指标）
-func (c *Calculator) calculateIndustryRates(year, month int) ([]Indicator, error) {
-	indicators := []Indicator{}
-
-	// 批发业
-	wholesaleIndicators, err := c.calculateWRIndustryRate(year, month, "wholesale", "批发业销售额")
-	if err != nil {
-		return nil, err
-	}
-	indicators = append(indicators, wholesaleIndicators...)
-
-	// 零售业
-	retailIndicators, err := c.calculateWRIndustryRate(year, month, "retail", "零售业销售额")
-	if err != nil {
-		return nil, err
-	}
-	indicators = append(indicators, retailIndicators...)
-
-	// 住宿业
-	accommodationIndicators, err := c.calculateACIndustryRate(year, month, "accommodation", "住宿业营业额")
-	if err != nil {
-		return nil, err
-	}
-	indicators = append(indicators, accommodationIndicators...)
-
-	// 餐饮业
-	cateringIndicators, err := c.calculateACIndustryRate(year, month, "catering", "餐饮业营业额")
-	if err != nil {
-		return nil, err
-	}
-	indicators = append(indicators, cateringIndicators...)
-
-	return indicators, nil
+// sums 某月 Registry 引用的全部汇总项合计
+func (c *Calculator) sums(year, month int) (map[Term]float64, error) {
+	t := Totals{sums: map[Term]float64{}}
+	for _, table := range []string{TableWR, TableAC} {
+		exprs := registryExpressions(table)
+		sqlExprs := make([]string, len(exprs))
+		for i, e := range exprs {
+			sqlExprs[i] = e.sqlExpr()
+		}
+		byIndustry, err := c.store.SumByIndustry(table, year, month, sqlExprs)
+		if err != nil {
+			return nil, err
+		}
+		for industryType, sums := range byIndustry {
+			for i, e := range exprs {
+				t.add(e, industryType, sums[i])
+			}
+		}
+	}
+	return t.sums, nil
 }
 
-// calculateWRIndustryRate 计算批零行业增速
-func (c *Calculator) calculateWRIndustryRate(year, month int, industryType, name string) ([]Indicator, error) {
-	opts := store.WRQueryOptions{
-		DataYear:     &year,
-		DataMonth:    &month,
-		IndustryType: &industryType,
-	}
-	records, err := c.store.GetWRByYearMonth(opts)
-	if err != nil {
-		return nil, err
-	}
-
-	var currentMonthSum float64
-	var lastYearMonthSum float64
-	var currentCumulativeSum float64
-	var lastYearCumulativeSum float64
-
-	for _, record := range records {
-		currentMonthSum += record.SalesCurrentMonth
-		lastYearMonthSum += record.SalesLastYearMonth
-		currentCumulativeSum += record.SalesCurrentCumulative
-		lastYearCumulativeSum += record.SalesLastYearCumulative
-	}
-
-	monthRate := 0.0
-	if lastYearMonthSum != 0 {
-		monthRate = (currentMonthSum - lastYearMonthSum) / lastYearMonthSum * 100
+// sqlExpr 汇总项的 SQL 求和表达式（不含行业过滤，行业由分组区分）
+func (t Term) sqlExpr() string {
+	fields := t.FieldList()
+	for i, f := range fields {
+		fields[i] = "COALESCE(" + f + ", 0)"
 	}
-
-	cumulativeRate := 0.0
-	if lastYearCumulativeSum != 0 {
-		cumulativeRate = (currentCumulativeSum - lastYearCumulativeSum) / lastYearCumulativeSum * 100
+	expr := strings.Join(fields, " + ")
+	if t.Flag != "" {
+		expr = "CASE WHEN " + t.Flag + " = 1 THEN " + expr + " END"
 	}
-
-	idPrefix := industryType
-	return []Indicator{
-		{
-			ID:    idPrefix + "_month_rate",
-			Name:  name + "增速（当月）",
-			Value: monthRate,
-			Unit:  "%",
-		},
-		{
-			ID:    idPrefix + "_cumulative_rate",
-			Name:  name + "增速（累计）",
-			Value: cumulativeRate,
-			Unit:  "%",
-		},
-	}, nil
+	return expr
 }
 
-// calculateACIndustryRate 计算住餐行业增速
-func (c *Calculator) calculateACIndustryRate(year, month int, industryType, name string) ([]Indicator, error) {
-	opts := store.ACQueryOptions{
-		DataYear:     &year,
-		DataMonth:    &month,
-		IndustryType: &industryType,
-	}
-	records, err := c.store.GetACByYearMonth(opts)
-	if err != nil {
-		return nil, err
-	}
-
-	var currentMonthSum float64
-	var lastYearMonthSum float64
-	var currentCumulativeSum float64
-	var lastYearCumulativeSum float64
-
-	for _, record := range records {
-		currentMonthSum += record.RevenueCurrentMonth
-		lastYearMonthSum += record.RevenueLastYearMonth
-		currentCumulativeSum += record.RevenueCurrentCumulative
-		lastYearCumulativeSum += record.RevenueLastYearCumulative
+// add 计入某行业企业的合计（同时计入不限行业的合计）
+func (t *Totals) add(expr Term, industryType string, v float64) {
+	if t.sums == nil {
+		t.sums = map[Term]float64{}
 	}
-
-	monthRate := 0.0
-	if lastYearMonthSum != 0 {
-		monthRate = (currentMonthSum - lastYearMonthSum) / lastYearMonthSum * 100
+	expr.IndustryType = ""
+	t.sums[expr] += v
+	if industryType != "" {
+		expr.IndustryType = industryType
+		t.sums[expr] += v
 	}
+}
 
-	cumulativeRate := 0.0
-	if lastYearCumulativeSum != 0 {
-		cumulativeRate = (currentCumulativeSum - lastYearCumulativeSum) / lastYearCumulativeSum * 100
+// Sum 若干汇总项的合计之和（汇总项须来自 Registry，可另加行业过滤）
+func (t Totals) Sum(terms ...Term) float64 {
+	var v float64
+	for _, term := range terms {
+		v += t.sums[term]
 	}
-
-	idPrefix := industryType
-	return []Indicator{
-		{
-			ID:    idPrefix + "_month_rate",
-			Name:  name + "增速（当月）",
-			Value: monthRate,
-			Unit:  "%",
-		},
-		{
-			ID:    idPrefix + "_cumulative_rate",
-			Name:  name + "增速（累计）",
-			Value: cumulativeRate,
-			Unit:  "%",
-		},
-	}, nil
+	return v
 }
 
-// calculateTotalSocial 计算社零总额（2个指标）
-func (c *Calculator) calculateTotalSocial(year, month int, specialIndicators []Indicator) ([]Indicator, error) {
-	// 获取限上累计社零额
-	limitAboveIndicators, err := c.calculateLimitAbove(year, month)
-	if err != nil {
-		return nil, err
+// Evaluate 按定义计算指标值
+func (t Totals) Evaluate(d Definition) float64 {
+	if d.SocialRetailCell != "" {
+		return t.SocialRetail().Cell(d.SocialRetailCell)
 	}
-
-	limitAboveCumulativeValue := limitAboveIndicators[2].Value // 第3个指标是累计值
-
-	// 获取配置：上年累计限下社零额
-	lastYearLimitBelowCumulative, err := c.store.GetConfigFloat("last_year_limit_below_cumulative")
-	if err != nil {
-		lastYearLimitBelowCumulative = 0
+	current := t.Sum(d.Numerator...)
+	if !d.IsRate() {
+		return current
 	}
+	return growthRate(current, t.Sum(d.Denominator...))
+}
 
-	// 获取小微企业增速
-	microSmallRate := specialIndicators[1].Value // 第2个指标是小微增速
-
-	// 估算本年累计限下社零额
-	estimatedLimitBelowCumulative := lastYearLimitBelowCumulative * (1 + microSmallRate/100)
+// SocialRetailInputs 社零额（定）表的输入：配置项 + 本月、上月的限上零售额与专项增速
+func (t Totals) SocialRetailInputs() SocialRetailInputs {
+	in := t.social
+	prev := Totals{sums: t.prev}
+	rate := func(totals Totals, id string) float64 {
+		def, _ := Lookup(id)
+		return totals.Evaluate(def)
+	}
+	limitAbove, _ := Lookup("limitAbove_month_rate")
+
+	in.MicroSmallRate = rate(t, "microSmall_month_rate")
+	in.EatWearUseRate = rate(t, "eatWearUse_month_rate")
+	in.PrevMicroSmallRate = rate(prev, "microSmall_month_rate")
+	in.PrevEatWearUseRate = rate(prev, "eatWearUse_month_rate")
+	in.PrevLimitAbove = WanYuan(prev.Sum(limitAbove.Numerator...))
+	in.PrevLimitAboveLastYear = WanYuan(prev.Sum(limitAbove.Denominator...))
+	in.LimitAboveLastYear = WanYuan(t.Sum(limitAbove.Denominator...))
+	in.LimitAbove = WanYuan(t.Sum(limitAbove.Numerator...))
+	return in
+}
 
-	// 社零总额（累计值）
-	totalSocialCumulative := limitAboveCumulativeValue + estimatedLimitBelowCumulative
+// SocialRetail 按 社零额（定） 表公式计算社零总额
+func (t Totals) SocialRetail() SocialRetail {
+	return t.SocialRetailInputs().Compute()
+}
 
-	// 社零总额增速（累计）
-	// 上年社零总额（累计）
-	wrOpts := store.WRQueryOptions{
-		DataYear:  &year,
-		DataMonth: &month,
-	}
-	wrRecords, err := c.store.GetWRByYearMonth(wrOpts)
-	if err != nil {
-		return nil, err
+// Indicators 计算全部指标并分组
+func (t Totals) Indicators() []IndicatorGroup {
+	values := make(map[string]float64, len(Registry))
+	for _, d := range Registry {
+		values[d.ID] = t.Evaluate(d)
 	}
+	return groupIndicators(values)
+}
 
-	var retailLastYearCumulativeSum float64
-	for _, record := range wrRecords {
-		retailLastYearCumulativeSum += record.RetailLastYearCumulative
+// groupIndicators 按定义顺序把指标值组装为分组
+func groupIndicators(values map[string]float64) []IndicatorGroup {
+	groups := make([]IndicatorGroup, len(IndicatorGroupNames))
+	index := map[string]int{}
+	for i, name := range IndicatorGroupNames {
+		groups[i].Name = name
+		index[name] = i
 	}
-
-	lastYearTotalCumulative := retailLastYearCumulativeSum + lastYearLimitBelowCumulative
-
-	totalSocialRate := 0.0
-	if lastYearTotalCumulative != 0 {
-		totalSocialRate = (totalSocialCumulative - lastYearTotalCumulative) / lastYearTotalCumulative * 100
+	for _, d := range Registry {
+		i := index[d.Group]
+		groups[i].Indicators = append(groups[i].Indicators, Indicator{ID: d.ID, Name: d.Name, Value: values[d.ID], Unit: d.Unit})
 	}
-
-	return []Indicator{
-		{
-			ID:    "totalSocial_cumulative_value",
-			Name:  "社零总额（累计值）",
-			Value: totalSocialCumulative,
-			Unit:  "万元",
-		},
-		{
-			ID:    "totalSocial_cumulative_rate",
-			Name:  "社零总额增速（累计）",
-			Value: totalSocialRate,
-			Unit:  "%",
-		},
-	}, nil
+	return groups
 }
 
-// intPtr 返回整数指针
-func intPtr(i int) *int {
-	return &i
+// growthRate 同比增速（%），上年为 0 时返回 0
+func growthRate(current, lastYear float64) float64 {
+	if lastYear == 0 {
+		return 0
+	}
+	return (current - lastYear) / lastYear * 100
 }
@@ -0,0 +1,212 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+// seedMonth 生成 n 家企业（80% 批零、20% 住餐）的月度数据
+func seedMonth(tb testing.TB, st *store.Store, year, month, n int) {
+	tb.Helper()
+	rng := rand.New(rand.NewSource(42))
+	amount := func() float64 { return math.Round(rng.Float64()*100000) / 100 }
+
+	var wr []*model.WholesaleRetail
+	var ac []*model.AccommodationCatering
+	for i := 0; i < n; i++ {
+		if i%5 != 4 {
+			r := &model.WholesaleRetail{
+				CreditCode:   fmt.Sprintf("WR%06d", i),
+				Name:         fmt.Sprintf("批零企业%d", i),
+				IndustryType: []string{"wholesale", "retail"}[i%2],
+				DataYear:     year,
+				DataMonth:    month,
+				IsSmallMicro: rng.Intn(2),
+				IsEatWearUse: rng.Intn(2),
+			}
+			r.SalesCurrentMonth, r.SalesLastYearMonth = amount(), amount()
+			r.SalesCurrentCumulative, r.SalesLastYearCumulative = amount()*10, amount()*10
+			r.RetailCurrentMonth, r.RetailLastYearMonth = amount(), amount()
+			r.RetailCurrentCumulative, r.RetailLastYearCumulative = amount()*10, amount()*10
+			r.SalesPrevMonth, r.RetailPrevMonth = amount(), amount()
+			wr = append(wr, r)
+			continue
+		}
+		r := &model.AccommodationCatering{
+			CreditCode:   fmt.Sprintf("AC%06d", i),
+			Name:         fmt.Sprintf("住餐企业%d", i),
+			IndustryType: []string{"accommodation", "catering"}[i%2],
+			DataYear:     year,
+			DataMonth:    month,
+		}
+		r.RevenueCurrentMonth, r.RevenueLastYearMonth = amount(), amount()
+		r.RevenueCurrentCumulative, r.RevenueLastYearCumulative = amount()*10, amount()*10
+		r.FoodCurrentMonth, r.FoodLastYearMonth = amount(), amount()
+		r.FoodCurrentCumulative, r.FoodLastYearCumulative = amount()*10, amount()*10
+		r.GoodsCurrentMonth, r.GoodsLastYearMonth = amount(), amount()
+		r.GoodsCurrentCumulative, r.GoodsLastYearCumulative = amount()*10, amount()*10
+		r.RevenuePrevMonth = amount()
+		ac = append(ac, r)
+	}
+	if err := st.BatchInsertWR(wr); err != nil {
+		tb.Fatalf("insert wr: %v", err)
+	}
+	if err := st.BatchInsertAC(ac); err != nil {
+		tb.Fatalf("insert ac: %v", err)
+	}
+	for key, v := range map[string]float64{
+		"history_social_e18": 1515156, "history_social_e19": 1445208, "history_social_e20": 1597656,
+		"history_social_e21": 1396358, "history_social_e22": 1328076, "history_social_e23": 1445208,
+		"weight_small_micro": 0.1, "weight_eat_wear_use": 0.1, "weight_sample": 0.1,
+	} {
+		if err := st.SetConfigFloat(key, v); err != nil {
+			tb.Fatalf("set config: %v", err)
+		}
+	}
+}
+
+func newTestStore(tb testing.TB) *store.Store {
+	tb.Helper()
+	st, err := store.New(filepath.Join(tb.TempDir(), "northstar.db"))
+	if err != nil {
+		tb.Fatalf("init store: %v", err)
+	}
+	tb.Cleanup(func() { _ = st.Close() })
+	return st
+}
+
+func TestCalculateAll_MatchesRecordLevelSums(t *testing.T) {
+	st := newTestStore(t)
+	seedMonth(t, st, 2025, 12, 500)
+
+	year, month := 2025, 12
+	wr, err := st.GetWRByYearMonth(store.WRQueryOptions{DataYear: &year, DataMonth: &month})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac, err := st.GetACByYearMonth(store.ACQueryOptions{DataYear: &year, DataMonth: &month})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 逐条累加得到的期望值：[本月, 上年同月, 本年累计, 上年累计, 上月]
+	type sums [5]float64
+	add := func(a *sums, v ...float64) {
+		for i := range v {
+			a[i] += v[i]
+		}
+	}
+	var limitAbove, eatWearUse, smallMicro sums
+	industry := map[string]*sums{"wholesale": {}, "retail": {}, "accommodation": {}, "catering": {}}
+	for _, r := range wr {
+		add(&limitAbove, r.RetailCurrentMonth, r.RetailLastYearMonth, r.RetailCurrentCumulative, r.RetailLastYearCumulative)
+		if r.IsEatWearUse == 1 {
+			add(&eatWearUse, r.RetailCurrentMonth, r.RetailLastYearMonth, 0, 0, r.RetailPrevMonth)
+		}
+		if r.IsSmallMicro == 1 {
+			add(&smallMicro, r.RetailCurrentMonth, r.RetailLastYearMonth, 0, 0, r.RetailPrevMonth)
+		}
+		add(industry[r.IndustryType], r.SalesCurrentMonth, r.SalesLastYearMonth, r.SalesCurrentCumulative, r.SalesLastYearCumulative, r.SalesPrevMonth)
+	}
+	for _, r := range ac {
+		add(&limitAbove,
+			r.FoodCurrentMonth+r.GoodsCurrentMonth, r.FoodLastYearMonth+r.GoodsLastYearMonth,
+			r.FoodCurrentCumulative+r.GoodsCurrentCumulative, r.FoodLastYearCumulative+r.GoodsLastYearCumulative,
+		)
+		add(industry[r.IndustryType], r.RevenueCurrentMonth, r.RevenueLastYearMonth, r.RevenueCurrentCumulative, r.RevenueLastYearCumulative, r.RevenuePrevMonth)
+	}
+	microSmallRate := growthRate(smallMicro[0], smallMicro[1])
+	// 无上月数据：K4、K6 与上月增速为 0
+	social := SocialRetailInputs{
+		MicroSmallRate: microSmallRate, EatWearUseRate: growthRate(eatWearUse[0], eatWearUse[1]),
+		WeightSmallMicro: 0.1, WeightEatWearUse: 0.1, WeightSample: 0.1,
+		LimitAbove: math.Round(limitAbove[0] / 10), LimitAboveLastYear: math.Round(limitAbove[1] / 10),
+		E18: 1515156, E19: 1445208, E20: 1597656, E21: 1396358, E22: 1328076, E23: 1445208,
+	}.Compute()
+
+	want := map[string]float64{
+		"limitAbove_month_value":       limitAbove[0],
+		"limitAbove_month_rate":        growthRate(limitAbove[0], limitAbove[1]),
+		"limitAbove_cumulative_value":  limitAbove[2],
+		"limitAbove_cumulative_rate":   growthRate(limitAbove[2], limitAbove[3]),
+		"eatWearUse_month_rate":        growthRate(eatWearUse[0], eatWearUse[1]),
+		"microSmall_month_rate":        microSmallRate,
+		"totalSocial_cumulative_value": social.K21,
+		"totalSocial_cumulative_rate":  social.K23,
+		"eatWearUse_mom_rate":          growthRate(eatWearUse[0], eatWearUse[4]),
+		"microSmall_mom_rate":          growthRate(smallMicro[0], smallMicro[4]),
+	}
+	for k, a := range industry {
+		want[k+"_month_rate"] = growthRate(a[0], a[1])
+		want[k+"_cumulative_rate"] = growthRate(a[2], a[3])
+		want[k+"_mom_rate"] = growthRate(a[0], a[4])
+	}
+
+	groups, err := NewCalculator(st).CalculateAll(2025, 12)
+	if err != nil {
+		t.Fatalf("CalculateAll: %v", err)
+	}
+	got := 0
+	for _, g := range groups {
+		for _, ind := range g.Indicators {
+			got++
+			if math.Abs(ind.Value-want[ind.ID]) > 1e-6*math.Max(1, math.Abs(want[ind.ID])) {
+				t.Errorf("%s = %v, want %v", ind.ID, ind.Value, want[ind.ID])
+			}
+		}
+	}
+	if got != len(want) {
+		t.Fatalf("indicator count = %d, want %d", got, len(want))
+	}
+}
+
+// TestSocialRetail_TemplateExample 复现模板 社零额（定） 中的示例数据（2025年12月）
+func TestSocialRetail_TemplateExample(t *testing.T) {
+	s := SocialRetailInputs{
+		Month:          12,
+		MicroSmallRate: 3.2, PrevMicroSmallRate: 3.9,
+		EatWearUseRate: 1, PrevEatWearUseRate: 2.5,
+		SampleRate: -2, PrevSampleRate: 3.5,
+		WeightSmallMicro: 0.1, WeightEatWearUse: 0.1, WeightSample: 0.1,
+		PrevLimitAbove: 46207, PrevLimitAboveLastYear: 42173, LimitAboveLastYear: 57285, LimitAbove: 63042,
+		E18: 1515156, E19: 1445208, E20: 1597656, E21: 1396358, E22: 1328076, E23: 1445208,
+	}.Compute()
+
+	for _, c := range []struct {
+		cell      string
+		got, want float64
+	}{
+		{"B16", s.B16, -0.77},
+		{"K3", s.K3, 118798},
+		{"K7", s.K7, 72591},
+		{"K9", s.K9, -3.15906028628984},
+		{"K15", s.K15, 91423.988359758},
+		{"K17", s.K17, 154465.988359758},
+		{"K19", s.K19, 1.32372242322496},
+		{"K21", s.K21, 1669621.98835976},
+		{"K23", s.K23, 4.50447332590733},
+	} {
+		if math.Abs(c.got-c.want) > 1e-6 {
+			t.Errorf("%s = %v, want %v", c.cell, c.got, c.want)
+		}
+	}
+}
+
+func BenchmarkCalculateAll_20kCompanies(b *testing.B) {
+	st := newTestStore(b)
+	seedMonth(b, st, 2025, 12, 20000)
+	calc := NewCalculator(st)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := calc.CalculateAll(2025, 12); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
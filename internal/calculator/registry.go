@@ -0,0 +1,219 @@
+package calculator
+
+import "strings"
+
+// 企业数据表
+const (
+	TableWR = "wholesale_retail"
+	TableAC = "accommodation_catering"
+)
+
+// Term 汇总项：某表中满足过滤条件的企业，若干字段之和的合计
+type Term struct {
+	Table        string // TableWR / TableAC
+	Fields       string // 以 "+" 连接的字段，如 "food_current_month+goods_current_month"
+	IndustryType string // 行业类型过滤，空表示不限
+	Flag         string // 标记字段过滤（值为 1），空表示不限
+}
+
+// FieldList 参与求和的字段
+func (t Term) FieldList() []string {
+	return strings.Split(t.Fields, "+")
+}
+
+// LastYear 本期字段替换为上年同期字段
+func (t Term) LastYear() Term {
+	fields := t.FieldList()
+	for i, f := range fields {
+		fields[i] = LastYearField(f)
+	}
+	t.Fields = strings.Join(fields, "+")
+	return t
+}
+
+// PrevMonth 本月字段替换为上月字段
+func (t Term) PrevMonth() Term {
+	fields := t.FieldList()
+	for i, f := range fields {
+		fields[i] = PrevMonthField(f)
+	}
+	t.Fields = strings.Join(fields, "+")
+	return t
+}
+
+// LastYearField 本期字段对应的上年同期字段
+func LastYearField(field string) string {
+	return strings.Replace(field, "_current_", "_last_year_", 1)
+}
+
+// PrevMonthField 本月字段对应的上月字段；非本月字段（如累计）返回空
+func PrevMonthField(field string) string {
+	if !strings.HasSuffix(field, "_current_month") {
+		return ""
+	}
+	return strings.TrimSuffix(field, "_current_month") + "_prev_month"
+}
+
+// InIndustry 为汇总项加上行业类型过滤
+func InIndustry(terms []Term, industryType string) []Term {
+	out := make([]Term, len(terms))
+	for i, t := range terms {
+		t.IndustryType = industryType
+		out[i] = t
+	}
+	return out
+}
+
+func lastYearTerms(terms []Term) []Term {
+	out := make([]Term, len(terms))
+	for i, t := range terms {
+		out[i] = t.LastYear()
+	}
+	return out
+}
+
+func prevMonthTerms(terms []Term) []Term {
+	out := make([]Term, len(terms))
+	for i, t := range terms {
+		out[i] = t.PrevMonth()
+	}
+	return out
+}
+
+// Definition 指标定义：计算器、智能调整与导出共用同一份定义
+type Definition struct {
+	ID    string
+	Name  string
+	Unit  string
+	Group string
+	// Numerator 本期合计；Denominator 为空时指标值即本期合计，否则为相对 Denominator 的增速（%）：
+	// 上年同期字段为同比，上月字段为环比
+	Numerator   []Term
+	Denominator []Term
+	// SocialRetailCell 非空时指标取 社零额（定） 表该单元格的计算结果，Numerator 为其中的限上当月零售额（K16）
+	SocialRetailCell string
+}
+
+// IsRate 指标是否为增速
+func (d Definition) IsRate() bool {
+	return len(d.Denominator) > 0
+}
+
+// IndicatorGroupNames 指标分组（顺序即输出顺序）
+var IndicatorGroupNames = []string{"限上社零额", "专项增速", "四大行业增速", "社零总额", "环比增速"}
+
+// Registry 全部指标的定义（顺序即输出顺序）
+var Registry = func() []Definition {
+	// 限上社零额 = 批零零售额 + 住餐零售额（餐费收入 + 商品销售额，与 12月月报（定）.xlsx 一致）
+	limitAboveMonth := []Term{
+		{Table: TableWR, Fields: "retail_current_month"},
+		{Table: TableAC, Fields: "food_current_month+goods_current_month"},
+	}
+	limitAboveCumulative := []Term{
+		{Table: TableWR, Fields: "retail_current_cumulative"},
+		{Table: TableAC, Fields: "food_current_cumulative+goods_current_cumulative"},
+	}
+	eatWearUse := []Term{{Table: TableWR, Fields: "retail_current_month", Flag: "is_eat_wear_use"}}
+	smallMicro := []Term{{Table: TableWR, Fields: "retail_current_month", Flag: "is_small_micro"}}
+
+	defs := []Definition{
+		{ID: "limitAbove_month_value", Name: "限上社零额（当月值）", Unit: "万元", Group: "限上社零额",
+			Numerator: limitAboveMonth},
+		{ID: "limitAbove_month_rate", Name: "限上社零额增速（当月）", Unit: "%", Group: "限上社零额",
+			Numerator: limitAboveMonth, Denominator: lastYearTerms(limitAboveMonth)},
+		{ID: "limitAbove_cumulative_value", Name: "限上社零额（累计值）", Unit: "万元", Group: "限上社零额",
+			Numerator: limitAboveCumulative},
+		{ID: "limitAbove_cumulative_rate", Name: "限上社零额增速（累计）", Unit: "%", Group: "限上社零额",
+			Numerator: limitAboveCumulative, Denominator: lastYearTerms(limitAboveCumulative)},
+		{ID: "eatWearUse_month_rate", Name: "吃穿用增速（当月）", Unit: "%", Group: "专项增速",
+			Numerator: eatWearUse, Denominator: lastYearTerms(eatWearUse)},
+		{ID: "microSmall_month_rate", Name: "小微企业增速（当月）", Unit: "%", Group: "专项增速",
+			Numerator: smallMicro, Denominator: lastYearTerms(smallMicro)},
+	}
+	industries := []struct{ table, industryType, field, name string }{
+		{TableWR, "wholesale", "sales", "批发业销售额"},
+		{TableWR, "retail", "sales", "零售业销售额"},
+		{TableAC, "accommodation", "revenue", "住宿业营业额"},
+		{TableAC, "catering", "revenue", "餐饮业营业额"},
+	}
+	for _, i := range industries {
+		defs = append(defs, industryDefinitions(i.table, i.industryType, i.field, i.name)...)
+	}
+
+	// 社零总额按 社零额（定） 表计算：上月累计 + 本月限下估算 + 本月限上
+	defs = append(defs,
+		Definition{ID: "totalSocial_cumulative_value", Name: "社零总额（累计值）", Unit: "万元", Group: "社零总额",
+			Numerator: limitAboveMonth, SocialRetailCell: "K21"},
+		Definition{ID: "totalSocial_cumulative_rate", Name: "社零总额增速（累计）", Unit: "%", Group: "社零总额",
+			Numerator: limitAboveMonth, Denominator: lastYearTerms(limitAboveMonth), SocialRetailCell: "K23"},
+	)
+
+	// 环比增速：本月相对上月
+	defs = append(defs,
+		Definition{ID: "eatWearUse_mom_rate", Name: "吃穿用环比增速（当月）", Unit: "%", Group: "环比增速",
+			Numerator: eatWearUse, Denominator: prevMonthTerms(eatWearUse)},
+		Definition{ID: "microSmall_mom_rate", Name: "小微企业环比增速（当月）", Unit: "%", Group: "环比增速",
+			Numerator: smallMicro, Denominator: prevMonthTerms(smallMicro)},
+	)
+	for _, i := range industries {
+		month := []Term{{Table: i.table, Fields: i.field + "_current_month", IndustryType: i.industryType}}
+		defs = append(defs, Definition{ID: i.industryType + "_mom_rate", Name: i.name + "环比增速（当月）", Unit: "%", Group: "环比增速",
+			Numerator: month, Denominator: prevMonthTerms(month)})
+	}
+	return defs
+}()
+
+// SocialRetailRates 估算本月限下社零额所用的本月增速指标（社零额（定） B4、C4）
+var SocialRetailRates = []string{"microSmall_month_rate", "eatWearUse_month_rate"}
+
+// industryDefinitions 行业增速（当月、累计）
+func industryDefinitions(table, industryType, field, name string) []Definition {
+	month := []Term{{Table: table, Fields: field + "_current_month", IndustryType: industryType}}
+	cumulative := []Term{{Table: table, Fields: field + "_current_cumulative", IndustryType: industryType}}
+	return []Definition{
+		{ID: industryType + "_month_rate", Name: name + "增速（当月）", Unit: "%", Group: "四大行业增速",
+			Numerator: month, Denominator: lastYearTerms(month)},
+		{ID: industryType + "_cumulative_rate", Name: name + "增速（累计）", Unit: "%", Group: "四大行业增速",
+			Numerator: cumulative, Denominator: lastYearTerms(cumulative)},
+	}
+}
+
+// Lookup 按 ID 查找指标定义
+func Lookup(id string) (Definition, bool) {
+	for _, d := range Registry {
+		if d.ID == id {
+			return d, true
+		}
+	}
+	return Definition{}, false
+}
+
+// dependencies 指标依赖的本月汇总项（社零额（定）指标含限上当月、上年同月及估算限下所用增速的汇总项）
+func (d Definition) dependencies() []Term {
+	deps := append(append([]Term{}, d.Numerator...), d.Denominator...)
+	if d.SocialRetailCell != "" {
+		for _, id := range append([]string{"limitAbove_month_rate"}, SocialRetailRates...) {
+			if rate, ok := Lookup(id); ok {
+				deps = append(deps, rate.dependencies()...)
+			}
+		}
+	}
+	return deps
+}
+
+// registryExpressions 每张表需汇总的表达式（字段和 + 标记过滤，不含行业过滤），按首次出现顺序
+func registryExpressions(table string) []Term {
+	var out []Term
+	seen := map[Term]bool{}
+	for _, d := range Registry {
+		for _, t := range append(append([]Term{}, d.Numerator...), d.Denominator...) {
+			t.IndustryType = ""
+			if t.Table != table || seen[t] {
+				continue
+			}
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package calculator
+
+import "math"
+
+// SocialRetailInputs 社零额（定）表的输入单元格（金额：万元；增速：%）
+type SocialRetailInputs struct {
+	Month int `json:"J2"` // 当前月份
+
+	MicroSmallRate     float64 `json:"B4"` // 本月小微企业零售额增速
+	EatWearUseRate     float64 `json:"C4"` // 本月吃穿用商品零售额增速
+	SampleRate         float64 `json:"D4"` // 本月限下抽样单位零售额增速（配置）
+	PrevMicroSmallRate float64 `json:"B6"` // 上月小微企业零售额增速
+	PrevEatWearUseRate float64 `json:"C6"` // 上月吃穿用商品零售额增速
+	PrevSampleRate     float64 `json:"D6"` // 上月限下抽样单位零售额增速（配置）
+
+	WeightSmallMicro   float64 `json:"B12"` // 小微权重
+	WeightEatWearUse   float64 `json:"C12"` // 吃穿用权重
+	WeightSample       float64 `json:"D12"` // 抽样权重
+	ProvinceRateChange float64 `json:"I3"`  // 全省（市）限下零售额增速变动量
+
+	PrevLimitAbove         float64 `json:"K4"`  // 上月平台上报零售额（限上当月）
+	PrevLimitAboveLastYear float64 `json:"K6"`  // 上月平台上报零售额上年同期数
+	LimitAboveLastYear     float64 `json:"K13"` // 本月平台上报零售额上年同期数
+	LimitAbove             float64 `json:"K16"` // 本月平台上报零售额（限上当月）
+
+	E18 float64 `json:"E18"` // 本年 1~上月 社零额
+	E19 float64 `json:"E19"` // 上年 1~上月 社零额
+	E20 float64 `json:"E20"` // 上年 1~本月 社零额
+	E21 float64 `json:"E21"` // 本年 1~上上月 社零额
+	E22 float64 `json:"E22"` // 上年 1~上上月 社零额
+	E23 float64 `json:"E23"` // 上年 1~上月 社零额（本月同期的上月累计）
+}
+
+// SocialRetail 社零额（定）表的计算结果，字段与模板公式单元格一一对应
+type SocialRetail struct {
+	Inputs SocialRetailInputs `json:"inputs"`
+
+	B16 float64 `json:"B16"` // 限下当月增速变动量初算结果 = Σ(本月-上月增速)×权重 + I3
+	K3  float64 `json:"K3"`  // 上月社零额（当月累计-上月累计）
+	K5  float64 `json:"K5"`  // 上年上月社零额
+	K7  float64 `json:"K7"`  // 上月限下社零额（当月-限上）
+	K8  float64 `json:"K8"`  // 上年上月限下社零额
+	K9  float64 `json:"K9"`  // 上月限下社零额增速
+	K11 float64 `json:"K11"` // 本月限下社零额增速（上月限下增速+增速变动量）
+	K12 float64 `json:"K12"` // 上年本月社零额
+	K14 float64 `json:"K14"` // 上年本月限下零售额（当月-限上）
+	K15 float64 `json:"K15"` // 本月限下社零额（同期限下×(1+当月限下增速)）
+	K17 float64 `json:"K17"` // 本月社零额总量（当月限下+限上）
+	K19 float64 `json:"K19"` // 本月社零额增速
+	K21 float64 `json:"K21"` // 本年 1~本月 社零额总量（上月累计+本月）
+	K23 float64 `json:"K23"` // 本年 1~本月 社零额增速
+}
+
+// Compute 按模板 社零额（定） 的公式计算（除数为 0 时增速取 0）
+func (in SocialRetailInputs) Compute() SocialRetail {
+	s := SocialRetail{Inputs: in}
+	s.B16 = (in.MicroSmallRate-in.PrevMicroSmallRate)*in.WeightSmallMicro +
+		(in.EatWearUseRate-in.PrevEatWearUseRate)*in.WeightEatWearUse +
+		(in.SampleRate-in.PrevSampleRate)*in.WeightSample +
+		in.ProvinceRateChange
+
+	s.K3 = in.E18 - in.E21
+	s.K5 = in.E19 - in.E22
+	s.K7 = s.K3 - in.PrevLimitAbove
+	s.K8 = s.K5 - in.PrevLimitAboveLastYear
+	s.K9 = growthRate(s.K7, s.K8)
+	s.K11 = s.K9 + s.B16
+
+	s.K12 = in.E20 - in.E23
+	s.K14 = s.K12 - in.LimitAboveLastYear
+	s.K15 = s.K14 * (1 + s.K11/100)
+	s.K17 = s.K15 + in.LimitAbove
+	s.K19 = growthRate(s.K17, s.K12)
+
+	s.K21 = in.E18 + s.K17
+	s.K23 = growthRate(s.K21, in.E20)
+	return s
+}
+
+// Cell 按单元格名取计算结果
+func (s SocialRetail) Cell(name string) float64 {
+	switch name {
+	case "K21":
+		return s.K21
+	case "K23":
+		return s.K23
+	}
+	return 0
+}
+
+// OverrideRate 以指定值替换 SocialRetailRates 中某个本月增速输入（智能调整按目标值估算限下社零额）
+func (in *SocialRetailInputs) OverrideRate(id string, v float64) {
+	switch id {
+	case "microSmall_month_rate":
+		in.MicroSmallRate = v
+	case "eatWearUse_month_rate":
+		in.EatWearUseRate = v
+	}
+}
+
+// WanYuan 企业表金额（千元）折合为社零额（定）口径（万元，取整，与导出一致）
+func WanYuan(thousand float64) float64 {
+	return math.Round(thousand / 10)
+}
+
+// socialRetailConfigKeys 社零额（定）表中来自配置项的输入
+var socialRetailConfigKeys = map[string]func(in *SocialRetailInputs) *float64{
+	"sample_rate_month":                func(in *SocialRetailInputs) *float64 { return &in.SampleRate },
+	"sample_rate_prev":                 func(in *SocialRetailInputs) *float64 { return &in.PrevSampleRate },
+	"weight_small_micro":               func(in *SocialRetailInputs) *float64 { return &in.WeightSmallMicro },
+	"weight_eat_wear_use":              func(in *SocialRetailInputs) *float64 { return &in.WeightEatWearUse },
+	"weight_sample":                    func(in *SocialRetailInputs) *float64 { return &in.WeightSample },
+	"province_limit_below_rate_change": func(in *SocialRetailInputs) *float64 { return &in.ProvinceRateChange },
+	"history_social_e18":               func(in *SocialRetailInputs) *float64 { return &in.E18 },
+	"history_social_e19":               func(in *SocialRetailInputs) *float64 { return &in.E19 },
+	"history_social_e20":               func(in *SocialRetailInputs) *float64 { return &in.E20 },
+	"history_social_e21":               func(in *SocialRetailInputs) *float64 { return &in.E21 },
+	"history_social_e22":               func(in *SocialRetailInputs) *float64 { return &in.E22 },
+	"history_social_e23":               func(in *SocialRetailInputs) *float64 { return &in.E23 },
+}
@@ -25,6 +25,12 @@ type ServerConfig struct {
 type DataConfig struct {
 	DataDir    string `toml:"data_dir"`
 	AutoBackup bool   `toml:"auto_backup"`
+	// BackupKeep 保留的数据库快照份数
+	BackupKeep int `toml:"backup_keep"`
+	// BackupIntervalMinutes 定时快照间隔（分钟），0 表示不做定时快照
+	BackupIntervalMinutes int `toml:"backup_interval_minutes"`
+	// KeepImportFiles 保留导入的原始工作簿（data_dir/imports），用于重新导入与问题标注
+	KeepImportFiles bool `toml:"keep_import_files"`
 }
 
 // BusinessConfig 业务配置
@@ -52,8 +58,11 @@ func DefaultConfig() *AppConfig {
 			DevMode: false,
 		},
 		Data: DataConfig{
-			DataDir:    "data",
-			AutoBackup: true,
+			DataDir:               "data",
+			AutoBackup:            true,
+			BackupKeep:            10,
+			BackupIntervalMinutes: 360,
+			KeepImportFiles:       true,
 		},
 		Business: BusinessConfig{
 			DefaultMonth: 1,
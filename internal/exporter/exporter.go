@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/xuri/excelize/v2"
+	"northstar/internal/calculator"
 	"northstar/internal/model"
 	"northstar/internal/store"
 )
@@ -125,7 +126,11 @@ func (e *Exporter) fillTemplateWorkbook(f *excelize.File, opts ExportOptions) er
 	}
 
 	reportProgress(opts.Progress, 74, "重算固定汇总区")
-	wh, re, acc, cat, err := e.rewriteFixedTotals(f)
+	totals, err := calculator.NewCalculator(e.store).Totals(opts.Year, opts.Month)
+	if err != nil {
+		return fmt.Errorf("汇总指标数据失败: %w", err)
+	}
+	overall, err := e.rewriteFixedTotals(f, totals)
 	if err != nil {
 		return err
 	}
@@ -137,12 +142,12 @@ func (e *Exporter) fillTemplateWorkbook(f *excelize.File, opts ExportOptions) er
 	}
 
 	reportProgress(opts.Progress, 90, "写入社会消费品零售")
-	if err := fillSocialRetailSheetAndMaterialize(f, e.store, opts.Year, opts.Month, indicatorIndex); err != nil {
+	if err := fillSocialRetailSheetAndMaterialize(f, totals.SocialRetailInputs()); err != nil {
 		return err
 	}
 
 	reportProgress(opts.Progress, 96, "写入汇总页")
-	if err := rewriteFixedSummarySheet(f, opts.Year, opts.Month, wh, re, acc, cat, indicatorIndex, wrRecords, acRecords); err != nil {
+	if err := rewriteFixedSummarySheet(f, opts.Year, opts.Month, overall, indicatorIndex, wrRecords, acRecords); err != nil {
 		return err
 	}
 
@@ -203,53 +208,80 @@ func (e *Exporter) fillAccommodationCateringSheets(f *excelize.File, records []*
 	return nil
 }
 
+// wrSums 某行业表的汇总行与增速行取值（金额为千元）
 type wrSums struct {
 	maxRow        int
 	salesCur      float64
 	salesLast     float64
 	salesCurCum   float64
 	salesLastCum  float64
+	salesRate     float64
+	salesCumRate  float64
 	retailCur     float64
 	retailLast    float64
 	retailCurCum  float64
 	retailLastCum float64
+	retailRate    float64
+	retailCumRate float64
 }
 
-func (e *Exporter) rewriteFixedTotals(f *excelize.File) (wrSums, wrSums, wrSums, wrSums, error) {
-	wh, err := sumWholesaleRetail(f, "批发")
-	if err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
-	}
-	re, err := sumWholesaleRetail(f, "零售")
-	if err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
-	}
-	acc, err := sumAccommodationCatering(f, "住宿")
-	if err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
+// industrySums 按指标定义（calculator.Registry）汇总某行业的销售额（营业额）与零售额；
+// industryType 为空表示全部限上企业，此时零售额即限上社零额
+func industrySums(t calculator.Totals, industryType string) wrSums {
+	var s wrSums
+	rate := func(id string) (float64, float64, float64) {
+		def, ok := calculator.Lookup(id)
+		if !ok {
+			return 0, 0, 0
+		}
+		return math.Round(t.Sum(def.Numerator...)), math.Round(t.Sum(def.Denominator...)), math.Round(t.Evaluate(def))
 	}
-	cat, err := sumAccommodationCatering(f, "餐饮")
-	if err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
+	if industryType != "" {
+		s.salesCur, s.salesLast, s.salesRate = rate(industryType + "_month_rate")
+		s.salesCurCum, s.salesLastCum, s.salesCumRate = rate(industryType + "_cumulative_rate")
 	}
 
-	if err := rewriteTotalsWholesaleRetail(f, "批发", wh); err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
-	}
-	if err := rewriteTotalsWholesaleRetail(f, "零售", re); err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
-	}
-	if err := rewriteTotalsAccommodationCatering(f, "住宿", acc); err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
-	}
-	if err := rewriteTotalsAccommodationCatering(f, "餐饮", cat); err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
+	month, _ := calculator.Lookup("limitAbove_month_rate")
+	cumulative, _ := calculator.Lookup("limitAbove_cumulative_rate")
+	s.retailCur = math.Round(t.Sum(calculator.InIndustry(month.Numerator, industryType)...))
+	s.retailLast = math.Round(t.Sum(calculator.InIndustry(month.Denominator, industryType)...))
+	s.retailCurCum = math.Round(t.Sum(calculator.InIndustry(cumulative.Numerator, industryType)...))
+	s.retailLastCum = math.Round(t.Sum(calculator.InIndustry(cumulative.Denominator, industryType)...))
+	s.retailRate = math.Round(t.Evaluate(calculator.Definition{Numerator: calculator.InIndustry(month.Numerator, industryType), Denominator: calculator.InIndustry(month.Denominator, industryType)}))
+	s.retailCumRate = math.Round(t.Evaluate(calculator.Definition{Numerator: calculator.InIndustry(cumulative.Numerator, industryType), Denominator: calculator.InIndustry(cumulative.Denominator, industryType)}))
+	return s
+}
+
+// rewriteFixedTotals 重写四个行业表的合计/增速行及批发表右侧的零售额汇总区，返回全部限上企业的汇总
+func (e *Exporter) rewriteFixedTotals(f *excelize.File, totals calculator.Totals) (wrSums, error) {
+	sheets := []struct {
+		sheet        string
+		industryType string
+		rewrite      func(*excelize.File, string, wrSums) error
+	}{
+		{"批发", "wholesale", rewriteTotalsWholesaleRetail},
+		{"零售", "retail", rewriteTotalsWholesaleRetail},
+		{"住宿", "accommodation", rewriteTotalsAccommodationCatering},
+		{"餐饮", "catering", rewriteTotalsAccommodationCatering},
+	}
+	sums := make([]wrSums, len(sheets))
+	for i, sh := range sheets {
+		maxRow, err := findMaxDataRow(f, sh.sheet, "C", 2)
+		if err != nil {
+			return wrSums{}, err
+		}
+		sums[i] = industrySums(totals, sh.industryType)
+		sums[i].maxRow = maxRow
+		if err := sh.rewrite(f, sh.sheet, sums[i]); err != nil {
+			return wrSums{}, err
+		}
 	}
 
-	if err := rewriteOverallRetailAreaOnWholesale(f, wh, re, acc, cat); err != nil {
-		return wrSums{}, wrSums{}, wrSums{}, wrSums{}, err
+	overall := industrySums(totals, "")
+	if err := rewriteOverallRetailAreaOnWholesale(f, sums[0], sums[1], sums[2], sums[3], overall); err != nil {
+		return wrSums{}, err
 	}
-	return wh, re, acc, cat, nil
+	return overall, nil
 }
 
 // ---------- 行写入：批零（批发/零售/批零总表） ----------
@@ -647,60 +679,6 @@ func normalizeCodeText(s string) string {
 
 // ---------- 汇总区重写 ----------
 
-func sumWholesaleRetail(f *excelize.File, sheet string) (wrSums, error) {
-	maxRow, err := findMaxDataRow(f, sheet, "C", 2)
-	if err != nil {
-		return wrSums{}, err
-	}
-	s := wrSums{maxRow: maxRow}
-	for row := 2; row <= maxRow; row++ {
-		d, _ := getCellFloat(f, sheet, fmt.Sprintf("D%d", row))
-		e, _ := getCellFloat(f, sheet, fmt.Sprintf("E%d", row))
-		g, _ := getCellFloat(f, sheet, fmt.Sprintf("G%d", row))
-		h, _ := getCellFloat(f, sheet, fmt.Sprintf("H%d", row))
-		j, _ := getCellFloat(f, sheet, fmt.Sprintf("J%d", row))
-		k, _ := getCellFloat(f, sheet, fmt.Sprintf("K%d", row))
-		m, _ := getCellFloat(f, sheet, fmt.Sprintf("M%d", row))
-		n, _ := getCellFloat(f, sheet, fmt.Sprintf("N%d", row))
-		s.salesCur += d
-		s.salesLast += e
-		s.salesCurCum += g
-		s.salesLastCum += h
-		s.retailCur += j
-		s.retailLast += k
-		s.retailCurCum += m
-		s.retailLastCum += n
-	}
-	return s, nil
-}
-
-func sumAccommodationCatering(f *excelize.File, sheet string) (wrSums, error) {
-	maxRow, err := findMaxDataRow(f, sheet, "C", 2)
-	if err != nil {
-		return wrSums{}, err
-	}
-	s := wrSums{maxRow: maxRow}
-	for row := 2; row <= maxRow; row++ {
-		d, _ := getCellFloat(f, sheet, fmt.Sprintf("D%d", row))
-		e, _ := getCellFloat(f, sheet, fmt.Sprintf("E%d", row))
-		g, _ := getCellFloat(f, sheet, fmt.Sprintf("G%d", row))
-		h, _ := getCellFloat(f, sheet, fmt.Sprintf("H%d", row))
-		v, _ := getCellFloat(f, sheet, fmt.Sprintf("V%d", row))
-		w, _ := getCellFloat(f, sheet, fmt.Sprintf("W%d", row))
-		x, _ := getCellFloat(f, sheet, fmt.Sprintf("X%d", row))
-		y, _ := getCellFloat(f, sheet, fmt.Sprintf("Y%d", row))
-		s.salesCur += d
-		s.salesLast += e
-		s.salesCurCum += g
-		s.salesLastCum += h
-		s.retailCur += v
-		s.retailLast += w
-		s.retailCurCum += x
-		s.retailLastCum += y
-	}
-	return s, nil
-}
-
 func rewriteTotalsWholesaleRetail(f *excelize.File, sheet string, sums wrSums) error {
 	sumRow := sums.maxRow + 1
 	growthRow := sums.maxRow + 2
@@ -730,10 +708,10 @@ func rewriteTotalsWholesaleRetail(f *excelize.File, sheet string, sums wrSums) e
 		return err
 	}
 
-	if err := setCellValue(f, sheet, fmt.Sprintf("E%d", growthRow), ratePercent(sums.salesCur, sums.salesLast)); err != nil {
+	if err := setCellValue(f, sheet, fmt.Sprintf("E%d", growthRow), sums.salesRate); err != nil {
 		return err
 	}
-	if err := setCellValue(f, sheet, fmt.Sprintf("H%d", growthRow), ratePercent(sums.salesCurCum, sums.salesLastCum)); err != nil {
+	if err := setCellValue(f, sheet, fmt.Sprintf("H%d", growthRow), sums.salesCumRate); err != nil {
 		return err
 	}
 
@@ -769,17 +747,17 @@ func rewriteTotalsAccommodationCatering(f *excelize.File, sheet string, sums wrS
 		return err
 	}
 
-	if err := setCellValue(f, sheet, fmt.Sprintf("E%d", growthRow), ratePercent(sums.salesCur, sums.salesLast)); err != nil {
+	if err := setCellValue(f, sheet, fmt.Sprintf("E%d", growthRow), sums.salesRate); err != nil {
 		return err
 	}
-	if err := setCellValue(f, sheet, fmt.Sprintf("H%d", growthRow), ratePercent(sums.salesCurCum, sums.salesLastCum)); err != nil {
+	if err := setCellValue(f, sheet, fmt.Sprintf("H%d", growthRow), sums.salesCumRate); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func rewriteOverallRetailAreaOnWholesale(f *excelize.File, wh, re, acc, cat wrSums) error {
+func rewriteOverallRetailAreaOnWholesale(f *excelize.File, wh, re, acc, cat, overall wrSums) error {
 	ws := "批发"
 	whMax := wh.maxRow
 	sumRow := whMax + 1
@@ -828,28 +806,23 @@ func rewriteOverallRetailAreaOnWholesale(f *excelize.File, wh, re, acc, cat wrSu
 		return err
 	}
 
-	overallCur := wh.retailCur + re.retailCur + acc.retailCur + cat.retailCur
-	overallLast := wh.retailLast + re.retailLast + acc.retailLast + cat.retailLast
-	overallCurCum := wh.retailCurCum + re.retailCurCum + acc.retailCurCum + cat.retailCurCum
-	overallLastCum := wh.retailLastCum + re.retailLastCum + acc.retailLastCum + cat.retailLastCum
-
-	if err := setCellValue(f, ws, fmt.Sprintf("J%d", totalRow), overallCur); err != nil {
+	if err := setCellValue(f, ws, fmt.Sprintf("J%d", totalRow), overall.retailCur); err != nil {
 		return err
 	}
-	if err := setCellValue(f, ws, fmt.Sprintf("K%d", totalRow), overallLast); err != nil {
+	if err := setCellValue(f, ws, fmt.Sprintf("K%d", totalRow), overall.retailLast); err != nil {
 		return err
 	}
-	if err := setCellValue(f, ws, fmt.Sprintf("M%d", totalRow), overallCurCum); err != nil {
+	if err := setCellValue(f, ws, fmt.Sprintf("M%d", totalRow), overall.retailCurCum); err != nil {
 		return err
 	}
-	if err := setCellValue(f, ws, fmt.Sprintf("N%d", totalRow), overallLastCum); err != nil {
+	if err := setCellValue(f, ws, fmt.Sprintf("N%d", totalRow), overall.retailLastCum); err != nil {
 		return err
 	}
 
-	if err := setCellValue(f, ws, fmt.Sprintf("K%d", totalGrowthRow), ratePercent(overallCur, overallLast)); err != nil {
+	if err := setCellValue(f, ws, fmt.Sprintf("K%d", totalGrowthRow), overall.retailRate); err != nil {
 		return err
 	}
-	if err := setCellValue(f, ws, fmt.Sprintf("N%d", totalGrowthRow), ratePercent(overallCurCum, overallLastCum)); err != nil {
+	if err := setCellValue(f, ws, fmt.Sprintf("N%d", totalGrowthRow), overall.retailCumRate); err != nil {
 		return err
 	}
 
@@ -237,84 +237,40 @@ func fillEatWearUseExcludedSheetByRowOrder(f *excelize.File, sheet string, recor
 	return clearSheetArea(f, sheet, 2, maxRow, 1, maxCol)
 }
 
-func fillSocialRetailSheetAndMaterialize(
-	f *excelize.File,
-	st *store.Store,
-	year int,
-	month int,
-	indicators indicatorIndex,
-) error {
+// fillSocialRetailSheetAndMaterialize 写入 社零额（定） 的输入单元格（与指标计算共用 calculator.SocialRetailInputs），公式单元格保留
+func fillSocialRetailSheetAndMaterialize(f *excelize.File, in calculator.SocialRetailInputs) error {
 	sheet := "社零额（定）"
 
-	prevYear, prevMonth := prevYearMonth(year, month)
-	prevIndicators, err := calculateIndicatorIndex(st, prevYear, prevMonth)
-	if err != nil {
-		prevIndicators = indicatorIndex{}
-	}
-
-	getFloat := func(key string) float64 {
-		v, err := st.GetConfigFloat(key)
-		if err != nil {
-			return 0
+	for _, c := range []struct {
+		cell  string
+		value interface{}
+	}{
+		{"J2", in.Month},
+		{"B4", in.MicroSmallRate},
+		{"C4", in.EatWearUseRate},
+		{"D4", in.SampleRate},
+		{"B6", in.PrevMicroSmallRate},
+		{"C6", in.PrevEatWearUseRate},
+		{"D6", in.PrevSampleRate},
+		{"B12", in.WeightSmallMicro},
+		{"C12", in.WeightEatWearUse},
+		{"D12", in.WeightSample},
+		{"I3", in.ProvinceRateChange},
+		{"K4", in.PrevLimitAbove},
+		{"K6", in.PrevLimitAboveLastYear},
+		{"K13", in.LimitAboveLastYear},
+		{"K16", in.LimitAbove},
+		{"E18", in.E18},
+		{"E19", in.E19},
+		{"E20", in.E20},
+		{"E21", in.E21},
+		{"E22", in.E22},
+		{"E23", in.E23},
+	} {
+		if err := setCellValueIfNoFormula(f, sheet, c.cell, c.value); err != nil {
+			return err
 		}
-		return v
-	}
-
-	if err := setCellValueIfNoFormula(f, sheet, "J2", month); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "B4", indicators["microSmall_month_rate"].Value); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "C4", indicators["eatWearUse_month_rate"].Value); err != nil {
-		return err
 	}
-	if err := setCellValueIfNoFormula(f, sheet, "D4", getFloat("sample_rate_month")); err != nil {
-		return err
-	}
-
-	if err := setCellValueIfNoFormula(f, sheet, "B6", prevIndicators["microSmall_month_rate"].Value); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "C6", prevIndicators["eatWearUse_month_rate"].Value); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "D6", getFloat("sample_rate_prev")); err != nil {
-		return err
-	}
-
-	if err := setCellValueIfNoFormula(f, sheet, "B12", getFloat("weight_small_micro")); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "C12", getFloat("weight_eat_wear_use")); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "D12", getFloat("weight_sample")); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "I3", getFloat("province_limit_below_rate_change")); err != nil {
-		return err
-	}
-
-	if err := setCellValueIfNoFormula(f, sheet, "E18", getFloat("history_social_e18")); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "E19", getFloat("history_social_e19")); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "E20", getFloat("history_social_e20")); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "E21", getFloat("history_social_e21")); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "E22", getFloat("history_social_e22")); err != nil {
-		return err
-	}
-	if err := setCellValueIfNoFormula(f, sheet, "E23", getFloat("history_social_e23")); err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -322,10 +278,7 @@ func rewriteFixedSummarySheet(
 	f *excelize.File,
 	year int,
 	month int,
-	wh wrSums,
-	re wrSums,
-	acc wrSums,
-	cat wrSums,
+	overall wrSums,
 	indicators indicatorIndex,
 	wrRecords []*model.WholesaleRetail,
 	acRecords []*model.AccommodationCatering,
@@ -351,16 +304,14 @@ func rewriteFixedSummarySheet(
 		reportRate = math.Round(float64(reportedCompanies) / float64(totalCompanies) * 100.0)
 	}
 
-	overallRetailCur := wh.retailCur + re.retailCur + acc.retailCur + cat.retailCur
-	overallRetailLast := wh.retailLast + re.retailLast + acc.retailLast + cat.retailLast
-	overallRetailCurCum := wh.retailCurCum + re.retailCurCum + acc.retailCurCum + cat.retailCurCum
-	overallRetailLastCum := wh.retailLastCum + re.retailLastCum + acc.retailLastCum + cat.retailLastCum
+	// 限上社零额与各行业增速均取自指标定义（与页面指标一致）
+	rate := func(id string) float64 { return indicators[id].Value }
 
 	// 汇总表（定）口径为“万元”，行业表/总表口径为“千元”
-	limitAboveMonthWan := math.Round(overallRetailCur / 10.0)
-	limitAboveLastYearMonthWan := math.Round(overallRetailLast / 10.0)
-	limitAboveCumulativeWan := math.Round(overallRetailCurCum / 10.0)
-	limitAboveLastYearCumulativeWan := math.Round(overallRetailLastCum / 10.0)
+	limitAboveMonthWan := math.Round(overall.retailCur / 10.0)
+	limitAboveLastYearMonthWan := math.Round(overall.retailLast / 10.0)
+	limitAboveCumulativeWan := math.Round(overall.retailCurCum / 10.0)
+	limitAboveLastYearCumulativeWan := math.Round(overall.retailLastCum / 10.0)
 
 	if err := setCellValueIfNoFormula(f, summary, "B4", totalCompanies); err != nil {
 		return err
@@ -385,35 +336,35 @@ func rewriteFixedSummarySheet(
 		return err
 	}
 
-	if err := setCellValueIfNoFormula(f, summary, "K4", ratePercent(wh.salesCur, wh.salesLast)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "K4", rate("wholesale_month_rate")); err != nil {
 		return err
 	}
-	if err := setCellValueIfNoFormula(f, summary, "L4", ratePercent(wh.salesCurCum, wh.salesLastCum)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "L4", rate("wholesale_cumulative_rate")); err != nil {
 		return err
 	}
-	if err := setCellValueIfNoFormula(f, summary, "M4", ratePercent(re.salesCur, re.salesLast)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "M4", rate("retail_month_rate")); err != nil {
 		return err
 	}
-	if err := setCellValueIfNoFormula(f, summary, "N4", ratePercent(re.salesCurCum, re.salesLastCum)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "N4", rate("retail_cumulative_rate")); err != nil {
 		return err
 	}
-	if err := setCellValueIfNoFormula(f, summary, "O4", ratePercent(acc.salesCur, acc.salesLast)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "O4", rate("accommodation_month_rate")); err != nil {
 		return err
 	}
-	if err := setCellValueIfNoFormula(f, summary, "P4", ratePercent(acc.salesCurCum, acc.salesLastCum)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "P4", rate("accommodation_cumulative_rate")); err != nil {
 		return err
 	}
-	if err := setCellValueIfNoFormula(f, summary, "Q4", ratePercent(cat.salesCur, cat.salesLast)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "Q4", rate("catering_month_rate")); err != nil {
 		return err
 	}
-	if err := setCellValueIfNoFormula(f, summary, "R4", ratePercent(cat.salesCurCum, cat.salesLastCum)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "R4", rate("catering_cumulative_rate")); err != nil {
 		return err
 	}
 
-	if err := setCellValueIfNoFormula(f, summary, "S4", ratePercent(overallRetailCur, overallRetailLast)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "S4", rate("limitAbove_month_rate")); err != nil {
 		return err
 	}
-	if err := setCellValueIfNoFormula(f, summary, "T4", ratePercent(overallRetailCurCum, overallRetailLastCum)); err != nil {
+	if err := setCellValueIfNoFormula(f, summary, "T4", rate("limitAbove_cumulative_rate")); err != nil {
 		return err
 	}
 
@@ -448,14 +399,14 @@ func rewriteFixedSummarySheet(
 		totalCompanies,
 		statusText,
 		month,
-		int(ratePercent(wh.salesCur, wh.salesLast)),
-		int(ratePercent(re.salesCur, re.salesLast)),
-		int(ratePercent(acc.salesCur, acc.salesLast)),
-		int(ratePercent(cat.salesCur, cat.salesLast)),
+		int(rate("wholesale_month_rate")),
+		int(rate("retail_month_rate")),
+		int(rate("accommodation_month_rate")),
+		int(rate("catering_month_rate")),
 		monthRetailYi,
-		int(ratePercent(overallRetailCur, overallRetailLast)),
+		int(rate("limitAbove_month_rate")),
 		cumRetailYi,
-		int(ratePercent(overallRetailCurCum, overallRetailLastCum)),
+		int(rate("limitAbove_cumulative_rate")),
 		period,
 		totalSocialYiText,
 		int(totalSocialRate),
@@ -470,13 +421,6 @@ func rewriteFixedSummarySheet(
 	return nil
 }
 
-func prevYearMonth(year, month int) (int, int) {
-	if month <= 1 {
-		return year - 1, 12
-	}
-	return year, month - 1
-}
-
 func getSheetMaxColRow(f *excelize.File, sheet string) (int, int, error) {
 	dim, err := f.GetSheetDimension(sheet)
 	if err != nil {
@@ -1,10 +1,15 @@
 package exporter
 
 import (
+	"math"
 	"path/filepath"
+	"strconv"
 	"testing"
 
+	"github.com/xuri/excelize/v2"
+
 	"northstar/internal/calculator"
+	"northstar/internal/model"
 	"northstar/internal/store"
 )
 
@@ -34,10 +39,10 @@ func TestExport_PreserveTemplateFormulas(t *testing.T) {
 		"totalSocial_cumulative_value": calculator.Indicator{ID: "totalSocial_cumulative_value", Value: 0},
 		"totalSocial_cumulative_rate":  calculator.Indicator{ID: "totalSocial_cumulative_rate", Value: 0},
 	}
-	if err := fillSocialRetailSheetAndMaterialize(f, st, 2025, 12, idx); err != nil {
+	if err := fillSocialRetailSheetAndMaterialize(f, calculator.SocialRetailInputs{Month: 12}); err != nil {
 		t.Fatalf("fill social retail: %v", err)
 	}
-	if err := rewriteFixedSummarySheet(f, 2025, 12, wrSums{}, wrSums{}, wrSums{}, wrSums{}, idx, nil, nil); err != nil {
+	if err := rewriteFixedSummarySheet(f, 2025, 12, wrSums{}, idx, nil, nil); err != nil {
 		t.Fatalf("rewrite summary: %v", err)
 	}
 
@@ -62,3 +67,70 @@ func TestExport_PreserveTemplateFormulas(t *testing.T) {
 		}
 	}
 }
+
+func TestExport_SocialRetailSheetMatchesCalculator(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	// 11、12 月各两家批零企业（一家小微、一家吃穿用）
+	for _, month := range []int{11, 12} {
+		k := float64(month)
+		if err := st.BatchInsertWR([]*model.WholesaleRetail{
+			{CreditCode: "W1", Name: "小微", IndustryType: "retail", DataYear: 2025, DataMonth: month, IsSmallMicro: 1,
+				RetailCurrentMonth: 2000 * k, RetailLastYearMonth: 1900 * k},
+			{CreditCode: "W2", Name: "吃穿用", IndustryType: "wholesale", DataYear: 2025, DataMonth: month, IsEatWearUse: 1,
+				RetailCurrentMonth: 310000 + k, RetailLastYearMonth: 320000},
+		}); err != nil {
+			t.Fatalf("insert wr: %v", err)
+		}
+	}
+	for key, v := range map[string]float64{
+		"sample_rate_month": -2, "sample_rate_prev": 3.5, "province_limit_below_rate_change": 0.2,
+		"weight_small_micro": 0.3, "weight_eat_wear_use": 0.3, "weight_sample": 0.4,
+		"history_social_e18": 1515156, "history_social_e19": 1445208, "history_social_e20": 1597656,
+		"history_social_e21": 1396358, "history_social_e22": 1328076, "history_social_e23": 1445208,
+	} {
+		if err := st.SetConfigFloat(key, v); err != nil {
+			t.Fatalf("set config: %v", err)
+		}
+	}
+
+	totals, err := calculator.NewCalculator(st).Totals(2025, 12)
+	if err != nil {
+		t.Fatalf("totals: %v", err)
+	}
+	want := totals.SocialRetail()
+
+	f, err := openEmbeddedMonthReportTemplate()
+	if err != nil {
+		t.Fatalf("open template: %v", err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	if err := fillSocialRetailSheetAndMaterialize(f, totals.SocialRetailInputs()); err != nil {
+		t.Fatalf("fill social retail: %v", err)
+	}
+
+	// 导出后 Excel 按模板公式重算的结果须与接口返回的计算过程一致
+	for _, c := range []struct {
+		cell string
+		want float64
+	}{
+		{"K3", want.K3}, {"K7", want.K7}, {"K9", want.K9}, {"K15", want.K15},
+		{"K17", want.K17}, {"K19", want.K19}, {"K21", want.K21}, {"K23", want.K23},
+	} {
+		raw, err := f.CalcCellValue("社零额（定）", c.cell, excelize.Options{RawCellValue: true})
+		if err != nil {
+			t.Fatalf("calc %s: %v", c.cell, err)
+		}
+		got, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			t.Fatalf("parse %s=%q: %v", c.cell, raw, err)
+		}
+		if math.Abs(got-c.want) > 1e-6*math.Max(1, math.Abs(c.want)) {
+			t.Errorf("%s: excel=%v, calculator=%v", c.cell, got, c.want)
+		}
+	}
+}
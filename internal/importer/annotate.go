@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/xuri/excelize/v2"
+	"northstar/internal/model"
+	"northstar/internal/parser"
+)
+
+// IssueSheetName 标注工作簿中问题清单 Sheet 的名称
+const IssueSheetName = "导入问题"
+
+// WriteAnnotatedWorkbook 生成带问题标注的工作簿：原始文件存在时在其副本上将问题单元格标红并添加批注，
+// 否则仅输出问题清单；两种情况都会追加“导入问题”清单 Sheet
+func WriteAnnotatedWorkbook(srcPath string, issues []model.RowIssue, w io.Writer) error {
+	f, annotate := openSourceWorkbook(srcPath)
+	defer f.Close()
+
+	if annotate {
+		if err := annotateCells(f, issues); err != nil {
+			return err
+		}
+	}
+	if err := writeIssueSheet(f, issues, !annotate); err != nil {
+		return err
+	}
+	if err := f.Write(w); err != nil {
+		return fmt.Errorf("failed to write annotated workbook: %w", err)
+	}
+	return nil
+}
+
+// openSourceWorkbook 打开原始工作簿；不存在或无法打开时返回新建的空工作簿
+func openSourceWorkbook(path string) (*excelize.File, bool) {
+	if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			if f, err := parser.OpenWorkbook(path); err == nil {
+				return f, true
+			}
+		}
+	}
+	return excelize.NewFile(), false
+}
+
+// annotateCells 问题单元格标红并添加批注（整行问题标注在 A 列）
+func annotateCells(f *excelize.File, issues []model.RowIssue) error {
+	style, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create issue style: %w", err)
+	}
+
+	// 同一单元格的多个问题合并为一条批注
+	type cellKey struct{ sheet, cell string }
+	var order []cellKey
+	notes := map[cellKey]string{}
+	for _, issue := range issues {
+		if idx, _ := f.GetSheetIndex(issue.Sheet); idx < 0 {
+			continue
+		}
+		col := issue.Column
+		if col == "" {
+			col = "A"
+		}
+		key := cellKey{issue.Sheet, fmt.Sprintf("%s%d", col, issue.Row)}
+		if _, ok := notes[key]; !ok {
+			order = append(order, key)
+		} else {
+			notes[key] += "\n"
+		}
+		notes[key] += issue.Problem
+	}
+
+	for _, key := range order {
+		if err := f.SetCellStyle(key.sheet, key.cell, key.cell, style); err != nil {
+			return fmt.Errorf("failed to style %s!%s: %w", key.sheet, key.cell, err)
+		}
+		if err := f.AddComment(key.sheet, excelize.Comment{
+			Cell:   key.cell,
+			Author: "northstar",
+			Text:   notes[key],
+		}); err != nil {
+			return fmt.Errorf("failed to comment %s!%s: %w", key.sheet, key.cell, err)
+		}
+	}
+	return nil
+}
+
+// writeIssueSheet 写入问题清单 Sheet；replaceDefault 为 true 时复用新工作簿的默认 Sheet
+func writeIssueSheet(f *excelize.File, issues []model.RowIssue, replaceDefault bool) error {
+	if replaceDefault {
+		if err := f.SetSheetName(f.GetSheetName(0), IssueSheetName); err != nil {
+			return fmt.Errorf("failed to rename issue sheet: %w", err)
+		}
+	} else {
+		if idx, _ := f.GetSheetIndex(IssueSheetName); idx >= 0 {
+			if err := f.DeleteSheet(IssueSheetName); err != nil {
+				return fmt.Errorf("failed to replace issue sheet: %w", err)
+			}
+		}
+		if _, err := f.NewSheet(IssueSheetName); err != nil {
+			return fmt.Errorf("failed to create issue sheet: %w", err)
+		}
+	}
+
+	header := []interface{}{"Sheet", "行号", "列", "列名", "原始值", "问题类型", "问题说明"}
+	if err := f.SetSheetRow(IssueSheetName, "A1", &header); err != nil {
+		return fmt.Errorf("failed to write issue header: %w", err)
+	}
+	for i, issue := range issues {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		row := []interface{}{issue.Sheet, issue.Row, issue.Column, issue.Header, issue.Value, issue.Code, issue.Problem}
+		if err := f.SetSheetRow(IssueSheetName, cell, &row); err != nil {
+			return fmt.Errorf("failed to write issue row: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+func TestImport_RecordsRowIssuesAndAnnotatesWorkbook(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.New(filepath.Join(dir, "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	input := filepath.Join(dir, "input.xlsx")
+	writeWorkbook(t, input, []string{"批发"}, map[string][][]interface{}{
+		"批发": {
+			{"统一社会信用代码", "单位详细名称", "行业代码", "单位规模", "2025年12月销售额", "2025年12月零售额"},
+			{"91A", "甲公司", "5111", 3, "一百", 50},
+			{"", "乙公司", "9999", 3, 100, 50},
+			{"91A", "甲公司分部", "5111", 3, 100, 50},
+		},
+	})
+
+	var importID int64
+	for evt := range NewCoordinator(st).Import(ImportOptions{
+		FilePath:        input,
+		ClearExisting:   true,
+		CalculateFields: true,
+		OnStart:         func(id int64) { importID = id },
+	}) {
+		if evt.Type == "error" {
+			t.Fatalf("import error: %s", evt.Message)
+		}
+	}
+
+	issues, total, err := st.ListImportIssues(importID, 0, 0)
+	if err != nil {
+		t.Fatalf("ListImportIssues: %v", err)
+	}
+	want := []struct {
+		row    int
+		column string
+		code   string
+	}{
+		{2, "E", model.IssueInvalidNumber},
+		{3, "A", model.IssueMissingCreditCode},
+		{3, "C", model.IssueUnknownIndustryCode},
+		{4, "A", model.IssueDuplicateCompany},
+	}
+	if total != len(want) {
+		t.Fatalf("got %d issues, want %d: %+v", total, len(want), issues)
+	}
+	for i, w := range want {
+		got := issues[i]
+		if got.Sheet != "批发" || got.Row != w.row || got.Column != w.column || got.Code != w.code {
+			t.Fatalf("issue %d = %+v, want row=%d col=%s code=%s", i, got, w.row, w.column, w.code)
+		}
+	}
+	if issues[0].Header != "2025年12月销售额" || issues[0].Value != "一百" {
+		t.Fatalf("invalid number issue should carry header and raw value: %+v", issues[0])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAnnotatedWorkbook(input, issues, &buf); err != nil {
+		t.Fatalf("WriteAnnotatedWorkbook: %v", err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	comments, err := f.GetComments("批发")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != len(want) {
+		t.Fatalf("got %d comments, want %d", len(comments), len(want))
+	}
+	rows, err := f.GetRows(IssueSheetName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(want)+1 {
+		t.Fatalf("issue sheet has %d rows, want %d", len(rows), len(want)+1)
+	}
+}
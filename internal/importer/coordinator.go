@@ -1,8 +1,11 @@
 package importer
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +15,7 @@ import (
 	"github.com/xuri/excelize/v2"
 	"northstar/internal/model"
 	"northstar/internal/parser"
+	"northstar/internal/rules"
 	"northstar/internal/store"
 )
 
@@ -36,8 +40,14 @@ type ImportOptions struct {
 	ClearExisting   bool // 是否清空现有数据
 	UpdateConfigYM  bool // 是否更新配置中的当前年月
 	CalculateFields bool // 是否计算衍生字段
+	Overrides       *Overrides // 人工确认的 Sheet 类型/年月/列映射（可选）
+	// OnStart 导入日志创建后回调，参数为 import_logs.id（用于登记可取消的导入）
+	OnStart func(importID int64)
 }
 
+// ErrImportCanceled 导入被取消（请求断开或主动取消）
+var ErrImportCanceled = errors.New("导入已取消")
+
 // ProgressEvent 进度事件
 type ProgressEvent struct {
 	Type      string      `json:"type"`      // start/sheet_start/sheet_done/done/error
@@ -61,22 +71,33 @@ type ImportContext struct {
 	clearedACSYM   map[string]bool
 	importLogID    *int64
 	currentMeta    *sheetMetaDraft
+	sheetMetas     []model.SheetMeta // 待写入的 Sheet 元信息（导入结束后统一写入）
+	failures       []string          // 导入失败的 Sheet（非空时整体回滚）
+	issues         []model.RowIssue  // 行级问题（导入结束后统一写入）
+	aliases        []model.ColumnAlias
+	learned        []model.SheetProfile // 人工确认的 Sheet 类型（导入成功时记为识别档案）
+	profileHits    []int64              // 命中的识别档案
 }
 
 // Import 执行导入，返回进度通道
 func (c *Coordinator) Import(opts ImportOptions) <-chan ProgressEvent {
+	return c.ImportWithContext(context.Background(), opts)
+}
+
+// ImportWithContext 执行导入，runCtx 取消时在 Sheet 之间停止并整体回滚
+func (c *Coordinator) ImportWithContext(runCtx context.Context, opts ImportOptions) <-chan ProgressEvent {
 	progressChan := make(chan ProgressEvent, 100)
 
 	go func() {
 		defer close(progressChan)
-		c.doImport(opts, progressChan)
+		c.doImport(runCtx, opts, progressChan)
 	}()
 
 	return progressChan
 }
 
 // doImport 执行导入逻辑
-func (c *Coordinator) doImport(opts ImportOptions, progressChan chan ProgressEvent) {
+func (c *Coordinator) doImport(runCtx context.Context, opts ImportOptions, progressChan chan ProgressEvent) {
 	startTime := time.Now()
 
 	originalName := opts.OriginalFilename
@@ -84,30 +105,42 @@ func (c *Coordinator) doImport(opts ImportOptions, progressChan chan ProgressEve
 		originalName = filepath.Base(opts.FilePath)
 	}
 
+	var importLogID *int64
+	fileSize, fileHash := statAndHashFile(opts.FilePath)
+	id, logErr := c.store.CreateImportLog(originalName, opts.FilePath, fileSize, fileHash)
+	if logErr == nil {
+		importLogID = &id
+		// 记录人工确认的解析参数，重新导入时沿用
+		if opts.Overrides != nil {
+			if data, err := json.Marshal(opts.Overrides); err == nil {
+				_ = c.store.SetImportLogOverrides(id, string(data))
+			}
+		}
+		if opts.OnStart != nil {
+			opts.OnStart(id)
+		}
+	}
+
 	// 发送开始事件
 	c.sendProgress(progressChan, ProgressEvent{
 		Type:    "start",
 		Message: "开始导入 Excel 文件",
-		Data: map[string]string{
+		Data: map[string]interface{}{
 			"filename": originalName,
+			"importId": id,
 		},
 		Timestamp: time.Now(),
 	})
-
-	var importLogID *int64
-	fileSize, fileHash := statAndHashFile(opts.FilePath)
-	if id, err := c.store.CreateImportLog(originalName, opts.FilePath, fileSize, fileHash); err == nil {
-		importLogID = &id
-	} else {
+	if logErr != nil {
 		c.sendProgress(progressChan, ProgressEvent{
 			Type:      "warning",
-			Message:   fmt.Sprintf("创建导入日志失败: %v", err),
+			Message:   fmt.Sprintf("创建导入日志失败: %v", logErr),
 			Timestamp: time.Now(),
 		})
 	}
 
 	// 打开 Excel 文件
-	file, err := excelize.OpenFile(opts.FilePath)
+	file, err := parser.OpenWorkbook(opts.FilePath)
 	if err != nil {
 		if importLogID != nil {
 			_ = c.store.UpdateImportLog(*importLogID, 0, 0, 0, 0, 0, 0, "failed", fmt.Sprintf("打开文件失败: %v", err))
@@ -138,6 +171,26 @@ func (c *Coordinator) doImport(opts ImportOptions, progressChan chan ProgressEve
 		},
 	}
 
+	// 学习到的识别档案（读取失败时仅使用内置识别规则）
+	if err := c.loadProfiles(); err != nil {
+		c.sendProgress(progressChan, ProgressEvent{
+			Type:      "warning",
+			Message:   fmt.Sprintf("读取识别档案失败，仅使用内置识别规则: %v", err),
+			Timestamp: time.Now(),
+		})
+	}
+
+	// 用户列别名（读取失败时仅使用内置映射规则）
+	if aliases, err := c.store.ListColumnAliases(); err == nil {
+		ctx.aliases = aliases
+	} else {
+		c.sendProgress(progressChan, ProgressEvent{
+			Type:      "warning",
+			Message:   fmt.Sprintf("读取列别名失败，仅使用内置映射规则: %v", err),
+			Timestamp: time.Now(),
+		})
+	}
+
 	// 获取所有 Sheet
 	sheetList := file.GetSheetList()
 	ctx.Report.TotalSheets = len(sheetList)
@@ -151,30 +204,65 @@ func (c *Coordinator) doImport(opts ImportOptions, progressChan chan ProgressEve
 		Timestamp: time.Now(),
 	})
 
-	// 遍历所有 Sheet
-	for _, sheetName := range sheetList {
-		c.processSheet(ctx, sheetName, opts)
-	}
+	// 整个工作簿在同一事务内写入：任一 Sheet 失败则整体回滚，原有数据保持不变
+	err = c.store.Atomic(func(tx *store.Store) error {
+		work := &Coordinator{store: tx, recognizer: c.recognizer}
 
-	// 计算衍生字段
-	if opts.CalculateFields && ctx.CurrentYear > 0 && ctx.CurrentMonth > 0 {
-		c.calculateDerivedFields(ctx)
-	}
+		// 遍历所有 Sheet
+		for _, sheetName := range sheetList {
+			if runCtx.Err() != nil {
+				return ErrImportCanceled
+			}
+			work.processSheet(ctx, sheetName, opts)
+		}
+		if len(ctx.failures) > 0 {
+			return fmt.Errorf("%d 个 Sheet 导入失败: %s", len(ctx.failures), joinErrors(ctx.failures))
+		}
+		work.saveProfiles(ctx)
 
-	// 更新配置中的当前年月
-	if opts.UpdateConfigYM && ctx.CurrentYear > 0 && ctx.CurrentMonth > 0 {
-		c.updateCurrentYearMonth(ctx)
-	}
+		// 计算衍生字段
+		if opts.CalculateFields && ctx.CurrentYear > 0 && ctx.CurrentMonth > 0 {
+			work.calculateDerivedFields(ctx)
+		}
+
+		// 业务规则校验（仅提示，不阻断导入）
+		if ctx.CurrentYear > 0 && ctx.CurrentMonth > 0 {
+			work.reportValidation(ctx)
+			work.recordHistory(ctx, originalName)
+		}
+
+		// 更新配置中的当前年月
+		if opts.UpdateConfigYM && ctx.CurrentYear > 0 && ctx.CurrentMonth > 0 {
+			work.updateCurrentYearMonth(ctx)
+		}
+
+		// 提交前最后一次检查：取消后不再写入
+		if runCtx.Err() != nil {
+			return ErrImportCanceled
+		}
+		return nil
+	})
 
 	// 汇总统计
 	ctx.Report.Duration = time.Since(startTime)
+	if err != nil {
+		ctx.Report.RolledBack = true
+		ctx.Report.RollbackReason = err.Error()
+	}
+
+	// Sheet 元信息与行级问题在事务外写入，回滚的导入也保留诊断信息
+	c.flushSheetMetas(ctx)
+	c.flushIssues(ctx)
 
 	if ctx.importLogID != nil {
 		status := "completed"
 		errMsg := ""
-		if ctx.Report.ErrorRows > 0 {
-			// 有错误行也视为完成，但保留错误信息
-			status = "completed"
+		if ctx.Report.RolledBack {
+			status = "rolled_back"
+			if errors.Is(err, ErrImportCanceled) {
+				status = "cancelled"
+			}
+			errMsg = ctx.Report.RollbackReason
 		}
 		_ = c.store.UpdateImportLog(
 			*ctx.importLogID,
@@ -187,6 +275,19 @@ func (c *Coordinator) doImport(opts ImportOptions, progressChan chan ProgressEve
 			status,
 			errMsg,
 		)
+		if !ctx.Report.RolledBack && ctx.CurrentYear > 0 && ctx.CurrentMonth > 0 {
+			_ = c.store.SetImportLogYearMonth(*ctx.importLogID, ctx.CurrentYear, ctx.CurrentMonth)
+		}
+	}
+
+	if ctx.Report.RolledBack {
+		c.sendProgress(progressChan, ProgressEvent{
+			Type:      "error",
+			Message:   fmt.Sprintf("导入未完成，已回滚，原有数据未改变: %s", ctx.Report.RollbackReason),
+			Data:      ctx.Report,
+			Timestamp: time.Now(),
+		})
+		return
 	}
 
 	// 发送完成事件
@@ -211,8 +312,8 @@ func (c *Coordinator) processSheet(ctx *ImportContext, sheetName string, opts Im
 		Timestamp: time.Now(),
 	})
 
-	// 读取表头
-	rows, err := ctx.File.GetRows(sheetName)
+	// 读取表头（识别标题行与多行合并表头）
+	rows, band, err := parser.ReadSheet(ctx.File, sheetName)
 	if err != nil || len(rows) < 1 {
 		c.recordSheetResult(ctx, parser.ParseResult{
 			SheetName: sheetName,
@@ -224,10 +325,26 @@ func (c *Coordinator) processSheet(ctx *ImportContext, sheetName string, opts Im
 		return
 	}
 
-	headers := rows[0]
+	headers := band.Headers
 
 	// 识别 Sheet 类型
 	recognition := c.recognizer.Recognize(sheetName, headers)
+	if t, ok := opts.Overrides.sheetType(sheetName); ok {
+		recognition.SheetType = t
+		recognition.Confidence = 1
+		recognition.ProfileID = 0
+		if sig := parser.HeaderSignature(headers); sig != "" {
+			ctx.learned = append(ctx.learned, model.SheetProfile{
+				Signature: sig,
+				SheetType: string(t),
+				SheetName: sheetName,
+				Headers:   headers,
+			})
+		}
+	}
+	if recognition.ProfileID != 0 {
+		ctx.profileHits = append(ctx.profileHits, recognition.ProfileID)
+	}
 
 	c.sendProgress(ctx.ProgressChan, ProgressEvent{
 		Type:    "info",
@@ -245,9 +362,10 @@ func (c *Coordinator) processSheet(ctx *ImportContext, sheetName string, opts Im
 		SheetName:    sheetName,
 		SheetType:    recognition.SheetType,
 		Confidence:   recognition.Confidence,
-		TotalRows:    maxInt(0, len(rows)-1),
+		TotalRows:    maxInt(0, len(rows)-band.DataStart),
 		TotalColumns: len(headers),
 		Headers:      headers,
+		HeaderBand:   band,
 	}
 	switch recognition.SheetType {
 	case parser.SheetTypeWholesale, parser.SheetTypeRetail:
@@ -301,7 +419,13 @@ func (c *Coordinator) processWholesaleRetail(ctx *ImportContext, sheetName strin
 
 	// 解析 Sheet
 	wrParser := parser.NewWRParser(ctx.File)
+	wrParser.SetOverrides(c.parseOverrides(ctx, sheetName, opts))
+	wrParser.SetAliases(ctx.aliases)
 	records, err := wrParser.ParseSheet(sheetName)
+	c.collectIssues(ctx, sheetName, wrParser.Issues())
+	if ctx.currentMeta != nil {
+		ctx.currentMeta.Mappings = wrParser.Mappings()
+	}
 	if err != nil {
 		c.recordSheetResult(ctx, parser.ParseResult{
 			SheetName: sheetName,
@@ -342,11 +466,15 @@ func (c *Coordinator) processWholesaleRetail(ctx *ImportContext, sheetName strin
 		if !ctx.clearedWRYM[key] {
 			ctx.clearedWRYM[key] = true
 			if err := c.store.DeleteWRByYearMonth(year, month); err != nil {
-				c.sendProgress(ctx.ProgressChan, ProgressEvent{
-					Type:      "warning",
-					Message:   fmt.Sprintf("清空批零旧数据失败: %v", err),
-					Timestamp: time.Now(),
+				c.recordSheetResult(ctx, parser.ParseResult{
+					SheetName: sheetName,
+					SheetType: parser.SheetTypeWholesale,
+					Status:    "error",
+					ErrorRows: len(records),
+					Errors:    []string{fmt.Sprintf("清空批零旧数据失败: %v", err)},
+					Duration:  time.Since(sheetStartTime),
 				})
+				return
 			}
 		}
 	}
@@ -381,7 +509,13 @@ func (c *Coordinator) processAccommodationCatering(ctx *ImportContext, sheetName
 
 	// 解析 Sheet
 	acParser := parser.NewACParser(ctx.File)
+	acParser.SetOverrides(c.parseOverrides(ctx, sheetName, opts))
+	acParser.SetAliases(ctx.aliases)
 	records, err := acParser.ParseSheet(sheetName)
+	c.collectIssues(ctx, sheetName, acParser.Issues())
+	if ctx.currentMeta != nil {
+		ctx.currentMeta.Mappings = acParser.Mappings()
+	}
 	if err != nil {
 		c.recordSheetResult(ctx, parser.ParseResult{
 			SheetName: sheetName,
@@ -422,11 +556,15 @@ func (c *Coordinator) processAccommodationCatering(ctx *ImportContext, sheetName
 		if !ctx.clearedACYM[key] {
 			ctx.clearedACYM[key] = true
 			if err := c.store.DeleteACByYearMonth(year, month); err != nil {
-				c.sendProgress(ctx.ProgressChan, ProgressEvent{
-					Type:      "warning",
-					Message:   fmt.Sprintf("清空住餐旧数据失败: %v", err),
-					Timestamp: time.Now(),
+				c.recordSheetResult(ctx, parser.ParseResult{
+					SheetName: sheetName,
+					SheetType: parser.SheetTypeAccommodation,
+					Status:    "error",
+					ErrorRows: len(records),
+					Errors:    []string{fmt.Sprintf("清空住餐旧数据失败: %v", err)},
+					Duration:  time.Since(sheetStartTime),
 				})
+				return
 			}
 		}
 	}
@@ -479,11 +617,15 @@ func (c *Coordinator) processWRSnapshot(ctx *ImportContext, sheetName string, op
 		if !ctx.clearedWRSYM[key] {
 			ctx.clearedWRSYM[key] = true
 			if err := c.store.DeleteWRSnapshotByYearMonth(year, month); err != nil {
-				c.sendProgress(ctx.ProgressChan, ProgressEvent{
-					Type:      "warning",
-					Message:   fmt.Sprintf("清空批零快照旧数据失败: %v", err),
-					Timestamp: time.Now(),
+				c.recordSheetResult(ctx, parser.ParseResult{
+					SheetName: sheetName,
+					SheetType: parser.SheetTypeWRSnapshot,
+					Status:    "error",
+					ErrorRows: len(records),
+					Errors:    []string{fmt.Sprintf("清空批零快照旧数据失败: %v", err)},
+					Duration:  time.Since(sheetStartTime),
 				})
+				return
 			}
 		}
 	}
@@ -534,11 +676,15 @@ func (c *Coordinator) processACSnapshot(ctx *ImportContext, sheetName string, op
 		if !ctx.clearedACSYM[key] {
 			ctx.clearedACSYM[key] = true
 			if err := c.store.DeleteACSnapshotByYearMonth(year, month); err != nil {
-				c.sendProgress(ctx.ProgressChan, ProgressEvent{
-					Type:      "warning",
-					Message:   fmt.Sprintf("清空住餐快照旧数据失败: %v", err),
-					Timestamp: time.Now(),
+				c.recordSheetResult(ctx, parser.ParseResult{
+					SheetName: sheetName,
+					SheetType: parser.SheetTypeACSnapshot,
+					Status:    "error",
+					ErrorRows: len(records),
+					Errors:    []string{fmt.Sprintf("清空住餐快照旧数据失败: %v", err)},
+					Duration:  time.Since(sheetStartTime),
 				})
+				return
 			}
 		}
 	}
@@ -652,6 +798,42 @@ func (c *Coordinator) calculateDerivedFields(ctx *ImportContext) {
 	})
 }
 
+// reportValidation 对导入月份执行业务规则校验并推送汇总
+func (c *Coordinator) reportValidation(ctx *ImportContext) {
+	report, err := rules.NewEngine(c.store).Evaluate(ctx.CurrentYear, ctx.CurrentMonth)
+	if err != nil {
+		c.sendProgress(ctx.ProgressChan, ProgressEvent{
+			Type:      "warning",
+			Message:   fmt.Sprintf("业务规则校验失败: %v", err),
+			Timestamp: time.Now(),
+		})
+		return
+	}
+
+	evtType := "info"
+	if report.HasHard() {
+		evtType = "warning"
+	}
+	c.sendProgress(ctx.ProgressChan, ProgressEvent{
+		Type:      evtType,
+		Message:   fmt.Sprintf("业务规则校验: 硬性违规 %d 项，提示 %d 项", report.Summary.Hard, report.Summary.Soft),
+		Data:      report.Summary,
+		Timestamp: time.Now(),
+	})
+}
+
+// recordHistory 将导入写入的当月数据记录到修改历史（导入批次不可撤销）
+func (c *Coordinator) recordHistory(ctx *ImportContext, filename string) {
+	meta := store.ChangeMeta{Note: filename}
+	if _, err := c.store.RecordImportBatch(meta, ctx.CurrentYear, ctx.CurrentMonth); err != nil {
+		c.sendProgress(ctx.ProgressChan, ProgressEvent{
+			Type:      "warning",
+			Message:   fmt.Sprintf("记录修改历史失败: %v", err),
+			Timestamp: time.Now(),
+		})
+	}
+}
+
 // updateCurrentYearMonth 更新配置中的当前年月
 func (c *Coordinator) updateCurrentYearMonth(ctx *ImportContext) {
 	if err := c.store.SetCurrentYearMonth(ctx.CurrentYear, ctx.CurrentMonth); err != nil {
@@ -683,6 +865,9 @@ func (c *Coordinator) recordSheetResult(ctx *ImportContext, result parser.ParseR
 	if result.ErrorRows > 0 {
 		ctx.Report.ErrorRows += result.ErrorRows
 	}
+	if result.Status == "error" {
+		ctx.failures = append(ctx.failures, fmt.Sprintf("%s: %s", result.SheetName, joinErrors(result.Errors)))
+	}
 
 	ctx.Report.TotalRows += result.ImportedRows + result.ErrorRows
 
@@ -700,23 +885,36 @@ func (c *Coordinator) recordSheetResult(ctx *ImportContext, result parser.ParseR
 		Timestamp: time.Now(),
 	})
 
-	// 写入 sheets_meta（失败不影响导入流程）
+	// 记录 sheets_meta（导入结束后写入，失败不影响导入流程）
 	if ctx.currentMeta != nil {
 		meta := model.SheetMeta{
-			SheetName:    ctx.currentMeta.SheetName,
-			SheetType:    string(ctx.currentMeta.SheetType),
-			Confidence:   ctx.currentMeta.Confidence,
-			TotalRows:    ctx.currentMeta.TotalRows,
-			TotalColumns: ctx.currentMeta.TotalColumns,
-			ImportedRows: result.ImportedRows,
-			ColumnsJSON:  store.BuildColumnsJSON(ctx.currentMeta.Headers),
-			Status:       result.Status,
-			ErrorMessage: joinErrors(result.Errors),
-			SourceFile:   ctx.Report.Filename,
+			SheetName:         ctx.currentMeta.SheetName,
+			SheetType:         string(ctx.currentMeta.SheetType),
+			Confidence:        ctx.currentMeta.Confidence,
+			TotalRows:         ctx.currentMeta.TotalRows,
+			TotalColumns:      ctx.currentMeta.TotalColumns,
+			ImportedRows:      result.ImportedRows,
+			ColumnsJSON:       store.BuildColumnsJSON(ctx.currentMeta.Headers),
+			ColumnMappingJSON: buildMappingJSON(ctx.currentMeta.Mappings),
+			Status:            result.Status,
+			ErrorMessage:      joinErrors(result.Errors),
+			SourceFile:        ctx.Report.Filename,
 		}
+		meta.HeaderRowStart, meta.HeaderRowEnd = ctx.currentMeta.HeaderBand.ExcelRows()
 		if ctx.importLogID != nil {
 			meta.ImportLogID = ctx.importLogID
 		}
+		ctx.sheetMetas = append(ctx.sheetMetas, meta)
+	}
+}
+
+// flushSheetMetas 写入 sheets_meta；导入回滚时各 Sheet 状态标记为 rolled_back
+func (c *Coordinator) flushSheetMetas(ctx *ImportContext) {
+	for _, meta := range ctx.sheetMetas {
+		if ctx.Report.RolledBack && meta.Status == "imported" {
+			meta.Status = "rolled_back"
+			meta.ImportedRows = 0
+		}
 		if err := c.store.InsertSheetMeta(meta); err != nil {
 			c.sendProgress(ctx.ProgressChan, ProgressEvent{
 				Type:      "warning",
@@ -727,6 +925,73 @@ func (c *Coordinator) recordSheetResult(ctx *ImportContext, result parser.ParseR
 	}
 }
 
+// parseOverrides 单个主表的解析参数：Sheet 类型以协调器的识别结果为准（含人工指定与识别档案）
+func (c *Coordinator) parseOverrides(ctx *ImportContext, sheetName string, opts ImportOptions) parser.ParseOverrides {
+	o := opts.Overrides.forSheet(sheetName)
+	if o.SheetType == "" && ctx.currentMeta != nil {
+		o.SheetType = ctx.currentMeta.SheetType
+	}
+	return o
+}
+
+// loadProfiles 加载学习到的 Sheet 识别档案
+func (c *Coordinator) loadProfiles() error {
+	profiles, err := c.store.ListSheetProfiles()
+	if err != nil {
+		return err
+	}
+	c.recognizer.SetProfiles(profiles)
+	return nil
+}
+
+// saveProfiles 记录人工确认的 Sheet 类型为识别档案，并累计档案命中次数（随导入事务提交）
+func (c *Coordinator) saveProfiles(ctx *ImportContext) {
+	for _, p := range ctx.learned {
+		if err := c.store.SaveSheetProfile(p); err != nil {
+			c.sendProgress(ctx.ProgressChan, ProgressEvent{
+				Type:      "warning",
+				Message:   fmt.Sprintf("保存识别档案失败: %v", err),
+				Timestamp: time.Now(),
+			})
+		}
+	}
+	for _, id := range ctx.profileHits {
+		_ = c.store.IncrementSheetProfileHits(id)
+	}
+}
+
+// collectIssues 记录解析时发现的行级问题
+func (c *Coordinator) collectIssues(ctx *ImportContext, sheetName string, issues []model.RowIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	ctx.issues = append(ctx.issues, issues...)
+	ctx.Report.IssueCount += len(issues)
+	c.sendProgress(ctx.ProgressChan, ProgressEvent{
+		Type:    "warning",
+		Message: fmt.Sprintf("Sheet \"%s\" 发现 %d 个行级问题", sheetName, len(issues)),
+		Data: map[string]interface{}{
+			"sheetName":  sheetName,
+			"issueCount": len(issues),
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// flushIssues 写入行级问题（关联导入日志）
+func (c *Coordinator) flushIssues(ctx *ImportContext) {
+	if ctx.importLogID == nil || len(ctx.issues) == 0 {
+		return
+	}
+	if err := c.store.InsertImportIssues(*ctx.importLogID, ctx.issues); err != nil {
+		c.sendProgress(ctx.ProgressChan, ProgressEvent{
+			Type:      "warning",
+			Message:   fmt.Sprintf("写入行级问题失败: %v", err),
+			Timestamp: time.Now(),
+		})
+	}
+}
+
 func sheetStatusText(r parser.ParseResult) string {
 	switch r.Status {
 	case "imported":
@@ -759,6 +1024,20 @@ type sheetMetaDraft struct {
 	TotalRows    int
 	TotalColumns int
 	Headers      []string
+	HeaderBand   parser.HeaderBand
+	Mappings     []parser.FieldMapping
+}
+
+// buildMappingJSON 列映射序列化为 JSON（非主表无映射时为空）
+func buildMappingJSON(mappings []parser.FieldMapping) string {
+	if len(mappings) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(mappings)
+	if err != nil {
+		return ""
+	}
+	return string(b)
 }
 
 func statAndHashFile(path string) (int64, string) {
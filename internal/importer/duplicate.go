@@ -0,0 +1,50 @@
+package importer
+
+import (
+	"fmt"
+
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+// DuplicateUpload 与此前成功导入完全相同的上传文件（按 MD5 比对）
+type DuplicateUpload struct {
+	FileHash     string               `json:"fileHash"`
+	Previous     model.ImportLog      `json:"previous"`     // 最近一次相同文件的成功导入
+	Edits        []*store.ChangeBatch `json:"edits"`        // 该月最近一次导入之后的修改（重新导入将被覆盖）
+	EditedFields int                  `json:"editedFields"` // 上述修改涉及的字段数
+}
+
+// Message 面向用户的提示
+func (d *DuplicateUpload) Message() string {
+	msg := fmt.Sprintf("该文件与 %s 导入的“%s”完全相同", d.Previous.StartedAt.Local().Format("2006-01-02 15:04"), d.Previous.Filename)
+	if d.Previous.DataYear > 0 {
+		msg += fmt.Sprintf("（%d年%d月数据）", d.Previous.DataYear, d.Previous.DataMonth)
+	}
+	if len(d.Edits) > 0 {
+		msg += fmt.Sprintf("，此后已有 %d 次修改（%d 个字段），重新导入将覆盖这些修改", len(d.Edits), d.EditedFields)
+	}
+	return msg + "。确认后可继续导入"
+}
+
+// CheckDuplicate 检查文件是否与此前成功导入的文件相同；不同时返回 nil
+func (c *Coordinator) CheckDuplicate(path string) (*DuplicateUpload, error) {
+	_, hash := statAndHashFile(path)
+	prev, err := c.store.FindCompletedImportByHash(hash)
+	if err != nil || prev == nil {
+		return nil, err
+	}
+
+	dup := &DuplicateUpload{FileHash: hash, Previous: *prev, Edits: []*store.ChangeBatch{}}
+	if prev.DataYear > 0 && prev.DataMonth > 0 {
+		edits, err := c.store.ListEditsSinceImport(prev.DataYear, prev.DataMonth)
+		if err != nil {
+			return nil, err
+		}
+		dup.Edits = edits
+		for _, b := range edits {
+			dup.EditedFields += b.ChangeCount
+		}
+	}
+	return dup, nil
+}
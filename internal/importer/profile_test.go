@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"northstar/internal/parser"
+	"northstar/internal/store"
+)
+
+func TestImport_SheetTypeCorrectionIsLearnedAsProfile(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.New(filepath.Join(dir, "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	layout := func(month string) []interface{} {
+		return []interface{}{"单位详细名称", month + "月商品总额", "上年" + month + "月商品总额"}
+	}
+	first := filepath.Join(dir, "first.xlsx")
+	writeWorkbook(t, first, []string{"清单"}, map[string][][]interface{}{
+		"清单": {layout("11"), {"甲公司", 100, 80}},
+	})
+
+	coordinator := NewCoordinator(st)
+	preview, err := coordinator.Resolve(first)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if preview.Sheets[0].SheetType != parser.SheetTypeUnknown {
+		t.Fatalf("sheet should not be recognized before learning, got %s", preview.Sheets[0].SheetType)
+	}
+
+	for evt := range coordinator.Import(ImportOptions{
+		FilePath:        first,
+		ClearExisting:   true,
+		CalculateFields: true,
+		Overrides: &Overrides{
+			SheetTypes: map[string]parser.SheetType{"清单": parser.SheetTypeWholesale},
+			Year:       2025,
+			Month:      11,
+			Columns:    map[string]map[int]string{"清单": {1: "sales_current_month", 2: "sales_last_year_month"}},
+		},
+	}) {
+		if evt.Type == "error" {
+			t.Fatalf("import error: %s", evt.Message)
+		}
+	}
+
+	profiles, err := st.ListSheetProfiles()
+	if err != nil || len(profiles) != 1 || profiles[0].SheetType != string(parser.SheetTypeWholesale) {
+		t.Fatalf("expected one learned profile, got %+v err=%v", profiles, err)
+	}
+
+	// 下个月同样布局的工作簿（列名中的月份不同）
+	second := filepath.Join(dir, "second.xlsx")
+	writeWorkbook(t, second, []string{"清单"}, map[string][][]interface{}{
+		"清单": {layout("12"), {"甲公司", 120, 90}},
+	})
+	preview, err = NewCoordinator(st).Resolve(second)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	got := preview.Sheets[0]
+	if got.SheetType != parser.SheetTypeWholesale || got.Confidence != 1 || got.ProfileID != profiles[0].ID {
+		t.Fatalf("learned layout not recognized: %+v", got.SheetRecognitionResult)
+	}
+}
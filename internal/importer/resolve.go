@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"fmt"
+
+	"northstar/internal/model"
+	"northstar/internal/parser"
+)
+
+// SheetPreview 单个 Sheet 的解析预览
+type SheetPreview struct {
+	parser.SheetRecognitionResult
+	Headers        []string              `json:"headers"`
+	HeaderRowStart int                   `json:"headerRowStart"` // 表头首行（Excel 行号）
+	HeaderRowEnd   int                   `json:"headerRowEnd"`   // 表头末行
+	DataRows       int                   `json:"dataRows"`
+	Mappings       []parser.FieldMapping `json:"mappings,omitempty"` // 仅主表：列 → 数据库字段
+}
+
+// ResolvePreview 导入解析预览：各 Sheet 识别结果、数据年月与字段映射
+type ResolvePreview struct {
+	Year       int                 `json:"year"`
+	Month      int                 `json:"month"`
+	Sheets     []SheetPreview      `json:"sheets"`
+	Resolution model.ResolveResult `json:"resolution"`
+}
+
+// Overrides 导入前人工确认的解析参数
+type Overrides struct {
+	SheetTypes map[string]parser.SheetType `json:"sheetTypes,omitempty"` // Sheet 名 → 类型
+	Year       int                         `json:"year,omitempty"`
+	Month      int                         `json:"month,omitempty"`
+	Columns    map[string]map[int]string   `json:"columns,omitempty"` // Sheet 名 → 列索引 → 数据库字段（空表示忽略）
+}
+
+// sheetType 返回人工指定的 Sheet 类型
+func (o *Overrides) sheetType(sheetName string) (parser.SheetType, bool) {
+	if o == nil {
+		return "", false
+	}
+	t, ok := o.SheetTypes[sheetName]
+	return t, ok
+}
+
+// forSheet 生成单个 Sheet 的解析参数
+func (o *Overrides) forSheet(sheetName string) parser.ParseOverrides {
+	if o == nil {
+		return parser.ParseOverrides{}
+	}
+	out := parser.ParseOverrides{Year: o.Year, Month: o.Month, Columns: o.Columns[sheetName]}
+	out.SheetType, _ = o.sheetType(sheetName)
+	return out
+}
+
+// modelSheetTypes model.SheetType（解析阶段约定的角色）与解析器类型的对应关系
+var modelSheetTypes = map[model.SheetType]parser.SheetType{
+	model.SheetTypeWholesaleMain:                 parser.SheetTypeWholesale,
+	model.SheetTypeRetailMain:                    parser.SheetTypeRetail,
+	model.SheetTypeAccommodationMain:             parser.SheetTypeAccommodation,
+	model.SheetTypeCateringMain:                  parser.SheetTypeCatering,
+	model.SheetTypeWholesaleRetailSnapshot:       parser.SheetTypeWRSnapshot,
+	model.SheetTypeAccommodationCateringSnapshot: parser.SheetTypeACSnapshot,
+	model.SheetTypeEatWearUse:                    parser.SheetTypeSummary,
+	model.SheetTypeMicroSmall:                    parser.SheetTypeSummary,
+	model.SheetTypeEatWearUseExcluded:            parser.SheetTypeSummary,
+	model.SheetTypeFixedSocialRetail:             parser.SheetTypeSummary,
+	model.SheetTypeFixedSummary:                  parser.SheetTypeSummary,
+	model.SheetTypeUnknown:                       parser.SheetTypeUnknown,
+}
+
+// ParseSheetType 解析人工指定的 Sheet 类型，同时接受解析器类型（wholesale/wr_snapshot 等）
+// 与 model.SheetType（wholesale_main 等）两种写法
+func ParseSheetType(v string) (parser.SheetType, error) {
+	if t, ok := modelSheetTypes[model.SheetType(v)]; ok {
+		return t, nil
+	}
+	switch t := parser.SheetType(v); t {
+	case parser.SheetTypeWholesale, parser.SheetTypeRetail,
+		parser.SheetTypeAccommodation, parser.SheetTypeCatering,
+		parser.SheetTypeWRSnapshot, parser.SheetTypeACSnapshot,
+		parser.SheetTypeSummary, parser.SheetTypeUnknown:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown sheet type: %s", v)
+}
+
+// OverridesFromRequest 将解析阶段请求转换为导入参数
+func OverridesFromRequest(req model.ResolveRequest) (*Overrides, error) {
+	if req.Month < 0 || req.Month > 12 {
+		return nil, fmt.Errorf("invalid month: %d", req.Month)
+	}
+	o := &Overrides{
+		SheetTypes: map[string]parser.SheetType{},
+		Year:       req.Year,
+		Month:      req.Month,
+		Columns:    req.Columns,
+	}
+	for sheet, v := range req.Overrides {
+		t, err := ParseSheetType(string(v))
+		if err != nil {
+			return nil, err
+		}
+		o.SheetTypes[sheet] = t
+	}
+	// 已指定类型的 Sheet 按对应表校验，其余在提交时按识别结果校验（见 ValidateColumns）
+	for sheet, columns := range req.Columns {
+		if err := parser.ValidateColumnOverrides(o.SheetTypes[sheet], columns); err != nil {
+			return nil, fmt.Errorf("%s: %w", sheet, err)
+		}
+	}
+	return o, nil
+}
+
+// ValidateColumns 按各 Sheet 的类型（人工指定优先，其次识别结果）校验列映射
+func (o *Overrides) ValidateColumns(preview *ResolvePreview) error {
+	if o == nil {
+		return nil
+	}
+	detected := map[string]parser.SheetType{}
+	for _, s := range preview.Sheets {
+		detected[s.SheetName] = s.SheetType
+	}
+	for sheet, columns := range o.Columns {
+		t, ok := o.sheetType(sheet)
+		if !ok {
+			t = detected[sheet]
+		}
+		if err := parser.ValidateColumnOverrides(t, columns); err != nil {
+			return fmt.Errorf("%s: %w", sheet, err)
+		}
+	}
+	return nil
+}
+
+// Resolve 解析工作簿但不写入：返回每个 Sheet 的识别结果、数据年月与主表字段映射
+func (c *Coordinator) Resolve(filePath string) (*ResolvePreview, error) {
+	file, err := parser.OpenWorkbook(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	aliases, err := c.store.ListColumnAliases()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.loadProfiles(); err != nil {
+		return nil, err
+	}
+
+	preview := &ResolvePreview{
+		Sheets: []SheetPreview{},
+		Resolution: model.ResolveResult{
+			MainSheets:     map[model.SheetType]string{},
+			SnapshotSheets: map[model.SheetType]string{},
+			UnknownSheets:  []string{},
+			UnusedSheets:   []string{},
+		},
+	}
+
+	for _, sheetName := range file.GetSheetList() {
+		rows, band, err := parser.ReadSheet(file, sheetName)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Sheet %s 失败: %w", sheetName, err)
+		}
+		headers := band.Headers
+
+		sp := SheetPreview{
+			SheetRecognitionResult: c.recognizer.Recognize(sheetName, headers),
+			Headers:                headers,
+			DataRows:               maxInt(0, len(rows)-band.DataStart),
+		}
+		sp.HeaderRowStart, sp.HeaderRowEnd = band.ExcelRows()
+		if parser.IsMainSheetType(sp.SheetType) {
+			sp.DataYear, sp.DataMonth = parser.DetectSheetYearMonth(sheetName, headers)
+			sp.Mappings = parser.MapColumns(sp.SheetType, headers, sp.DataYear, sp.DataMonth, aliases)
+			if preview.Year == 0 && sp.DataYear > 0 && sp.DataMonth > 0 {
+				preview.Year, preview.Month = sp.DataYear, sp.DataMonth
+			}
+		}
+		preview.Sheets = append(preview.Sheets, sp)
+		addToResolution(&preview.Resolution, sp.SheetName, sp.SheetType)
+	}
+
+	preview.Resolution.Month = preview.Month
+	return preview, nil
+}
+
+// addToResolution 将解析器类型归入解析阶段的角色映射。
+// 同一角色只记录首个 Sheet（其余同类 Sheet 仍会导入，完整列表见 ResolvePreview.Sheets）。
+func addToResolution(r *model.ResolveResult, sheetName string, t parser.SheetType) {
+	var role model.SheetType
+	roles := r.MainSheets
+	switch t {
+	case parser.SheetTypeWholesale:
+		role = model.SheetTypeWholesaleMain
+	case parser.SheetTypeRetail:
+		role = model.SheetTypeRetailMain
+	case parser.SheetTypeAccommodation:
+		role = model.SheetTypeAccommodationMain
+	case parser.SheetTypeCatering:
+		role = model.SheetTypeCateringMain
+	case parser.SheetTypeWRSnapshot:
+		role, roles = model.SheetTypeWholesaleRetailSnapshot, r.SnapshotSheets
+	case parser.SheetTypeACSnapshot:
+		role, roles = model.SheetTypeAccommodationCateringSnapshot, r.SnapshotSheets
+	case parser.SheetTypeUnknown:
+		r.UnknownSheets = append(r.UnknownSheets, sheetName)
+		return
+	default:
+		r.UnusedSheets = append(r.UnusedSheets, sheetName)
+		return
+	}
+	if _, exists := roles[role]; !exists {
+		roles[role] = sheetName
+	}
+}
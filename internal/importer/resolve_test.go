@@ -0,0 +1,84 @@
+package importer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"northstar/internal/model"
+	"northstar/internal/parser"
+	"northstar/internal/store"
+)
+
+func TestResolveThenImportWithOverrides(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "misc.xlsx")
+
+	f := excelize.NewFile()
+	sheet := "企业清单"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.SetSheetRow(sheet, "A1", &[]interface{}{"单位详细名称", "本月销售额", "上年同期销售额", "备用列"})
+	_ = f.SetSheetRow(sheet, "A2", &[]interface{}{"甲公司", 100, 80, 900})
+	if err := f.SaveAs(input); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	st, err := store.New(filepath.Join(dir, "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	coordinator := NewCoordinator(st)
+	preview, err := coordinator.Resolve(input)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(preview.Sheets) != 1 || preview.Sheets[0].SheetType != parser.SheetTypeUnknown {
+		t.Fatalf("expected sheet to be unrecognized, got %+v", preview.Sheets)
+	}
+	if len(preview.Resolution.UnknownSheets) != 1 || preview.Year != 0 {
+		t.Fatalf("unexpected resolution: %+v year=%d", preview.Resolution, preview.Year)
+	}
+
+	overrides, err := OverridesFromRequest(model.ResolveRequest{
+		Year:      2025,
+		Month:     11,
+		Overrides: map[string]model.SheetType{sheet: model.SheetTypeWholesaleMain},
+		Columns:   map[string]map[int]string{sheet: {3: "sales_current_cumulative"}},
+	})
+	if err != nil {
+		t.Fatalf("OverridesFromRequest: %v", err)
+	}
+
+	for evt := range coordinator.Import(ImportOptions{
+		FilePath:        input,
+		ClearExisting:   true,
+		UpdateConfigYM:  true,
+		CalculateFields: true,
+		Overrides:       overrides,
+	}) {
+		if evt.Type == "error" {
+			t.Fatalf("import error: %s", evt.Message)
+		}
+	}
+
+	var month int
+	var cur, lastYear, cum float64
+	if err := st.DB().QueryRow(`
+		SELECT data_month, sales_current_month, sales_last_year_month, sales_current_cumulative
+		FROM wholesale_retail WHERE name = '甲公司' AND data_year = 2025
+	`).Scan(&month, &cur, &lastYear, &cum); err != nil {
+		t.Fatalf("query imported row: %v", err)
+	}
+	if month != 11 || cur != 100 || lastYear != 80 || cum != 900 {
+		t.Fatalf("month=%d cur=%v lastYear=%v cum=%v", month, cur, lastYear, cum)
+	}
+
+	if _, err := ParseSheetType("not_a_type"); err == nil {
+		t.Fatalf("expected error for unknown sheet type")
+	}
+}
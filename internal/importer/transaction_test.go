@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"northstar/internal/store"
+)
+
+// writeWorkbook 按 Sheet 顺序生成测试工作簿
+func writeWorkbook(t *testing.T, path string, sheets []string, rows map[string][][]interface{}) {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	for i, name := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", name); err != nil {
+				t.Fatal(err)
+			}
+		} else if _, err := f.NewSheet(name); err != nil {
+			t.Fatal(err)
+		}
+		for r, row := range rows[name] {
+			cell, _ := excelize.CoordinatesToCellName(1, r+1)
+			if err := f.SetSheetRow(name, cell, &row); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runImport(t *testing.T, c *Coordinator, path string) []ProgressEvent {
+	t.Helper()
+	var events []ProgressEvent
+	for evt := range c.Import(ImportOptions{
+		FilePath:        path,
+		ClearExisting:   true,
+		UpdateConfigYM:  true,
+		CalculateFields: true,
+	}) {
+		events = append(events, evt)
+	}
+	return events
+}
+
+func TestImport_FailedSheetRollsBackWholeWorkbook(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.New(filepath.Join(dir, "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	header := []interface{}{"统一社会信用代码", "单位详细名称", "行业代码", "单位规模", "2025年12月销售额", "2025年12月零售额"}
+	good := filepath.Join(dir, "good.xlsx")
+	writeWorkbook(t, good, []string{"批发"}, map[string][][]interface{}{
+		"批发": {header, {"91A", "原有公司", "5111", 3, 100, 50}},
+	})
+	runImport(t, NewCoordinator(st), good)
+
+	// 第二个工作簿：首个 Sheet 正常，第二个 Sheet 只有表头（解析失败）
+	bad := filepath.Join(dir, "bad.xlsx")
+	writeWorkbook(t, bad, []string{"批发", "零售"}, map[string][][]interface{}{
+		"批发": {header, {"91B", "新公司", "5111", 3, 200, 80}},
+		"零售": {header},
+	})
+	events := runImport(t, NewCoordinator(st), bad)
+
+	last := events[len(events)-1]
+	if last.Type != "error" {
+		t.Fatalf("expected final error event, got %s: %s", last.Type, last.Message)
+	}
+
+	var names []string
+	rows, err := st.DB().Query("SELECT name FROM wholesale_retail WHERE data_year = 2025 AND data_month = 12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var n string
+		_ = rows.Scan(&n)
+		names = append(names, n)
+	}
+	rows.Close()
+	if len(names) != 1 || names[0] != "原有公司" {
+		t.Fatalf("previous month data should be untouched, got %v", names)
+	}
+
+	var status, reason string
+	if err := st.DB().QueryRow(
+		"SELECT status, error_message FROM import_logs ORDER BY id DESC LIMIT 1",
+	).Scan(&status, &reason); err != nil {
+		t.Fatal(err)
+	}
+	if status != "rolled_back" || reason == "" {
+		t.Fatalf("import log status=%q reason=%q", status, reason)
+	}
+
+	var metaStatus string
+	if err := st.DB().QueryRow(
+		"SELECT status FROM sheets_meta WHERE sheet_name = '批发' ORDER BY id DESC LIMIT 1",
+	).Scan(&metaStatus); err != nil {
+		t.Fatal(err)
+	}
+	if metaStatus != "rolled_back" {
+		t.Fatalf("sheet meta status=%q, want rolled_back", metaStatus)
+	}
+}
+
+func TestImportWithContext_CanceledImportRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	st, err := store.New(filepath.Join(dir, "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	input := filepath.Join(dir, "input.xlsx")
+	writeWorkbook(t, input, []string{"批发"}, map[string][][]interface{}{
+		"批发": {
+			{"统一社会信用代码", "单位详细名称", "行业代码", "单位规模", "2025年12月销售额", "2025年12月零售额"},
+			{"91A", "甲公司", "5111", 3, 100, 50},
+		},
+	})
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var startedID int64
+	for range NewCoordinator(st).ImportWithContext(runCtx, ImportOptions{
+		FilePath:        input,
+		ClearExisting:   true,
+		CalculateFields: true,
+		OnStart:         func(id int64) { startedID = id },
+	}) {
+	}
+
+	var n int
+	if err := st.DB().QueryRow("SELECT COUNT(*) FROM wholesale_retail").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("canceled import wrote %d rows", n)
+	}
+
+	var status string
+	if err := st.DB().QueryRow("SELECT status FROM import_logs WHERE id = ?", startedID).Scan(&status); err != nil {
+		t.Fatalf("import log %d: %v", startedID, err)
+	}
+	if status != "cancelled" {
+		t.Fatalf("import log status=%q, want cancelled", status)
+	}
+}
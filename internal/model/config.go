@@ -8,17 +8,20 @@ type Config struct {
 
 // OptimizeConstraints 智能调整约束条件
 type OptimizeConstraints struct {
-	TargetGrowthRate   float64  `json:"targetGrowthRate"`   // 目标增速
-	MaxIndividualRate  float64  `json:"maxIndividualRate"`  // 单个企业最大增速
-	MinIndividualRate  float64  `json:"minIndividualRate"`  // 单个企业最小增速
-	PriorityIndustries []string `json:"priorityIndustries"` // 优先调整的行业
+	TargetGrowthRate     float64  `json:"targetGrowthRate"`     // 目标增速
+	MaxIndividualRate    *float64 `json:"maxIndividualRate"`    // 单个企业最大增速（未设置不限制）
+	MinIndividualRate    *float64 `json:"minIndividualRate"`    // 单个企业最小增速（未设置不限制）
+	MaxIndividualMomRate *float64 `json:"maxIndividualMomRate"` // 单个企业最大环比增速（当月字段相对上月，未设置不限制）
+	MinIndividualMomRate *float64 `json:"minIndividualMomRate"` // 单个企业最小环比增速（未设置不限制）
+	PriorityIndustries   []string `json:"priorityIndustries"`   // 优先调整的行业
 }
 
 // DefaultOptimizeConstraints 默认约束条件
 func DefaultOptimizeConstraints() *OptimizeConstraints {
+	maxRate, minRate := 0.5, 0.0
 	return &OptimizeConstraints{
-		MaxIndividualRate:  0.5,
-		MinIndividualRate:  0,
+		MaxIndividualRate:  &maxRate,
+		MinIndividualRate:  &minRate,
 		PriorityIndustries: []string{"catering", "retail"},
 	}
 }
@@ -36,6 +39,7 @@ type OptimizeResult struct {
 type CompanyAdjustment struct {
 	CompanyID     string  `json:"companyId"`
 	CompanyName   string  `json:"companyName"`
+	Field         string  `json:"field,omitempty"` // 调整的金额字段（住餐零售额为 food+goods 合计）
 	OriginalValue float64 `json:"originalValue"`
 	AdjustedValue float64 `json:"adjustedValue"`
 	ChangePercent float64 `json:"changePercent"`
@@ -1,9 +1,14 @@
 package model
 
+import "time"
+
 // ResolveRequest 解析阶段请求参数（手动选择月份 + 人工确认 sheet 类型）
 type ResolveRequest struct {
+	Year      int                  `json:"year"`
 	Month     int                  `json:"month"`
 	Overrides map[string]SheetType `json:"overrides"`
+	// Columns 主表列映射修正：sheet 名 → 列索引 → 数据库字段（空字符串表示忽略该列）
+	Columns map[string]map[int]string `json:"columns"`
 }
 
 // ResolveResult 解析阶段产物：选择后的 sheet 角色映射
@@ -14,3 +19,58 @@ type ResolveResult struct {
 	UnknownSheets  []string             `json:"unknownSheets"`
 	UnusedSheets   []string             `json:"unusedSheets"`
 }
+
+// 行级问题类型
+const (
+	IssueInvalidNumber       = "invalid_number"        // 数值无法解析
+	IssueMissingCreditCode   = "missing_credit_code"   // 缺少统一社会信用代码
+	IssueUnknownIndustryCode = "unknown_industry_code" // 行业代码无法识别或与表类型不符
+	IssueDuplicateCompany    = "duplicate_company"     // 同一 Sheet 内企业重复
+)
+
+// RowIssue 导入时发现的行级问题（定位到单元格）
+type RowIssue struct {
+	Sheet   string `json:"sheet"`
+	Row     int    `json:"row"`    // Excel 行号（从 1 开始）
+	Column  string `json:"column"` // 列字母（如 "E"）；整行问题时为空
+	Header  string `json:"header"`
+	Value   string `json:"value"`
+	Code    string `json:"code"`
+	Problem string `json:"problem"`
+}
+
+// 列别名匹配方式
+const (
+	AliasMatchExact    = "exact"    // 列名（规范化后）完全相同
+	AliasMatchContains = "contains" // 列名包含 pattern
+	AliasMatchRegex    = "regex"    // 正则匹配
+)
+
+// ColumnAlias 用户维护的列别名：列名 pattern → 数据库字段 + 时间口径，导入时优先于内置规则
+type ColumnAlias struct {
+	ID        int64  `json:"id"`
+	Pattern   string `json:"pattern"`
+	MatchType string `json:"matchType"` // exact/contains/regex
+	// Scope 适用表：wholesale_retail / accommodation_catering，空表示两者
+	Scope string `json:"scope"`
+	// DBField 数据库字段，或时间敏感指标（sales/retail/revenue/room/food/goods，按 TimeType 或列名推断口径）
+	DBField string `json:"dbField"`
+	// TimeType 时间口径（current_month/prev_month/last_year_month/current_cumulative/prev_cumulative/
+	// last_year_cumulative/last_year_prev_cumulative），空表示按列名推断
+	TimeType  string    `json:"timeType"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SheetProfile 学习到的 Sheet 识别档案：按表头签名记住人工确认的 Sheet 类型
+type SheetProfile struct {
+	ID        int64     `json:"id"`
+	Signature string    `json:"signature"` // 规范化表头签名
+	SheetType string    `json:"sheetType"`
+	SheetName string    `json:"sheetName"` // 学习时的 Sheet 名（仅供参考）
+	Headers   []string  `json:"headers"`   // 学习时的表头
+	HitCount  int       `json:"hitCount"`  // 被识别命中的次数
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -209,6 +209,8 @@ type SheetMeta struct {
 	Confidence       float64   `json:"confidence"`
 	TotalRows        int       `json:"totalRows"`
 	TotalColumns     int       `json:"totalColumns"`
+	HeaderRowStart   int       `json:"headerRowStart"` // 表头首行（Excel 行号）
+	HeaderRowEnd     int       `json:"headerRowEnd"`   // 表头末行（多行表头时大于首行）
 	ImportedRows     int       `json:"importedRows"`
 	ColumnsJSON      string    `json:"columnsJson"`
 	ColumnMappingJSON string   `json:"columnMappingJson"`
@@ -232,6 +234,8 @@ type ImportLog struct {
 	TotalRows      int        `json:"totalRows"`
 	ImportedRows   int        `json:"importedRows"`
 	ErrorRows      int        `json:"errorRows"`
+	DataYear       int        `json:"dataYear"`  // 导入产生的数据年份（未识别时为 0）
+	DataMonth      int        `json:"dataMonth"` // 导入产生的数据月份
 	Status         string     `json:"status"`
 	ErrorMessage   string     `json:"errorMessage"`
 	StartedAt      time.Time  `json:"startedAt"`
@@ -15,6 +15,10 @@ type ACParser struct {
 	recognizer   *SheetRecognizer
 	currentYear  int
 	currentMonth int
+	overrides    ParseOverrides
+	diag         *rowDiagnostics
+	mappings     map[int]FieldMapping
+	aliases      []model.ColumnAlias
 }
 
 // NewACParser 创建住餐解析器
@@ -25,29 +29,58 @@ func NewACParser(file *excelize.File) *ACParser {
 	}
 }
 
+// SetOverrides 设置人工确认的解析参数
+func (p *ACParser) SetOverrides(o ParseOverrides) {
+	p.overrides = o
+}
+
+// SetAliases 设置用户列别名（优先于内置映射规则）
+func (p *ACParser) SetAliases(aliases []model.ColumnAlias) {
+	p.aliases = aliases
+}
+
+// Mappings 返回最近一次 ParseSheet 使用的列映射（按列序）
+func (p *ACParser) Mappings() []FieldMapping {
+	return sortedMappings(p.mappings)
+}
+
+// Issues 返回最近一次 ParseSheet 收集到的行级问题
+func (p *ACParser) Issues() []model.RowIssue {
+	if p.diag == nil {
+		return nil
+	}
+	return p.diag.issues
+}
+
 // ParseSheet 解析住餐 Sheet
 func (p *ACParser) ParseSheet(sheetName string) ([]*model.AccommodationCatering, error) {
 	// 读取所有行
-	rows, err := p.file.GetRows(sheetName)
+	rows, band, err := ReadSheet(p.file, sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read sheet: %w", err)
 	}
 
-	if len(rows) < 2 {
+	if len(rows) <= band.DataStart {
 		return nil, fmt.Errorf("sheet has no data rows")
 	}
 
-	// 第一行是表头
-	headers := rows[0]
+	// 表头区域（可能有标题行、多行合并表头）
+	headers := band.Headers
 
 	// 识别 Sheet 类型
 	result := p.recognizer.Recognize(sheetName, headers)
+	if p.overrides.SheetType != "" {
+		result.SheetType = p.overrides.SheetType
+	}
 	if result.SheetType != SheetTypeAccommodation && result.SheetType != SheetTypeCatering {
 		return nil, fmt.Errorf("not an accommodation/catering sheet")
 	}
 
 	// 从列名中识别当前年月
 	year, month := FindCurrentYearMonth(headers)
+	if p.overrides.Year > 0 && p.overrides.Month > 0 {
+		year, month = p.overrides.Year, p.overrides.Month
+	}
 	if year == 0 || month == 0 {
 		// 兜底：部分文件列头可能不带年份/月份，但 sheet 名包含 "YYYY年MM月"
 		if y, m, found := ExtractYearMonth(sheetName); found {
@@ -62,11 +95,17 @@ func (p *ACParser) ParseSheet(sheetName string) ([]*model.AccommodationCatering,
 
 	// 创建字段映射器
 	mapper := NewFieldMapper(year, month)
+	mapper.SetAliases(p.aliases)
 	mappings := mapper.MapAccommodationCatering(headers)
+	if err := applyColumnOverrides(SheetTypeAccommodation, mappings, headers, p.overrides.Columns); err != nil {
+		return nil, err
+	}
+	p.mappings = mappings
+	p.diag = newRowDiagnostics(sheetName, headers, mappings, "accommodation", "catering")
 
 	// 解析数据行
 	var records []*model.AccommodationCatering
-	for rowIdx := 1; rowIdx < len(rows); rowIdx++ {
+	for rowIdx := band.DataStart; rowIdx < len(rows); rowIdx++ {
 		row := rows[rowIdx]
 		record := p.parseACRow(row, mappings, sheetName, rowIdx+1)
 		if record != nil {
@@ -102,6 +141,7 @@ func (p *ACParser) parseACRow(row []string, mappings map[int]FieldMapping, sheet
 		if value == "" {
 			continue
 		}
+		p.diag.checkValue(rowNo, colIdx, mapping.DBField, value)
 
 		// 根据字段名设置值
 		p.setACFieldValue(record, mapping.DBField, value)
@@ -111,6 +151,7 @@ func (p *ACParser) parseACRow(row []string, mappings map[int]FieldMapping, sheet
 	if record.Name == "" {
 		return nil // 跳过无名称的行
 	}
+	p.diag.checkCompany(rowNo, record.CreditCode, record.Name, record.IndustryCode)
 
 	// 补齐行业类型（用于指标计算与过滤）
 	if record.IndustryType == "" && record.IndustryCode != "" {
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"northstar/internal/model"
+)
+
+// 列别名适用范围
+const (
+	AliasScopeWR = "wholesale_retail"
+	AliasScopeAC = "accommodation_catering"
+)
+
+// aliasFields 各表可映射的数据库字段
+var aliasFields = map[string][]string{
+	AliasScopeWR: {
+		"credit_code", "name", "industry_code", "company_scale", "retail_ratio",
+		"sales_prev_month", "sales_current_month", "sales_last_year_month", "sales_month_rate",
+		"sales_prev_cumulative", "sales_last_year_prev_cumulative", "sales_current_cumulative",
+		"sales_last_year_cumulative", "sales_cumulative_rate",
+		"retail_prev_month", "retail_current_month", "retail_last_year_month", "retail_month_rate",
+		"retail_prev_cumulative", "retail_last_year_prev_cumulative", "retail_current_cumulative",
+		"retail_last_year_cumulative", "retail_cumulative_rate",
+		"cat_grain_oil_food", "cat_beverage", "cat_tobacco_liquor", "cat_clothing", "cat_daily_use", "cat_automobile",
+		"is_small_micro", "is_eat_wear_use", "first_report_ip", "fill_ip", "network_sales",
+		"opening_year", "opening_month",
+	},
+	AliasScopeAC: {
+		"credit_code", "name", "industry_code", "company_scale",
+		"revenue_prev_month", "revenue_current_month", "revenue_last_year_month", "revenue_month_rate",
+		"revenue_prev_cumulative", "revenue_current_cumulative", "revenue_last_year_cumulative", "revenue_cumulative_rate",
+		"room_prev_month", "room_current_month", "room_last_year_month",
+		"room_prev_cumulative", "room_current_cumulative", "room_last_year_cumulative",
+		"food_prev_month", "food_current_month", "food_last_year_month",
+		"food_prev_cumulative", "food_current_cumulative", "food_last_year_cumulative",
+		"goods_prev_month", "goods_current_month", "goods_last_year_month",
+		"goods_prev_cumulative", "goods_current_cumulative", "goods_last_year_cumulative",
+		"retail_current_month", "retail_last_year_month",
+		"is_small_micro", "is_eat_wear_use", "first_report_ip", "fill_ip", "network_sales",
+		"opening_year", "opening_month",
+	},
+}
+
+// aliasMetrics 时间敏感指标（按时间口径映射到具体字段）
+var aliasMetrics = map[string][]string{
+	AliasScopeWR: {"sales", "retail"},
+	AliasScopeAC: {"revenue", "room", "food", "goods", "retail"},
+}
+
+// timeTypeNames 时间口径名称
+var timeTypeNames = map[string]FieldTimeType{
+	"current_month":             CurrentMonth,
+	"prev_month":                PrevMonth,
+	"last_year_month":           LastYearMonth,
+	"current_cumulative":        CurrentCumulative,
+	"prev_cumulative":           PrevCumulative,
+	"last_year_cumulative":      LastYearCumulative,
+	"last_year_prev_cumulative": LastYearPrevCumulative,
+}
+
+// matchTypeRank 匹配方式优先级：完全相同 > 包含 > 正则
+var matchTypeRank = map[string]int{
+	model.AliasMatchExact:    0,
+	model.AliasMatchContains: 1,
+	model.AliasMatchRegex:    2,
+}
+
+// ValidateColumnAlias 校验列别名（匹配方式、正则、适用表、字段与时间口径）
+func ValidateColumnAlias(a model.ColumnAlias) error {
+	if strings.TrimSpace(a.Pattern) == "" {
+		return fmt.Errorf("pattern 不能为空")
+	}
+	if _, ok := matchTypeRank[a.MatchType]; !ok {
+		return fmt.Errorf("不支持的匹配方式: %s", a.MatchType)
+	}
+	if a.MatchType == model.AliasMatchRegex {
+		if _, err := regexp.Compile(a.Pattern); err != nil {
+			return fmt.Errorf("正则表达式无效: %v", err)
+		}
+	}
+	if a.TimeType != "" {
+		if _, ok := timeTypeNames[a.TimeType]; !ok {
+			return fmt.Errorf("不支持的时间口径: %s", a.TimeType)
+		}
+	}
+
+	scopes := []string{AliasScopeWR, AliasScopeAC}
+	switch a.Scope {
+	case "":
+	case AliasScopeWR, AliasScopeAC:
+		scopes = []string{a.Scope}
+	default:
+		return fmt.Errorf("不支持的适用表: %s", a.Scope)
+	}
+	for _, scope := range scopes {
+		if containsString(aliasMetrics[scope], a.DBField) || containsString(aliasFields[scope], a.DBField) {
+			return nil
+		}
+	}
+	return fmt.Errorf("未知字段: %s", a.DBField)
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// aliasRule 预处理后的列别名
+type aliasRule struct {
+	alias   model.ColumnAlias
+	pattern string
+	re      *regexp.Regexp
+}
+
+func (r aliasRule) match(col string) bool {
+	switch r.alias.MatchType {
+	case model.AliasMatchExact:
+		return col == r.pattern
+	case model.AliasMatchContains:
+		return strings.Contains(col, r.pattern)
+	case model.AliasMatchRegex:
+		return r.re.MatchString(col)
+	}
+	return false
+}
+
+// SetAliases 设置用户列别名（无效别名忽略）；同一列命中多个别名时，完全相同 > 包含 > 正则，其次按创建顺序
+func (m *FieldMapper) SetAliases(aliases []model.ColumnAlias) {
+	rules := make([]aliasRule, 0, len(aliases))
+	for _, a := range aliases {
+		if ValidateColumnAlias(a) != nil {
+			continue
+		}
+		r := aliasRule{alias: a, pattern: NormalizeColumnName(a.Pattern)}
+		if a.MatchType == model.AliasMatchRegex {
+			r.re = regexp.MustCompile(a.Pattern)
+		}
+		rules = append(rules, r)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return matchTypeRank[rules[i].alias.MatchType] < matchTypeRank[rules[j].alias.MatchType]
+	})
+	m.aliases = rules
+}
+
+// mapAlias 按用户列别名映射单列；未命中返回 false
+func (m *FieldMapper) mapAlias(scope, col string, idx int) (FieldMapping, bool) {
+	for _, r := range m.aliases {
+		if r.alias.Scope != "" && r.alias.Scope != scope {
+			continue
+		}
+		if !r.match(col) {
+			continue
+		}
+
+		mapping := FieldMapping{ColumnIndex: idx, ColumnName: col}
+		timeType, explicit := timeTypeNames[r.alias.TimeType]
+		if containsString(aliasMetrics[scope], r.alias.DBField) {
+			if !explicit {
+				timeType = InferFieldTimeType(col, m.currentYear, m.currentMonth)
+			}
+			mapping.TimeType = timeType
+			mapping.DBField = m.mapMetricField(r.alias.DBField, timeType)
+		} else if containsString(aliasFields[scope], r.alias.DBField) {
+			mapping.TimeType = timeType
+			mapping.DBField = r.alias.DBField
+		}
+		if mapping.DBField != "" && containsString(aliasFields[scope], mapping.DBField) {
+			return mapping, true
+		}
+	}
+	return FieldMapping{}, false
+}
+
+// mapMetricField 时间敏感指标 + 时间口径 → 字段
+func (m *FieldMapper) mapMetricField(metric string, timeType FieldTimeType) string {
+	switch metric {
+	case "sales":
+		return m.mapSalesField(timeType)
+	case "retail":
+		return m.mapRetailField(timeType)
+	case "revenue":
+		return m.mapRevenueField(timeType)
+	case "room":
+		return m.mapRoomField(timeType)
+	case "food":
+		return m.mapFoodField(timeType)
+	case "goods":
+		return m.mapGoodsField(timeType)
+	}
+	return ""
+}
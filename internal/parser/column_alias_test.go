@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"testing"
+
+	"northstar/internal/model"
+)
+
+func TestFieldMapper_AliasesTakePrecedenceOverBuiltinRules(t *testing.T) {
+	mapper := NewFieldMapper(2025, 12)
+	mapper.SetAliases([]model.ColumnAlias{
+		{Pattern: "商品总额", MatchType: model.AliasMatchContains, DBField: "sales", TimeType: "last_year_month"},
+		{Pattern: "[101-2]商品总额", MatchType: model.AliasMatchExact, DBField: "sales", TimeType: "current_month"},
+		{Pattern: `^网销`, MatchType: model.AliasMatchRegex, Scope: AliasScopeWR, DBField: "network_sales"},
+		{Pattern: "单位详细名称", MatchType: model.AliasMatchExact, Scope: AliasScopeAC, DBField: "credit_code"},
+		{Pattern: "无效", MatchType: model.AliasMatchContains, DBField: "no_such_field"},
+	})
+
+	headers := []string{"单位详细名称", "[101-2]商品总额", "[101-3]上年商品总额", "网销金额", "无效列"}
+	mappings := mapper.MapWholesaleRetail(headers)
+
+	want := map[int]string{
+		0: "name", // 仅住餐适用的别名不影响批零表
+		1: "sales_current_month",
+		2: "sales_last_year_month",
+		3: "network_sales",
+	}
+	for idx, field := range want {
+		if mappings[idx].DBField != field {
+			t.Errorf("column %d (%s) mapped to %q, want %q", idx, headers[idx], mappings[idx].DBField, field)
+		}
+	}
+	if _, ok := mappings[4]; ok {
+		t.Errorf("invalid alias should be ignored, got %+v", mappings[4])
+	}
+
+	if err := ValidateColumnAlias(model.ColumnAlias{Pattern: "(", MatchType: model.AliasMatchRegex, DBField: "name"}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if err := ValidateColumnAlias(model.ColumnAlias{Pattern: "x", MatchType: model.AliasMatchExact, Scope: AliasScopeAC, DBField: "sales"}); err == nil {
+		t.Error("sales is not a catering metric")
+	}
+}
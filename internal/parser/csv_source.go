@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// readCSV 读取一个 CSV 文件为 Sheet：自动识别编码（UTF-8/UTF-16/GBK/GB18030）与分隔符（逗号/制表符/分号）
+func readCSV(name string, data []byte) (sourceSheet, error) {
+	text, err := decodeText(data)
+	if err != nil {
+		return sourceSheet{}, fmt.Errorf("CSV %s 编码无法识别: %w", name, err)
+	}
+
+	r := csv.NewReader(bytes.NewReader(text))
+	r.Comma = detectDelimiter(text)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return sourceSheet{}, fmt.Errorf("CSV %s 解析失败: %w", name, err)
+	}
+
+	sheet := sourceSheet{Name: name, Rows: make([][]interface{}, len(records))}
+	for i, record := range records {
+		row := make([]interface{}, len(record))
+		for j, v := range record {
+			if v = strings.TrimSpace(v); v != "" {
+				row[j] = v
+			}
+		}
+		sheet.Rows[i] = row
+	}
+	return sheet, nil
+}
+
+// decodeText 按 BOM 或内容识别编码并转为 UTF-8；非 UTF-8 内容按 GB18030（兼容 GBK）解码
+func decodeText(data []byte) ([]byte, error) {
+	var enc encoding.Encoding
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return data[3:], nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		enc = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	case utf8.Valid(data):
+		return data, nil
+	default:
+		enc = simplifiedchinese.GB18030
+	}
+	return enc.NewDecoder().Bytes(data)
+}
+
+// detectDelimiter 根据首行判断分隔符
+func detectDelimiter(text []byte) rune {
+	line := text
+	if i := bytes.IndexByte(text, '\n'); i >= 0 {
+		line = text[:i]
+	}
+	best, bestCount := ',', bytes.Count(line, []byte{','})
+	for _, d := range []rune{'\t', ';'} {
+		if n := bytes.Count(line, []byte{byte(d)}); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
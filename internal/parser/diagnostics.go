@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"northstar/internal/model"
+)
+
+// textFields 文本字段（其余映射字段均按数值解析）
+var textFields = map[string]bool{
+	"credit_code":     true,
+	"name":            true,
+	"industry_code":   true,
+	"first_report_ip": true,
+	"fill_ip":         true,
+}
+
+// rowDiagnostics 主表行级问题收集器
+type rowDiagnostics struct {
+	sheet    string
+	headers  []string
+	columnOf map[string]int // 数据库字段 → 列索引
+	industry []string       // 本表允许的行业类型
+	seen     map[string]int // 企业键 → 首次出现的行号
+	issues   []model.RowIssue
+}
+
+func newRowDiagnostics(sheet string, headers []string, mappings map[int]FieldMapping, industry ...string) *rowDiagnostics {
+	d := &rowDiagnostics{
+		sheet:    sheet,
+		headers:  headers,
+		columnOf: map[string]int{},
+		industry: industry,
+		seen:     map[string]int{},
+	}
+	for idx, m := range mappings {
+		if prev, ok := d.columnOf[m.DBField]; !ok || idx < prev {
+			d.columnOf[m.DBField] = idx
+		}
+	}
+	return d
+}
+
+// add 记录问题；col < 0 表示整行问题
+func (d *rowDiagnostics) add(row, col int, code, problem, value string) {
+	issue := model.RowIssue{Sheet: d.sheet, Row: row, Value: value, Code: code, Problem: problem}
+	if col >= 0 {
+		issue.Column, _ = excelize.ColumnNumberToName(col + 1)
+		if col < len(d.headers) {
+			issue.Header = strings.TrimSpace(d.headers[col])
+		}
+	}
+	d.issues = append(d.issues, issue)
+}
+
+// checkValue 检查数值字段能否解析（解析失败时原逻辑按 0/空处理）
+func (d *rowDiagnostics) checkValue(row, col int, field, value string) {
+	if textFields[field] || isNumericCell(value) {
+		return
+	}
+	d.add(row, col, model.IssueInvalidNumber, "无法解析为数值，已按空值处理", value)
+}
+
+// checkCompany 检查信用代码、行业代码与重复企业
+func (d *rowDiagnostics) checkCompany(row int, creditCode, name, industryCode string) {
+	if strings.TrimSpace(creditCode) == "" {
+		d.add(row, d.column("credit_code"), model.IssueMissingCreditCode, "缺少统一社会信用代码", name)
+	}
+
+	if industryCode != "" {
+		t := RecognizeIndustryType(industryCode)
+		allowed := false
+		for _, v := range d.industry {
+			if t == v {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			problem := "无法识别的行业代码"
+			if t != "unknown" {
+				problem = fmt.Sprintf("行业代码属于 %s，与本表类型不符", t)
+			}
+			d.add(row, d.column("industry_code"), model.IssueUnknownIndustryCode, problem, industryCode)
+		}
+	}
+
+	key, col, value := "code:"+creditCode, d.column("credit_code"), creditCode
+	if strings.TrimSpace(creditCode) == "" {
+		key, col, value = "name:"+name, d.column("name"), name
+	}
+	if first, ok := d.seen[key]; ok {
+		d.add(row, col, model.IssueDuplicateCompany, fmt.Sprintf("与第 %d 行企业重复", first), value)
+		return
+	}
+	d.seen[key] = row
+}
+
+func (d *rowDiagnostics) column(field string) int {
+	if idx, ok := d.columnOf[field]; ok {
+		return idx
+	}
+	return -1
+}
+
+// isNumericCell 单元格能否按数值解析（允许千分位、百分号与常见的空值占位符）
+func isNumericCell(s string) bool {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "", "-", "—", "--", "/":
+		return true
+	}
+	s = strings.ReplaceAll(s, ",", "")
+	s = strings.ReplaceAll(s, "％", "%")
+	s = strings.TrimSuffix(s, "%")
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -8,6 +8,7 @@ import (
 type FieldMapper struct {
 	currentYear  int
 	currentMonth int
+	aliases      []aliasRule
 }
 
 // NewFieldMapper 创建字段映射器
@@ -33,7 +34,11 @@ func (m *FieldMapper) MapWholesaleRetail(columnNames []string) map[int]FieldMapp
 			continue
 		}
 
-		mapping := m.mapWRColumnWithContext(col, idx, normalized)
+		// 用户列别名优先于内置规则
+		mapping, ok := m.mapAlias(AliasScopeWR, col, idx)
+		if !ok {
+			mapping = m.mapWRColumnWithContext(col, idx, normalized)
+		}
 		if mapping.DBField != "" {
 			mappings[idx] = mapping
 		}
@@ -57,7 +62,11 @@ func (m *FieldMapper) MapAccommodationCatering(columnNames []string) map[int]Fie
 			continue
 		}
 
-		mapping := m.mapACColumnWithContext(col, idx, normalized)
+		// 用户列别名优先于内置规则
+		mapping, ok := m.mapAlias(AliasScopeAC, col, idx)
+		if !ok {
+			mapping = m.mapACColumnWithContext(col, idx, normalized)
+		}
 		if mapping.DBField != "" {
 			mappings[idx] = mapping
 		}
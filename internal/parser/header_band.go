@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	headerScanRows = 10 // 在前若干行中查找表头
+	maxHeaderRows  = 3  // 表头最多行数
+)
+
+// subHeaderKeywords 第二层表头常见的口径关键词
+var subHeaderKeywords = []string{"本月", "当月", "上月", "本年", "上年", "去年", "同期", "累计", "增速", "增长"}
+
+// HeaderBand 表头区域（行索引从 0 开始）
+type HeaderBand struct {
+	StartRow  int      // 表头首行
+	EndRow    int      // 表头末行
+	DataStart int      // 数据首行
+	Headers   []string // 展开合并单元格后的列名（多行表头按“上层-下层”拼接）
+}
+
+// ExcelRows 表头首行与末行的 Excel 行号（从 1 开始）
+func (b HeaderBand) ExcelRows() (int, int) {
+	return b.StartRow + 1, b.EndRow + 1
+}
+
+// ReadSheet 读取 Sheet 全部行并识别表头区域
+func ReadSheet(f *excelize.File, sheetName string) ([][]string, HeaderBand, error) {
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		return nil, HeaderBand{}, err
+	}
+	merges, _ := f.GetMergeCells(sheetName)
+	return rows, DetectHeaderBand(rows, merges), nil
+}
+
+// DetectHeaderBand 识别表头区域：跳过标题/单位等单值行，合并的多行表头展开为组合列名
+func DetectHeaderBand(rows [][]string, merges []excelize.MergeCell) HeaderBand {
+	window := min(len(rows), headerScanRows)
+	if window == 0 {
+		return HeaderBand{DataStart: 1}
+	}
+
+	// 展开合并单元格（合并区域内每个单元格都取左上角的值）
+	filled := make([][]string, window)
+	hmerged := make([]bool, window)
+	for r := 0; r < window; r++ {
+		filled[r] = append([]string(nil), rows[r]...)
+	}
+	for _, m := range merges {
+		c1, r1, err1 := excelize.CellNameToCoordinates(m.GetStartAxis())
+		c2, r2, err2 := excelize.CellNameToCoordinates(m.GetEndAxis())
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		for r := r1 - 1; r < r2 && r < window; r++ {
+			if c2 > c1 {
+				hmerged[r] = true
+			}
+			for len(filled[r]) < c2 {
+				filled[r] = append(filled[r], "")
+			}
+			for c := c1 - 1; c < c2; c++ {
+				filled[r][c] = m.GetCellValue()
+			}
+		}
+	}
+
+	// 表头首行：非空单元格足够多且不止一个不同取值（排除标题行、单位行）
+	maxCount := 0
+	for _, row := range filled {
+		maxCount = max(maxCount, countNonEmpty(row))
+	}
+	start := 0
+	for r, row := range filled {
+		if n := countNonEmpty(row); n >= 2 && n*2 >= maxCount && countDistinct(row) >= 2 {
+			start = r
+			break
+		}
+	}
+
+	// 后续行：不含数值，且上一行有横向合并或本行含口径关键词时视为下层表头
+	end := start
+	for r := start + 1; r < window && r-start < maxHeaderRows; r++ {
+		if countNonEmpty(filled[r]) == 0 || hasNumericCell(filled[r]) {
+			break
+		}
+		if !hmerged[r-1] && !hasSubHeaderKeyword(filled[r]) {
+			break
+		}
+		end = r
+	}
+
+	band := HeaderBand{StartRow: start, EndRow: end, DataStart: end + 1}
+	if end == start {
+		band.Headers = rows[start]
+		return band
+	}
+
+	width := 0
+	for r := start; r <= end; r++ {
+		width = max(width, len(filled[r]))
+	}
+	band.Headers = make([]string, width)
+	for c := 0; c < width; c++ {
+		var parts []string
+		for r := start; r <= end; r++ {
+			if c >= len(filled[r]) {
+				continue
+			}
+			v := strings.TrimSpace(filled[r][c])
+			if v != "" && (len(parts) == 0 || parts[len(parts)-1] != v) {
+				parts = append(parts, v)
+			}
+		}
+		band.Headers[c] = strings.Join(parts, "-")
+	}
+	return band
+}
+
+func countNonEmpty(row []string) int {
+	n := 0
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func countDistinct(row []string) int {
+	seen := map[string]bool{}
+	for _, v := range row {
+		if v = strings.TrimSpace(v); v != "" {
+			seen[v] = true
+		}
+	}
+	return len(seen)
+}
+
+// hasNumericCell 行内是否有数值单元格（数据行特征）
+func hasNumericCell(row []string) bool {
+	for _, v := range row {
+		v = strings.ReplaceAll(strings.TrimSpace(v), ",", "")
+		if v == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSubHeaderKeyword(row []string) bool {
+	for _, v := range row {
+		for _, k := range subHeaderKeywords {
+			if strings.Contains(v, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
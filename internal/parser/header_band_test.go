@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestDetectHeaderBand_SingleRowHeaderUnchanged(t *testing.T) {
+	rows := [][]string{
+		{"统一社会信用代码", "单位详细名称", "本月销售额"},
+		{"91440300A", "甲公司", "100"},
+	}
+	band := DetectHeaderBand(rows, nil)
+	if band.StartRow != 0 || band.EndRow != 0 || band.DataStart != 1 {
+		t.Fatalf("unexpected band: %+v", band)
+	}
+	if !reflect.DeepEqual(band.Headers, rows[0]) {
+		t.Fatalf("headers=%v", band.Headers)
+	}
+}
+
+func TestWRParser_TitleRowAndMergedTwoRowHeader(t *testing.T) {
+	f := excelize.NewFile()
+	t.Cleanup(func() { _ = f.Close() })
+	sheet := "2025年12月批发"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		t.Fatal(err)
+	}
+	cells := map[string]interface{}{
+		"A1": "2025年12月限上批发业企业销售情况",
+		"A2": "单位：千元",
+		"A3": "统一社会信用代码", "B3": "单位详细名称", "C3": "行业代码", "D3": "商品销售额", "F3": "零售额",
+		"D4": "本月", "E4": "上年同期", "F4": "本月", "G4": "上年同期",
+		"A5": "91440300MA5TEST01X", "B5": "甲批发公司", "C5": "5131", "D5": 1200, "E5": 1000, "F5": 300, "G5": 250,
+	}
+	for cell, v := range cells {
+		if err := f.SetCellValue(sheet, cell, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range [][2]string{{"A1", "G1"}, {"A3", "A4"}, {"B3", "B4"}, {"C3", "C4"}, {"D3", "E3"}, {"F3", "G3"}} {
+		if err := f.MergeCell(sheet, r[0], r[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, band, err := ReadSheet(f, sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start, end := band.ExcelRows(); start != 3 || end != 4 || band.DataStart != 4 {
+		t.Fatalf("header rows=%d-%d dataStart=%d", start, end, band.DataStart)
+	}
+	want := []string{"统一社会信用代码", "单位详细名称", "行业代码", "商品销售额-本月", "商品销售额-上年同期", "零售额-本月", "零售额-上年同期"}
+	if !reflect.DeepEqual(band.Headers, want) {
+		t.Fatalf("headers=%q", band.Headers)
+	}
+
+	records, err := NewWRParser(f).ParseSheet(sheet)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records=%d, want 1", len(records))
+	}
+	r := records[0]
+	if r.RowNo != 5 || r.Name != "甲批发公司" || r.DataYear != 2025 || r.DataMonth != 12 {
+		t.Fatalf("unexpected record: row=%d name=%q ym=%d-%d", r.RowNo, r.Name, r.DataYear, r.DataMonth)
+	}
+	if r.SalesCurrentMonth != 1200 || r.SalesLastYearMonth != 1000 || r.RetailCurrentMonth != 300 || r.RetailLastYearMonth != 250 {
+		t.Fatalf("unexpected values: %+v", r)
+	}
+}
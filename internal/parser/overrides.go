@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+
+	"northstar/internal/model"
+)
+
+// ParseOverrides 人工确认的解析参数（导入预览后由用户修正）
+type ParseOverrides struct {
+	// SheetType 非空时视为已确认的类型，跳过识别校验
+	SheetType SheetType
+	// Year/Month 非零时强制作为数据年月（同时用于列名时间口径推断）
+	Year  int
+	Month int
+	// Columns 列索引 → 数据库字段；空字符串表示忽略该列
+	Columns map[int]string
+}
+
+// IsMainSheetType 是否为批零/住餐主表类型
+func IsMainSheetType(t SheetType) bool {
+	switch t {
+	case SheetTypeWholesale, SheetTypeRetail, SheetTypeAccommodation, SheetTypeCatering:
+		return true
+	}
+	return false
+}
+
+// DetectSheetYearMonth 推断主表数据年月：优先列名，其次 Sheet 名
+func DetectSheetYearMonth(sheetName string, headers []string) (year, month int) {
+	year, month = FindCurrentYearMonth(headers)
+	if year == 0 || month == 0 {
+		if y, m, found := ExtractYearMonth(sheetName); found {
+			return y, m
+		}
+	}
+	return year, month
+}
+
+// MapColumns 按 Sheet 类型生成列映射（按列序排列，用户列别名优先）；非主表返回 nil
+func MapColumns(sheetType SheetType, headers []string, year, month int, aliases []model.ColumnAlias) []FieldMapping {
+	mapper := NewFieldMapper(year, month)
+	mapper.SetAliases(aliases)
+
+	var mappings map[int]FieldMapping
+	switch sheetType {
+	case SheetTypeWholesale, SheetTypeRetail:
+		mappings = mapper.MapWholesaleRetail(headers)
+	case SheetTypeAccommodation, SheetTypeCatering:
+		mappings = mapper.MapAccommodationCatering(headers)
+	default:
+		return nil
+	}
+
+	return sortedMappings(mappings)
+}
+
+// sortedMappings 列映射按列序排列
+func sortedMappings(mappings map[int]FieldMapping) []FieldMapping {
+	out := make([]FieldMapping, 0, len(mappings))
+	for _, m := range mappings {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ColumnIndex < out[j].ColumnIndex })
+	return out
+}
+
+// ValidateColumnOverrides 校验人工列映射：字段须属于 Sheet 类型对应表的可映射字段（与列别名相同）；
+// 类型未知时须属于任一主表
+func ValidateColumnOverrides(sheetType SheetType, columns map[int]string) error {
+	scopes := []string{AliasScopeWR, AliasScopeAC}
+	switch sheetType {
+	case SheetTypeWholesale, SheetTypeRetail:
+		scopes = []string{AliasScopeWR}
+	case SheetTypeAccommodation, SheetTypeCatering:
+		scopes = []string{AliasScopeAC}
+	}
+	for idx, field := range columns {
+		if idx < 0 {
+			return fmt.Errorf("列索引无效: %d", idx)
+		}
+		if field == "" {
+			continue
+		}
+		known := false
+		for _, scope := range scopes {
+			if containsString(aliasFields[scope], field) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("第 %d 列映射的字段未知: %s", idx+1, field)
+		}
+	}
+	return nil
+}
+
+// applyColumnOverrides 用人工指定的字段覆盖自动映射；字段不属于该表时返回错误，避免该列被静默丢弃
+func applyColumnOverrides(sheetType SheetType, mappings map[int]FieldMapping, headers []string, columns map[int]string) error {
+	if err := ValidateColumnOverrides(sheetType, columns); err != nil {
+		return err
+	}
+	for idx, field := range columns {
+		if field == "" {
+			delete(mappings, idx)
+			continue
+		}
+		name := ""
+		if idx < len(headers) {
+			name = NormalizeColumnName(headers[idx])
+		}
+		mappings[idx] = FieldMapping{ColumnIndex: idx, ColumnName: name, DBField: field}
+	}
+	return nil
+}
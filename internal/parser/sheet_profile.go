@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"northstar/internal/model"
+)
+
+var digitsPattern = regexp.MustCompile(`[0-9]+`)
+
+// HeaderSignature 表头签名：列名规范化后将数字替换为 #（年月变化不影响签名），忽略末尾空列
+func HeaderSignature(headers []string) string {
+	cols := make([]string, len(headers))
+	for i, h := range headers {
+		cols[i] = digitsPattern.ReplaceAllString(NormalizeColumnName(h), "#")
+	}
+	for len(cols) > 0 && cols[len(cols)-1] == "" {
+		cols = cols[:len(cols)-1]
+	}
+	if len(cols) == 0 {
+		return ""
+	}
+	sum := sha1.Sum([]byte(strings.Join(cols, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetProfiles 设置学习到的识别档案（命中时以置信度 1.0 直接返回档案中的类型）
+func (r *SheetRecognizer) SetProfiles(profiles []model.SheetProfile) {
+	r.profiles = make(map[string]model.SheetProfile, len(profiles))
+	for _, p := range profiles {
+		if p.Signature != "" {
+			r.profiles[p.Signature] = p
+		}
+	}
+}
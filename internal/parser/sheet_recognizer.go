@@ -2,10 +2,14 @@ package parser
 
 import (
 	"strings"
+
+	"northstar/internal/model"
 )
 
 // SheetRecognizer Sheet 类型识别器
-type SheetRecognizer struct{}
+type SheetRecognizer struct {
+	profiles map[string]model.SheetProfile // 表头签名 → 识别档案
+}
 
 // NewSheetRecognizer 创建识别器
 func NewSheetRecognizer() *SheetRecognizer {
@@ -23,6 +27,18 @@ func (r *SheetRecognizer) Recognize(sheetName string, columnNames []string) Shee
 	// 尝试从 Sheet 名提取年月
 	year, month, _ := ExtractYearMonth(sheetName)
 
+	// 学习到的识别档案优先（表头布局与人工确认过的 Sheet 相同）
+	if p, ok := r.profiles[HeaderSignature(columnNames)]; ok {
+		return SheetRecognitionResult{
+			SheetName:  sheetName,
+			SheetType:  SheetType(p.SheetType),
+			Confidence: 1,
+			DataYear:   year,
+			DataMonth:  month,
+			ProfileID:  p.ID,
+		}
+	}
+
 	// 快照表优先：一旦命中 “本年-本月/上年-本月” 口径，不允许被主表误判
 	if snap := r.recognizeSnapshot(sheetName, normalized); snap.Confidence >= 0.9 {
 		snap.DataYear = year
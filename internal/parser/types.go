@@ -36,6 +36,7 @@ type SheetRecognitionResult struct {
 	Confidence float64   `json:"confidence"` // 置信度 0-1
 	DataYear   int       `json:"dataYear"`   // 识别出的数据年份
 	DataMonth  int       `json:"dataMonth"`  // 识别出的数据月份
+	ProfileID  int64     `json:"profileId,omitempty"` // 命中的识别档案（按学习结果识别时）
 }
 
 // FieldMapping 字段映射结果
@@ -68,4 +69,7 @@ type ImportReport struct {
 	ErrorRows      int           `json:"errorRows"`
 	Duration       time.Duration `json:"duration"`
 	Sheets         []ParseResult `json:"sheets"`
+	RolledBack     bool          `json:"rolledBack"`               // 导入失败并整体回滚
+	RollbackReason string        `json:"rollbackReason,omitempty"` // 回滚原因
+	IssueCount     int           `json:"issueCount"`               // 行级问题数（GET /api/imports/:id/errors）
 }
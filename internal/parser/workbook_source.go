@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// oleMagic OLE2 复合文档文件头（Excel 97-2003 .xls）
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// sourceSheet 适配器读出的 Sheet：按行存放单元格值（nil 表示空单元格）
+type sourceSheet struct {
+	Name   string
+	Rows   [][]interface{}
+	Merges []mergeRange
+}
+
+// mergeRange 合并区域（行列从 0 开始，含首尾）
+type mergeRange struct {
+	FirstRow, LastRow, FirstCol, LastCol int
+}
+
+// OpenWorkbook 打开待导入的工作簿：.xlsx 直接读取；.xls（BIFF8）、CSV 以及多个 CSV 打包的 zip
+// 转换为内存中的工作簿，供识别器与解析器统一处理
+func OpenWorkbook(path string) (*excelize.File, error) {
+	head := make([]byte, len(oleMagic))
+	if f, err := os.Open(path); err == nil {
+		n, _ := io.ReadFull(f, head)
+		head = head[:n]
+		f.Close()
+	}
+
+	switch {
+	case bytes.Equal(head, oleMagic):
+		return openXLS(path)
+	case bytes.HasPrefix(head, []byte("PK")):
+		if sheets, ok, err := readCSVBundle(path); ok || err != nil {
+			if err != nil {
+				return nil, err
+			}
+			return buildWorkbook(sheets)
+		}
+	case isCSVName(path):
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sheet, err := readCSV(sheetNameFromFile(path), data)
+		if err != nil {
+			return nil, err
+		}
+		return buildWorkbook([]sourceSheet{sheet})
+	}
+	return excelize.OpenFile(path)
+}
+
+// BundleCSVFiles 将多个 CSV 文件（每个文件一个 Sheet）打包为 zip，供 OpenWorkbook 读取。
+// 不记录修改时间，相同内容的文件打包结果一致（便于重复上传检测）。
+func BundleCSVFiles(w io.Writer, names []string, open func(i int) (io.ReadCloser, error)) error {
+	zw := zip.NewWriter(w)
+	for i, name := range names {
+		if !isCSVName(name) {
+			return fmt.Errorf("多文件上传仅支持 CSV：%s", name)
+		}
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: filepath.Base(name), Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		rc, err := open(i)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entry, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// readCSVBundle 读取 CSV 打包文件；不是 CSV 打包（如 .xlsx）时 ok 为 false
+func readCSVBundle(path string) ([]sourceSheet, bool, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, false, nil
+	}
+	defer zr.Close()
+
+	var sheets []sourceSheet
+	for _, entry := range zr.File {
+		if entry.Name == "[Content_Types].xml" {
+			return nil, false, nil
+		}
+		if entry.FileInfo().IsDir() || !isCSVName(entry.Name) {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, true, fmt.Errorf("读取 %s 失败: %w", entry.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, true, fmt.Errorf("读取 %s 失败: %w", entry.Name, err)
+		}
+		sheet, err := readCSV(sheetNameFromFile(entry.Name), data)
+		if err != nil {
+			return nil, true, err
+		}
+		sheets = append(sheets, sheet)
+	}
+	if len(sheets) == 0 {
+		return nil, false, nil
+	}
+	return sheets, true, nil
+}
+
+// buildWorkbook 将适配器读出的 Sheet 写入内存工作簿（保留合并区域）
+func buildWorkbook(sheets []sourceSheet) (*excelize.File, error) {
+	f := excelize.NewFile()
+	used := map[string]bool{}
+	for i, sheet := range sheets {
+		name := uniqueSheetName(sanitizeSheetName(sheet.Name), used)
+		if i == 0 {
+			if err := f.SetSheetName(f.GetSheetName(0), name); err != nil {
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(name); err != nil {
+			return nil, err
+		}
+
+		for r, row := range sheet.Rows {
+			if len(row) == 0 {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, r+1)
+			values := row
+			if err := f.SetSheetRow(name, cell, &values); err != nil {
+				return nil, fmt.Errorf("failed to write sheet %s: %w", name, err)
+			}
+		}
+		for _, m := range sheet.Merges {
+			topLeft, _ := excelize.CoordinatesToCellName(m.FirstCol+1, m.FirstRow+1)
+			bottomRight, _ := excelize.CoordinatesToCellName(m.LastCol+1, m.LastRow+1)
+			if err := f.MergeCell(name, topLeft, bottomRight); err != nil {
+				return nil, fmt.Errorf("failed to merge cells in sheet %s: %w", name, err)
+			}
+		}
+	}
+	return f, nil
+}
+
+func isCSVName(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".csv" || ext == ".txt"
+}
+
+// sheetNameFromFile CSV 文件名（去掉扩展名）作为 Sheet 名
+func sheetNameFromFile(name string) string {
+	base := filepath.Base(name)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// sanitizeSheetName 去掉 Excel 不允许的字符并截断到 31 个字符
+func sanitizeSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.Trim(strings.TrimSpace(name), "'"))
+	if utf8.RuneCountInString(name) > excelize.MaxSheetNameLength {
+		name = string([]rune(name)[:excelize.MaxSheetNameLength])
+	}
+	if name == "" {
+		name = "Sheet1"
+	}
+	return name
+}
+
+func uniqueSheetName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf("(%d)", i)
+		runes := []rune(name)
+		if len(runes)+len(suffix) > excelize.MaxSheetNameLength {
+			runes = runes[:excelize.MaxSheetNameLength-len(suffix)]
+		}
+		candidate = string(runes) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// biffRec 编码一条 BIFF 记录
+func biffRec(id uint16, parts ...[]byte) []byte {
+	data := bytes.Join(parts, nil)
+	out := binary.LittleEndian.AppendUint16(nil, id)
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(data)))
+	return append(out, data...)
+}
+
+func u16(vs ...int) []byte {
+	var out []byte
+	for _, v := range vs {
+		out = binary.LittleEndian.AppendUint16(out, uint16(v))
+	}
+	return out
+}
+
+func u32(v uint32) []byte { return binary.LittleEndian.AppendUint32(nil, v) }
+
+func utf16le(s string) []byte {
+	var out []byte
+	for _, r := range s {
+		out = binary.LittleEndian.AppendUint16(out, uint16(r))
+	}
+	return out
+}
+
+func TestReadBIFF_CellsSharedStringsAndMerges(t *testing.T) {
+	// 工作表子流：标题行合并 A1:D1，两行表头（A2:A3、C2:D2 合并），一行数据
+	sheet := bytes.Join([][]byte{
+		biffRec(biffBOF, u16(biff8Version, 0x10), make([]byte, 12)),
+		biffRec(biffLabelSST, u16(0, 0, 0), u32(0)),
+		biffRec(biffLabelSST, u16(1, 0, 0), u32(1)),
+		biffRec(biffLabelSST, u16(1, 1, 0), u32(2)),
+		biffRec(biffLabelSST, u16(1, 2, 0), u32(3)),
+		biffRec(biffLabel, u16(2, 2, 0), u16(2), []byte{1}, utf16le("本月")),
+		biffRec(biffLabelSST, u16(2, 3, 0), u32(4)),
+		biffRec(biffFormula, u16(3, 0, 0), []byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}, make([]byte, 6)),
+		biffRec(biffString, u16(6), []byte{0}, []byte("91A001")),
+		biffRec(biffNumber, u16(3, 3, 0), u64f(1234.5)),
+		biffRec(biffMulRK, u16(3, 1), u16(0), u32(5131<<2|0x02), u16(0), u32(100000<<2|0x03), u16(2)),
+		biffRec(biffMergeCells, u16(3), u16(0, 0, 0, 3), u16(1, 2, 0, 0), u16(1, 1, 2, 3)),
+		biffRec(biffEOF),
+	}, nil)
+
+	// 共享字符串表：第 3 个字符串跨 CONTINUE 记录且压缩方式改变
+	sst := bytes.Join([][]byte{
+		u16(10), []byte{1}, utf16le("2025年12月批发"),
+		u16(4), []byte{1}, utf16le("信用代码"),
+		u16(4), []byte{1}, utf16le("行业代码"),
+		u16(3), []byte{1}, utf16le("销售"),
+	}, nil)
+	cont := bytes.Join([][]byte{
+		[]byte{0}, []byte("X"), // 剩余 1 个字符，压缩存储
+		u16(4), []byte{1}, utf16le("上年同期"),
+	}, nil)
+
+	globalsHead := biffRec(biffBOF, u16(biff8Version, 0x05), make([]byte, 12))
+	name := []byte("Sheet A")
+	boundLen := 4 + 8 + len(name)
+	sstRec := biffRec(biffSST, u32(5), u32(5), sst)
+	contRec := biffRec(biffContinue, cont)
+	offset := len(globalsHead) + boundLen + len(sstRec) + len(contRec) + 4
+	stream := bytes.Join([][]byte{
+		globalsHead,
+		biffRec(biffBoundSheet, u32(uint32(offset)), []byte{0, 0, byte(len(name)), 0}, name),
+		sstRec, contRec,
+		biffRec(biffEOF),
+		sheet,
+	}, nil)
+
+	sheets, err := readBIFF(stream)
+	if err != nil {
+		t.Fatalf("readBIFF: %v", err)
+	}
+	f, err := buildWorkbook(sheets)
+	if err != nil {
+		t.Fatalf("buildWorkbook: %v", err)
+	}
+	if got := f.GetSheetList(); !reflect.DeepEqual(got, []string{"Sheet A"}) {
+		t.Fatalf("sheets=%v", got)
+	}
+
+	rows, band, err := ReadSheet(f, "Sheet A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"信用代码", "行业代码", "销售X-本月", "销售X-上年同期"}
+	if band.DataStart != 3 || !reflect.DeepEqual(band.Headers, want) {
+		t.Fatalf("band=%+v", band)
+	}
+	if got := rows[3]; !reflect.DeepEqual(got, []string{"91A001", "5131", "1000", "1234.5"}) {
+		t.Fatalf("data row=%q", got)
+	}
+}
+
+func u64f(v float64) []byte { return binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)) }
+
+func TestOpenWorkbook_GBKCSVBundle(t *testing.T) {
+	gbk := func(s string) []byte {
+		b, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	files := map[string][]byte{
+		"2025年12月批发.csv": gbk("统一社会信用代码,单位详细名称,行业代码,本月销售额,上年同期销售额\r\n91440300MA5TEST01X,甲批发公司,5131,\"1,200\",1000\r\n"),
+		"说明.csv":         append([]byte{0xEF, 0xBB, 0xBF}, "项目\t说明\n口径\t限上\n"...),
+	}
+	names := []string{"2025年12月批发.csv", "说明.csv"}
+
+	path := filepath.Join(t.TempDir(), "upload.zip")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = BundleCSVFiles(out, names, func(i int) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(files[names[i]])), nil
+	})
+	out.Close()
+	if err != nil {
+		t.Fatalf("bundle: %v", err)
+	}
+
+	f, err := OpenWorkbook(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if got := f.GetSheetList(); !reflect.DeepEqual(got, []string{"2025年12月批发", "说明"}) {
+		t.Fatalf("sheets=%v", got)
+	}
+	if rows, _ := f.GetRows("说明"); !reflect.DeepEqual(rows, [][]string{{"项目", "说明"}, {"口径", "限上"}}) {
+		t.Fatalf("tab separated rows=%q", rows)
+	}
+
+	records, err := NewWRParser(f).ParseSheet("2025年12月批发")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(records) != 1 || records[0].Name != "甲批发公司" || records[0].SalesCurrentMonth != 1200 || records[0].SalesLastYearMonth != 1000 {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+}
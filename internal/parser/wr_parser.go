@@ -19,6 +19,10 @@ type WRParser struct {
 	currentMonth  int
 	fallbackYear  int
 	fallbackMonth int
+	overrides     ParseOverrides
+	diag          *rowDiagnostics
+	mappings      map[int]FieldMapping
+	aliases       []model.ColumnAlias
 }
 
 // NewWRParser 创建批零解析器
@@ -37,29 +41,58 @@ func NewWRParserWithFallback(file *excelize.File, fallbackYear, fallbackMonth in
 	return p
 }
 
+// SetOverrides 设置人工确认的解析参数
+func (p *WRParser) SetOverrides(o ParseOverrides) {
+	p.overrides = o
+}
+
+// SetAliases 设置用户列别名（优先于内置映射规则）
+func (p *WRParser) SetAliases(aliases []model.ColumnAlias) {
+	p.aliases = aliases
+}
+
+// Mappings 返回最近一次 ParseSheet 使用的列映射（按列序）
+func (p *WRParser) Mappings() []FieldMapping {
+	return sortedMappings(p.mappings)
+}
+
+// Issues 返回最近一次 ParseSheet 收集到的行级问题
+func (p *WRParser) Issues() []model.RowIssue {
+	if p.diag == nil {
+		return nil
+	}
+	return p.diag.issues
+}
+
 // ParseSheet 解析批零 Sheet
 func (p *WRParser) ParseSheet(sheetName string) ([]*model.WholesaleRetail, error) {
 	// 读取所有行
-	rows, err := p.file.GetRows(sheetName)
+	rows, band, err := ReadSheet(p.file, sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read sheet: %w", err)
 	}
 
-	if len(rows) < 2 {
+	if len(rows) <= band.DataStart {
 		return nil, fmt.Errorf("sheet has no data rows")
 	}
 
-	// 第一行是表头
-	headers := rows[0]
+	// 表头区域（可能有标题行、多行合并表头）
+	headers := band.Headers
 
 	// 识别 Sheet 类型
 	result := p.recognizer.Recognize(sheetName, headers)
+	if p.overrides.SheetType != "" {
+		result.SheetType = p.overrides.SheetType
+	}
 	if result.SheetType != SheetTypeWholesale && result.SheetType != SheetTypeRetail {
 		return nil, fmt.Errorf("not a wholesale/retail sheet")
 	}
 
 	// 从列名中识别当前年月
 	year, month := FindCurrentYearMonth(headers)
+	if p.overrides.Year > 0 && p.overrides.Month > 0 {
+		year, month = p.overrides.Year, p.overrides.Month
+	}
 	if year == 0 || month == 0 {
 		// 兜底：部分文件列头可能不带年份/月份，但 sheet 名包含 "YYYY年MM月"
 		if y, m, found := ExtractYearMonth(sheetName); found {
@@ -77,11 +110,17 @@ func (p *WRParser) ParseSheet(sheetName string) ([]*model.WholesaleRetail, error
 
 	// 创建字段映射器
 	mapper := NewFieldMapper(year, month)
+	mapper.SetAliases(p.aliases)
 	mappings := mapper.MapWholesaleRetail(headers)
+	if err := applyColumnOverrides(SheetTypeWholesale, mappings, headers, p.overrides.Columns); err != nil {
+		return nil, err
+	}
+	p.mappings = mappings
+	p.diag = newRowDiagnostics(sheetName, headers, mappings, "wholesale", "retail")
 
 	// 解析数据行
 	var records []*model.WholesaleRetail
-	for rowIdx := 1; rowIdx < len(rows); rowIdx++ {
+	for rowIdx := band.DataStart; rowIdx < len(rows); rowIdx++ {
 		row := rows[rowIdx]
 		record := p.parseWRRow(row, mappings, sheetName, rowIdx+1)
 		if record != nil {
@@ -117,6 +156,7 @@ func (p *WRParser) parseWRRow(row []string, mappings map[int]FieldMapping, sheet
 		if value == "" {
 			continue
 		}
+		p.diag.checkValue(rowNo, colIdx, mapping.DBField, value)
 
 		// 根据字段名设置值
 		p.setWRFieldValue(record, mapping.DBField, value)
@@ -126,6 +166,7 @@ func (p *WRParser) parseWRRow(row []string, mappings map[int]FieldMapping, sheet
 	if record.Name == "" {
 		return nil // 跳过无名称的行
 	}
+	p.diag.checkCompany(rowNo, record.CreditCode, record.Name, record.IndustryCode)
 
 	// 补齐行业类型（用于指标计算与过滤）
 	if record.IndustryType == "" && record.IndustryCode != "" {
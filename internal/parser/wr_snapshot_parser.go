@@ -20,11 +20,11 @@ func NewWRSnapshotParser(file *excelize.File) *WRSnapshotParser {
 
 // ParseSheet 解析批零快照 Sheet
 func (p *WRSnapshotParser) ParseSheet(sheetName string) ([]*model.WRSnapshot, error) {
-	rows, err := p.file.GetRows(sheetName)
+	rows, band, err := ReadSheet(p.file, sheetName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read sheet: %w", err)
 	}
-	if len(rows) < 2 {
+	if len(rows) <= band.DataStart {
 		return nil, fmt.Errorf("sheet has no data rows")
 	}
 
@@ -33,11 +33,11 @@ func (p *WRSnapshotParser) ParseSheet(sheetName string) ([]*model.WRSnapshot, er
 		return nil, fmt.Errorf("cannot determine snapshot year/month from sheet name")
 	}
 
-	headers := rows[0]
+	headers := band.Headers
 	index := buildSnapshotIndex(headers)
 
 	var records []*model.WRSnapshot
-	for rowIdx := 1; rowIdx < len(rows); rowIdx++ {
+	for rowIdx := band.DataStart; rowIdx < len(rows); rowIdx++ {
 		row := rows[rowIdx]
 		rec := &model.WRSnapshot{
 			SnapshotYear:  year,
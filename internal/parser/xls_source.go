@@ -0,0 +1,319 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+	"github.com/xuri/excelize/v2"
+)
+
+// BIFF8 记录类型
+const (
+	biffFormula    = 0x0006
+	biffEOF        = 0x000A
+	biffContinue   = 0x003C
+	biffBoundSheet = 0x0085
+	biffMulRK      = 0x00BD
+	biffMergeCells = 0x00E5
+	biffSST        = 0x00FC
+	biffLabelSST   = 0x00FD
+	biffNumber     = 0x0203
+	biffLabel      = 0x0204
+	biffBoolErr    = 0x0205
+	biffString     = 0x0207
+	biffRK         = 0x027E
+	biffBOF        = 0x0809
+
+	biff8Version = 0x0600
+)
+
+// openXLS 读取 Excel 97-2003（BIFF8）工作簿
+func openXLS(path string) (*excelize.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	doc, err := mscfb.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取 xls 文件: %w", err)
+	}
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		if entry.Name != "Workbook" && entry.Name != "Book" {
+			continue
+		}
+		stream, err := io.ReadAll(entry)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取 xls 工作簿流: %w", err)
+		}
+		sheets, err := readBIFF(stream)
+		if err != nil {
+			return nil, err
+		}
+		return buildWorkbook(sheets)
+	}
+	return nil, fmt.Errorf("xls 文件中没有工作簿数据（可能已加密或不是 Excel 文件）")
+}
+
+// biffRecord BIFF 记录
+type biffRecord struct {
+	id   uint16
+	data []byte
+}
+
+// biffRecords 从 offset 开始依次读取记录，直到 EOF 记录或流结束
+func biffRecords(stream []byte, offset int) []biffRecord {
+	var records []biffRecord
+	for offset+4 <= len(stream) {
+		id := binary.LittleEndian.Uint16(stream[offset:])
+		size := int(binary.LittleEndian.Uint16(stream[offset+2:]))
+		end := min(offset+4+size, len(stream))
+		records = append(records, biffRecord{id: id, data: stream[offset+4 : end]})
+		offset = end
+		if id == biffEOF {
+			break
+		}
+	}
+	return records
+}
+
+// readBIFF 解析 BIFF8 工作簿流：全局区（Sheet 列表、共享字符串表）及各工作表的单元格与合并区域
+func readBIFF(stream []byte) (sheets []sourceSheet, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			sheets, err = nil, fmt.Errorf("xls 文件格式错误: %v", r)
+		}
+	}()
+
+	globals := biffRecords(stream, 0)
+	if len(globals) == 0 || globals[0].id != biffBOF || len(globals[0].data) < 2 {
+		return nil, fmt.Errorf("不是有效的 xls 文件")
+	}
+	if v := binary.LittleEndian.Uint16(globals[0].data); v != biff8Version {
+		return nil, fmt.Errorf("仅支持 Excel 97-2003（BIFF8）格式的 xls 文件，请另存为 .xlsx 后导入")
+	}
+
+	type sheetEntry struct {
+		name   string
+		offset int
+	}
+	var entries []sheetEntry
+	var sst []string
+	for i, rec := range globals {
+		switch rec.id {
+		case biffBoundSheet:
+			// 仅处理普通工作表（排除图表、宏表）
+			if len(rec.data) >= 8 && rec.data[5] == 0 {
+				r := &biffStringReader{segs: [][]byte{rec.data[6:]}}
+				cch := int(r.byte())
+				entries = append(entries, sheetEntry{
+					name:   r.chars(cch, r.byte()&0x01 != 0),
+					offset: int(binary.LittleEndian.Uint32(rec.data)),
+				})
+			}
+		case biffSST:
+			segs := [][]byte{rec.data[8:]}
+			for _, next := range globals[i+1:] {
+				if next.id != biffContinue {
+					break
+				}
+				segs = append(segs, next.data)
+			}
+			sst = readSST(segs, int(binary.LittleEndian.Uint32(rec.data[4:])))
+		}
+	}
+
+	for _, entry := range entries {
+		sheet := sourceSheet{Name: entry.name}
+		readBIFFSheet(&sheet, biffRecords(stream, entry.offset), sst)
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}
+
+// readSST 解析共享字符串表（字符串可能跨 CONTINUE 记录）
+func readSST(segs [][]byte, count int) []string {
+	r := &biffStringReader{segs: segs}
+	sst := make([]string, 0, count)
+	for i := 0; i < count && !r.done(); i++ {
+		cch := int(r.uint16())
+		flags := r.byte()
+		runs, ext := 0, 0
+		if flags&0x08 != 0 {
+			runs = int(r.uint16())
+		}
+		if flags&0x04 != 0 {
+			ext = int(r.uint32())
+		}
+		sst = append(sst, r.chars(cch, flags&0x01 != 0))
+		r.skip(runs*4 + ext)
+	}
+	return sst
+}
+
+// readBIFFSheet 解析工作表子流中的单元格与合并区域
+func readBIFFSheet(sheet *sourceSheet, records []biffRecord, sst []string) {
+	set := func(row, col int, v interface{}) {
+		for len(sheet.Rows) <= row {
+			sheet.Rows = append(sheet.Rows, nil)
+		}
+		for len(sheet.Rows[row]) <= col {
+			sheet.Rows[row] = append(sheet.Rows[row], nil)
+		}
+		sheet.Rows[row][col] = v
+	}
+	cellPos := func(data []byte) (int, int) {
+		return int(binary.LittleEndian.Uint16(data)), int(binary.LittleEndian.Uint16(data[2:]))
+	}
+
+	pendingRow, pendingCol := -1, -1 // 字符串公式：结果在随后的 STRING 记录中
+	for _, rec := range records {
+		data := rec.data
+		switch rec.id {
+		case biffNumber:
+			row, col := cellPos(data)
+			set(row, col, math.Float64frombits(binary.LittleEndian.Uint64(data[6:])))
+		case biffRK:
+			row, col := cellPos(data)
+			set(row, col, decodeRK(binary.LittleEndian.Uint32(data[6:])))
+		case biffMulRK:
+			row, first := cellPos(data)
+			for i := 0; 4+i*6+6 <= len(data)-2; i++ {
+				set(row, first+i, decodeRK(binary.LittleEndian.Uint32(data[4+i*6+2:])))
+			}
+		case biffLabelSST:
+			row, col := cellPos(data)
+			if idx := int(binary.LittleEndian.Uint32(data[6:])); idx < len(sst) && sst[idx] != "" {
+				set(row, col, sst[idx])
+			}
+		case biffLabel:
+			row, col := cellPos(data)
+			r := &biffStringReader{segs: [][]byte{data[6:]}}
+			cch := int(r.uint16())
+			if s := r.chars(cch, r.byte()&0x01 != 0); s != "" {
+				set(row, col, s)
+			}
+		case biffBoolErr:
+			row, col := cellPos(data)
+			if data[7] == 0 {
+				set(row, col, data[6] != 0)
+			}
+		case biffFormula:
+			row, col := cellPos(data)
+			result := data[6:14]
+			if result[6] != 0xFF || result[7] != 0xFF {
+				set(row, col, math.Float64frombits(binary.LittleEndian.Uint64(result)))
+				continue
+			}
+			switch result[0] {
+			case 0:
+				pendingRow, pendingCol = row, col
+			case 1:
+				set(row, col, result[2] != 0)
+			}
+		case biffString:
+			if pendingRow >= 0 {
+				r := &biffStringReader{segs: [][]byte{data}}
+				cch := int(r.uint16())
+				if s := r.chars(cch, r.byte()&0x01 != 0); s != "" {
+					set(pendingRow, pendingCol, s)
+				}
+				pendingRow, pendingCol = -1, -1
+			}
+		case biffMergeCells:
+			n := int(binary.LittleEndian.Uint16(data))
+			for i := 0; i < n && 2+i*8+8 <= len(data); i++ {
+				ref := data[2+i*8:]
+				sheet.Merges = append(sheet.Merges, mergeRange{
+					FirstRow: int(binary.LittleEndian.Uint16(ref)),
+					LastRow:  int(binary.LittleEndian.Uint16(ref[2:])),
+					FirstCol: int(binary.LittleEndian.Uint16(ref[4:])),
+					LastCol:  int(binary.LittleEndian.Uint16(ref[6:])),
+				})
+			}
+		}
+	}
+}
+
+// decodeRK 解析 RK 压缩数值
+func decodeRK(rk uint32) float64 {
+	var v float64
+	if rk&0x02 != 0 {
+		v = float64(int32(rk) >> 2)
+	} else {
+		v = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		v /= 100
+	}
+	return v
+}
+
+// biffStringReader 顺序读取（可能跨 CONTINUE 记录的）字节；字符跨记录时新记录首字节为压缩标志
+type biffStringReader struct {
+	segs [][]byte
+	seg  int
+	pos  int
+}
+
+func (r *biffStringReader) done() bool {
+	for r.seg < len(r.segs) && r.pos >= len(r.segs[r.seg]) {
+		r.seg++
+		r.pos = 0
+	}
+	return r.seg >= len(r.segs)
+}
+
+func (r *biffStringReader) byte() byte {
+	if r.done() {
+		return 0
+	}
+	b := r.segs[r.seg][r.pos]
+	r.pos++
+	return b
+}
+
+func (r *biffStringReader) uint16() uint16 {
+	return uint16(r.byte()) | uint16(r.byte())<<8
+}
+
+func (r *biffStringReader) uint32() uint32 {
+	return uint32(r.uint16()) | uint32(r.uint16())<<16
+}
+
+func (r *biffStringReader) skip(n int) {
+	for ; n > 0 && !r.done(); n-- {
+		r.pos++
+	}
+}
+
+// chars 读取 cch 个字符：high 为 true 时每字符 2 字节（UTF-16LE），否则 1 字节
+func (r *biffStringReader) chars(cch int, high bool) string {
+	units := make([]uint16, 0, cch)
+	for len(units) < cch {
+		if r.seg < len(r.segs) && r.pos >= len(r.segs[r.seg]) {
+			// 字符串跨 CONTINUE 记录：新记录首字节重新给出压缩标志
+			r.seg++
+			r.pos = 0
+			if r.seg >= len(r.segs) {
+				break
+			}
+			high = r.byte()&0x01 != 0
+		}
+		if r.done() {
+			break
+		}
+		if high {
+			units = append(units, r.uint16())
+		} else {
+			units = append(units, uint16(r.byte()))
+		}
+	}
+	return string(utf16.Decode(units))
+}
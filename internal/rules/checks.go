@@ -0,0 +1,360 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"northstar/internal/model"
+)
+
+// checker 单次校验的状态
+type checker struct {
+	year       int
+	month      int
+	th         Thresholds
+	violations []Violation
+}
+
+func (c *checker) add(v Violation) {
+	c.violations = append(c.violations, v)
+}
+
+// checkWR 批零企业规则（R02/R03/R07/R08/R09）
+func (c *checker) checkWR(r *model.WholesaleRetail, legalRates map[string]float64) {
+	base := Violation{
+		CompanyID:    fmt.Sprintf("wr:%d", r.ID),
+		CompanyName:  r.Name,
+		CreditCode:   r.CreditCode,
+		IndustryType: r.IndustryType,
+	}
+	individual := IsIndividual(r.CreditCode)
+
+	ratio, hasRatio := ratioOf(r.RetailCurrentMonth, r.SalesCurrentMonth)
+	monthRate, hasMonthRate := rateOf(r.SalesCurrentMonth, r.SalesLastYearMonth)
+
+	switch r.IndustryType {
+	case "wholesale":
+		// R02 批发零销比
+		if hasRatio {
+			limit := c.th.WholesaleRetailRatioMax
+			if individual {
+				limit = c.th.IndividualRetailRatioMax
+			}
+			if ratio > limit {
+				v := base
+				v.RuleID, v.Severity, v.Field = RuleWholesaleRatio, SeveritySoft, "retail_ratio"
+				v.Value, v.Limit = ratio, limit
+				v.Message = fmt.Sprintf("批发零销比 %.2f%% 超过上限 %.0f%%", ratio, limit)
+				c.add(v)
+			}
+		}
+
+		// R08 批发销售额/零售额增速个位数不变，小数变化 ≤1%
+		c.checkIntegerDrift(base, RuleWholesaleRateDrift, "sales_month_rate", "销售额",
+			r.OriginalSalesCurrentMonth, r.SalesCurrentMonth, r.SalesLastYearMonth, true)
+		c.checkIntegerDrift(base, RuleWholesaleRateDrift, "retail_month_rate", "零售额",
+			r.OriginalRetailCurrentMonth, r.RetailCurrentMonth, r.RetailLastYearMonth, true)
+
+	case "retail":
+		// R03 零售零销比
+		if hasRatio {
+			if IsGasStation(r.Name) {
+				if IsTownship(r.Name) && math.Abs(ratio-c.th.GasStationRetailRatio) > c.th.GasStationRetailRatioBand {
+					v := base
+					v.RuleID, v.Severity, v.Field = RuleRetailRatio, SeveritySoft, "retail_ratio"
+					v.Value, v.Limit = ratio, c.th.GasStationRetailRatio
+					v.Message = fmt.Sprintf("乡镇加油站零销比 %.2f%% 偏离 %.0f%%", ratio, c.th.GasStationRetailRatio)
+					c.add(v)
+				}
+			} else if ratio < c.th.RetailRatioMin {
+				v := base
+				v.RuleID, v.Severity, v.Field = RuleRetailRatio, SeveritySoft, "retail_ratio"
+				v.Value, v.Limit = ratio, c.th.RetailRatioMin
+				v.Message = fmt.Sprintf("零售企业零销比 %.2f%% 低于 %.0f%%，销售额应与零售额基本一致", ratio, c.th.RetailRatioMin)
+				c.add(v)
+			}
+		}
+	}
+
+	// R07 大个体增速不超过法人增速与上限；乡镇超市当月零售额上限
+	if individual && hasMonthRate {
+		if monthRate > c.th.IndividualRateMax {
+			v := base
+			v.RuleID, v.Severity, v.Field = RuleIndividualLimit, SeverityHard, "sales_month_rate"
+			v.Value, v.Limit = monthRate, c.th.IndividualRateMax
+			v.Message = fmt.Sprintf("大个体当月增速 %.2f%% 超过 %.0f%%", monthRate, c.th.IndividualRateMax)
+			c.add(v)
+		}
+		if legal, ok := legalRates[r.IndustryType]; ok && monthRate > legal {
+			v := base
+			v.RuleID, v.Severity, v.Field = RuleIndividualLimit, SeverityHard, "sales_month_rate"
+			v.Value, v.Limit = monthRate, legal
+			v.Message = fmt.Sprintf("大个体当月增速 %.2f%% 超过同行业法人增速 %.2f%%", monthRate, legal)
+			c.add(v)
+		}
+	}
+	if IsTownshipSupermarket(r.Name) && r.RetailCurrentMonth > c.th.TownshipSupermarketMonthMax {
+		v := base
+		v.RuleID, v.Severity, v.Field = RuleIndividualLimit, SeverityHard, "retail_current_month"
+		v.Value, v.Limit = r.RetailCurrentMonth, c.th.TownshipSupermarketMonthMax
+		v.Message = fmt.Sprintf("乡镇超市当月零售额 %.0f 千元超过 %.0f 千元", r.RetailCurrentMonth, c.th.TownshipSupermarketMonthMax)
+		c.add(v)
+	}
+
+	// R09 新进企业同期数上限
+	if c.isNewCompany(r.OpeningYear, r.OpeningMonth) {
+		limit := c.th.NewRetailLastYearMax
+		if r.IndustryType == "wholesale" {
+			limit = c.th.NewWholesaleLastYearMax
+		}
+		if r.SalesLastYearCumulative > limit {
+			v := base
+			v.RuleID, v.Severity, v.Field = RuleNewCompanyCap, SeverityHard, "sales_last_year_cumulative"
+			v.Value, v.Limit = r.SalesLastYearCumulative, limit
+			v.Message = fmt.Sprintf("新进企业同期累计销售额 %.0f 千元超过 %.0f 千元", r.SalesLastYearCumulative, limit)
+			c.add(v)
+		}
+	}
+}
+
+// checkAC 住餐企业规则（R04/R05/R06/R09）
+func (c *checker) checkAC(r *model.AccommodationCatering) {
+	base := Violation{
+		CompanyID:    fmt.Sprintf("ac:%d", r.ID),
+		CompanyName:  r.Name,
+		CreditCode:   r.CreditCode,
+		IndustryType: r.IndustryType,
+	}
+	room, food := r.RoomCurrentMonth, r.FoodCurrentMonth
+
+	switch r.IndustryType {
+	case "accommodation":
+		// R04 有餐饮的住宿企业：客房 > 餐费
+		if food > 0 && room <= food {
+			v := base
+			v.RuleID, v.Severity, v.Field = RuleAccommodationMix, SeverityHard, "room_current_month"
+			v.Value, v.Limit = room, food
+			v.Message = fmt.Sprintf("住宿企业客房收入 %.0f 应大于餐费收入 %.0f", room, food)
+			c.add(v)
+		}
+	case "catering":
+		// R05 有住宿的餐饮企业：客房 < 餐费
+		if room > 0 && room >= food {
+			v := base
+			v.RuleID, v.Severity, v.Field = RuleCateringMix, SeverityHard, "food_current_month"
+			v.Value, v.Limit = food, room
+			v.Message = fmt.Sprintf("餐饮企业餐费收入 %.0f 应大于客房收入 %.0f", food, room)
+			c.add(v)
+		}
+	}
+
+	// R05 纯住餐企业（无商品销售且只有客房或餐费）：营业额 = 客房或餐费
+	if r.GoodsCurrentMonth == 0 && (room == 0) != (food == 0) {
+		expect := room + food
+		if math.Abs(r.RevenueCurrentMonth-expect) > 0.5 {
+			v := base
+			v.RuleID, v.Severity, v.Field = RuleCateringMix, SeverityHard, "revenue_current_month"
+			v.Value, v.Limit = r.RevenueCurrentMonth, expect
+			v.Message = fmt.Sprintf("纯住餐企业营业额 %.0f 应等于客房/餐费收入 %.0f", r.RevenueCurrentMonth, expect)
+			c.add(v)
+		}
+	}
+
+	// R06 住餐销售额/零售额增速个位数不变，小数变化 ≤1%
+	c.checkIntegerDrift(base, RuleACRateDrift, "revenue_month_rate", "营业额",
+		r.OriginalRevenueCurrentMonth, r.RevenueCurrentMonth, r.RevenueLastYearMonth, true)
+	if r.OriginalFoodCurrentMonth != nil && r.OriginalGoodsCurrentMonth != nil {
+		orig := *r.OriginalFoodCurrentMonth + *r.OriginalGoodsCurrentMonth
+		c.checkIntegerDrift(base, RuleACRateDrift, "retail_month_rate", "零售额",
+			&orig, food+r.GoodsCurrentMonth, r.FoodLastYearMonth+r.GoodsLastYearMonth, true)
+	}
+
+	// R09 新进企业同期数上限
+	if c.isNewCompany(r.OpeningYear, r.OpeningMonth) && r.RevenueLastYearCumulative > c.th.NewACLastYearMax {
+		v := base
+		v.RuleID, v.Severity, v.Field = RuleNewCompanyCap, SeverityHard, "revenue_last_year_cumulative"
+		v.Value, v.Limit = r.RevenueLastYearCumulative, c.th.NewACLastYearMax
+		v.Message = fmt.Sprintf("新进企业同期累计营业额 %.0f 千元超过 %.0f 千元", r.RevenueLastYearCumulative, c.th.NewACLastYearMax)
+		c.add(v)
+	}
+}
+
+// checkIntegerDrift 调整后增速个位数不变（checkDecimal 时小数点后一位的变化也不超过阈值）
+func (c *checker) checkIntegerDrift(base Violation, ruleID, field, label string, original *float64, current, lastYear float64, checkDecimal bool) {
+	if original == nil {
+		return
+	}
+	before, ok := rateOf(*original, lastYear)
+	if !ok {
+		return
+	}
+	after, _ := rateOf(current, lastYear)
+
+	if math.Trunc(before) != math.Trunc(after) {
+		v := base
+		v.RuleID, v.Severity, v.Field = ruleID, SeveritySoft, field
+		v.Value, v.Limit = after, before
+		v.Message = fmt.Sprintf("%s增速由 %.2f%% 调整为 %.2f%%，个位数发生变化", label, before, after)
+		c.add(v)
+		return
+	}
+	// 个位数相同时整体变化必然小于 1 个百分点，按小数点后一位比较
+	tenths := func(rate float64) float64 { return math.Trunc(math.Round(rate*100) / 10) }
+	if checkDecimal && math.Abs(tenths(after)-tenths(before)) > c.th.RateDriftMax {
+		v := base
+		v.RuleID, v.Severity, v.Field = ruleID, SeveritySoft, field
+		v.Value, v.Limit = after, before
+		v.Message = fmt.Sprintf("%s增速由 %.2f%% 调整为 %.2f%%，小数点后一位变化超过 %.0f", label, before, after, c.th.RateDriftMax)
+		c.add(v)
+	}
+}
+
+// checkIndustryAggregates R01 四大行业当月同比/环比增速
+func (c *checker) checkIndustryAggregates(wr []*model.WholesaleRetail, ac []*model.AccommodationCatering) {
+	type agg struct{ cur, lastYear, prev float64 }
+	sums := map[string]*agg{}
+	get := func(k string) *agg {
+		if sums[k] == nil {
+			sums[k] = &agg{}
+		}
+		return sums[k]
+	}
+	for _, r := range wr {
+		a := get(r.IndustryType)
+		a.cur += r.SalesCurrentMonth
+		a.lastYear += r.SalesLastYearMonth
+		a.prev += r.SalesPrevMonth
+	}
+	for _, r := range ac {
+		a := get(r.IndustryType)
+		a.cur += r.RevenueCurrentMonth
+		a.lastYear += r.RevenueLastYearMonth
+		a.prev += r.RevenuePrevMonth
+	}
+
+	names := map[string]string{"wholesale": "批发业", "retail": "零售业", "accommodation": "住宿业", "catering": "餐饮业"}
+	band := c.th.IndustryRateBand
+	for _, industry := range []string{"wholesale", "retail", "accommodation", "catering"} {
+		a, ok := sums[industry]
+		if !ok {
+			continue
+		}
+		prefix := "sales"
+		if industry == "accommodation" || industry == "catering" {
+			prefix = "revenue"
+		}
+		if rate, ok := rateOf(a.cur, a.lastYear); ok && math.Abs(rate) > band {
+			c.add(Violation{
+				RuleID: RuleIndustryRateBand, Severity: SeverityHard, IndustryType: industry,
+				Field: prefix + "_month_rate", Value: rate, Limit: band,
+				Message: fmt.Sprintf("%s当月同比增速 %.2f%% 超出 ±%.0f%%", names[industry], rate, band),
+			})
+		}
+		if rate, ok := rateOf(a.cur, a.prev); ok && math.Abs(rate) > band {
+			c.add(Violation{
+				RuleID: RuleIndustryRateBand, Severity: SeverityHard, IndustryType: industry,
+				Field: prefix + "_mom_rate", Value: rate, Limit: band,
+				Message: fmt.Sprintf("%s当月环比增速 %.2f%% 超出 ±%.0f%%", names[industry], rate, band),
+			})
+		}
+	}
+}
+
+// checkSpecialRates R10 小微与吃穿用增速靠近 30%
+func (c *checker) checkSpecialRates(wr []*model.WholesaleRetail) {
+	var ewCur, ewLast, smCur, smLast float64
+	for _, r := range wr {
+		if r.IsEatWearUse == 1 {
+			ewCur += r.RetailCurrentMonth
+			ewLast += r.RetailLastYearMonth
+		}
+		if r.IsSmallMicro == 1 {
+			smCur += r.RetailCurrentMonth
+			smLast += r.RetailLastYearMonth
+		}
+	}
+
+	target, tol := c.th.SpecialRateTarget, c.th.SpecialRateTolerance
+	check := func(field, label string, cur, last float64) {
+		rate, ok := rateOf(cur, last)
+		if !ok {
+			return
+		}
+		if rate > target || rate < target-tol {
+			c.add(Violation{
+				RuleID: RuleSpecialRateTarget, Severity: SeveritySoft,
+				Field: field, Value: rate, Limit: target,
+				Message: fmt.Sprintf("%s当月增速 %.2f%% 未靠近目标 %.0f%%", label, rate, target),
+			})
+		}
+	}
+	check("eatWearUse_month_rate", "吃穿用", ewCur, ewLast)
+	check("microSmall_month_rate", "小微", smCur, smLast)
+}
+
+// isNewCompany 开业未满 12 个月视为新进企业
+func (c *checker) isNewCompany(openingYear, openingMonth *int) bool {
+	if openingYear == nil || *openingYear <= 0 {
+		return false
+	}
+	if openingMonth == nil || *openingMonth <= 0 {
+		return *openingYear >= c.year
+	}
+	diff := (c.year*12 + c.month) - (*openingYear*12 + *openingMonth)
+	return diff >= 0 && diff < 12
+}
+
+// legalPersonSalesRates 各行业法人（非大个体）当月销售额增速
+func legalPersonSalesRates(wr []*model.WholesaleRetail) map[string]float64 {
+	cur := map[string]float64{}
+	last := map[string]float64{}
+	for _, r := range wr {
+		if IsIndividual(r.CreditCode) {
+			continue
+		}
+		cur[r.IndustryType] += r.SalesCurrentMonth
+		last[r.IndustryType] += r.SalesLastYearMonth
+	}
+	out := map[string]float64{}
+	for k := range cur {
+		if rate, ok := rateOf(cur[k], last[k]); ok {
+			out[k] = rate
+		}
+	}
+	return out
+}
+
+// IsIndividual 大个体（个体工商户统一社会信用代码以 92 开头）
+func IsIndividual(creditCode string) bool {
+	return strings.HasPrefix(strings.TrimSpace(creditCode), "92")
+}
+
+// IsTownship 名称包含乡/镇
+func IsTownship(name string) bool {
+	return strings.Contains(name, "乡") || strings.Contains(name, "镇")
+}
+
+// IsGasStation 加油站
+func IsGasStation(name string) bool {
+	return strings.Contains(name, "加油站")
+}
+
+// IsTownshipSupermarket 乡镇超市
+func IsTownshipSupermarket(name string) bool {
+	return strings.Contains(name, "超市") && IsTownship(name)
+}
+
+func rateOf(cur, last float64) (float64, bool) {
+	if last == 0 {
+		return 0, false
+	}
+	return (cur - last) / last * 100, true
+}
+
+func ratioOf(retail, sales float64) (float64, bool) {
+	if sales <= 0 {
+		return 0, false
+	}
+	return retail / sales * 100, true
+}
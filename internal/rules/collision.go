@@ -0,0 +1,397 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"northstar/internal/model"
+)
+
+// 参与不雷同检测的字段
+const (
+	CollisionSalesMonthRate  = "sales_month_rate"
+	CollisionRetailMonthRate = "retail_month_rate"
+	CollisionRetailRatio     = "retail_ratio"
+)
+
+// CollisionFields 按去雷同处理顺序排列的检测字段
+var CollisionFields = []string{CollisionSalesMonthRate, CollisionRetailMonthRate, CollisionRetailRatio}
+
+// CollisionMember 雷同分组成员
+type CollisionMember struct {
+	CompanyID string  `json:"companyId"`
+	Name      string  `json:"name"`
+	Value     float64 `json:"value"`
+}
+
+// CollisionBand 增速不雷同区间（百分点）：同行业两家企业的增速相差不超过该值即视为雷同。
+// 零销比只按保留两位小数相同判定（prd/01 §4）。
+const CollisionBand = 1.0
+
+// CollisionGroup 同一行业内同一字段雷同的企业分组：按值排序后相邻企业雷同的归为一组
+type CollisionGroup struct {
+	IndustryType string            `json:"industryType"`
+	Field        string            `json:"field"`
+	Value        float64           `json:"value"` // 组内最小值
+	Members      []CollisionMember `json:"members"`
+}
+
+// Nudge 去雷同微调（整数调整本月金额）
+type Nudge struct {
+	CompanyID string  `json:"companyId"`
+	ID        int64   `json:"-"`
+	Field     string  `json:"field"`
+	Before    float64 `json:"before"`
+	After     float64 `json:"after"`
+}
+
+// DedupResult 去雷同结果
+type DedupResult struct {
+	Nudges     []Nudge          `json:"nudges"`
+	Remaining  []CollisionGroup `json:"remaining"`
+	Unresolved []string         `json:"unresolved"` // 无法在保持行业合计不变的前提下处理的 行业/字段
+}
+
+// DefaultDedupMaxStep 单家企业最大整数调整幅度（千元）
+const DefaultDedupMaxStep = 50
+
+// wrWorking 去雷同过程中的可变副本
+type wrWorking struct {
+	rec    *model.WholesaleRetail
+	sales  float64
+	retail float64
+}
+
+func (w *wrWorking) companyID() string {
+	return fmt.Sprintf("wr:%d", w.rec.ID)
+}
+
+// collisionKey 字段值（保留两位小数）；不可计算时返回 false
+func (w *wrWorking) collisionKey(field string) (float64, bool) {
+	var v float64
+	var ok bool
+	switch field {
+	case CollisionSalesMonthRate:
+		v, ok = rateOf(w.sales, w.rec.SalesLastYearMonth)
+	case CollisionRetailMonthRate:
+		v, ok = rateOf(w.retail, w.rec.RetailLastYearMonth)
+	case CollisionRetailRatio:
+		// 无零售额的纯批发企业零销比均为 0，不视为雷同
+		if w.retail == 0 {
+			return 0, false
+		}
+		v, ok = ratioOf(w.retail, w.sales)
+	}
+	if !ok {
+		return 0, false
+	}
+	return math.Round(v*100) / 100, true
+}
+
+// collisionBand 字段的雷同区间：增速为 CollisionBand，零销比为 0（两位小数相同）
+func collisionBand(field string) float64 {
+	if field == CollisionRetailRatio {
+		return 0
+	}
+	return CollisionBand
+}
+
+// withinBand 两个检测值是否雷同（保留两位小数相同或相差不超过字段的雷同区间）
+func withinBand(field string, a, b float64) bool {
+	return math.Abs(a-b) <= collisionBand(field)+1e-9
+}
+
+// amountField 去雷同时调整的金额字段
+func amountField(field string) string {
+	if field == CollisionSalesMonthRate {
+		return "sales_current_month"
+	}
+	return "retail_current_month"
+}
+
+func (w *wrWorking) amount(field string) float64 {
+	if amountField(field) == "sales_current_month" {
+		return w.sales
+	}
+	return w.retail
+}
+
+// setAmount 写入调整后的金额，违反 0 ≤ 零售额 ≤ 销售额 时拒绝
+func (w *wrWorking) setAmount(field string, v float64) bool {
+	if v < 0 {
+		return false
+	}
+	if amountField(field) == "sales_current_month" {
+		if v < w.retail {
+			return false
+		}
+		w.sales = v
+		return true
+	}
+	if v > w.sales {
+		return false
+	}
+	w.retail = v
+	return true
+}
+
+// restore 恢复金额（不做约束校验）
+func (w *wrWorking) restore(field string, v float64) {
+	if amountField(field) == "sales_current_month" {
+		w.sales = v
+	} else {
+		w.retail = v
+	}
+}
+
+// DetectCollisions 检测批零企业增速/零销比雷同
+func DetectCollisions(wr []*model.WholesaleRetail) []CollisionGroup {
+	return detectCollisions(newWorkingSet(wr))
+}
+
+func newWorkingSet(wr []*model.WholesaleRetail) []*wrWorking {
+	out := make([]*wrWorking, 0, len(wr))
+	for _, r := range wr {
+		out = append(out, &wrWorking{rec: r, sales: r.SalesCurrentMonth, retail: r.RetailCurrentMonth})
+	}
+	return out
+}
+
+func detectCollisions(rows []*wrWorking) []CollisionGroup {
+	type bucketKey struct {
+		industry string
+		field    string
+	}
+	type point struct {
+		w *wrWorking
+		v float64
+	}
+	buckets := map[bucketKey][]point{}
+	for _, w := range rows {
+		for _, field := range CollisionFields {
+			if v, ok := w.collisionKey(field); ok {
+				k := bucketKey{w.rec.IndustryType, field}
+				buckets[k] = append(buckets[k], point{w, v})
+			}
+		}
+	}
+
+	var out []CollisionGroup
+	for k, points := range buckets {
+		sort.Slice(points, func(i, j int) bool {
+			if points[i].v != points[j].v {
+				return points[i].v < points[j].v
+			}
+			return points[i].w.rec.ID < points[j].w.rec.ID
+		})
+		// 相邻差距不超过区间的企业连成一组
+		for start := 0; start < len(points); {
+			end := start + 1
+			for end < len(points) && withinBand(k.field, points[end-1].v, points[end].v) {
+				end++
+			}
+			if end-start >= 2 {
+				g := CollisionGroup{IndustryType: k.industry, Field: k.field, Value: points[start].v}
+				for _, p := range points[start:end] {
+					g.Members = append(g.Members, CollisionMember{CompanyID: p.w.companyID(), Name: p.w.rec.Name, Value: p.v})
+				}
+				sort.Slice(g.Members, func(i, j int) bool { return g.Members[i].CompanyID < g.Members[j].CompanyID })
+				out = append(out, g)
+			}
+			start = end
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].IndustryType != out[j].IndustryType {
+			return out[i].IndustryType < out[j].IndustryType
+		}
+		if out[i].Field != out[j].Field {
+			return out[i].Field < out[j].Field
+		}
+		return out[i].Value < out[j].Value
+	})
+	if out == nil {
+		out = []CollisionGroup{}
+	}
+	return out
+}
+
+// PlanDedup 计算去雷同微调方案：对雷同分组中除首家外的企业按最小整数幅度调整本月金额，
+// 调整量由同行业金额最大的未调整企业对冲，保证行业合计不变。
+func PlanDedup(wr []*model.WholesaleRetail, maxStep int) *DedupResult {
+	if maxStep <= 0 {
+		maxStep = DefaultDedupMaxStep
+	}
+	rows := newWorkingSet(wr)
+
+	byIndustry := map[string][]*wrWorking{}
+	var industries []string
+	for _, w := range rows {
+		if _, ok := byIndustry[w.rec.IndustryType]; !ok {
+			industries = append(industries, w.rec.IndustryType)
+		}
+		byIndustry[w.rec.IndustryType] = append(byIndustry[w.rec.IndustryType], w)
+	}
+	sort.Strings(industries)
+
+	result := &DedupResult{Unresolved: []string{}}
+	for _, field := range CollisionFields {
+		for _, industry := range industries {
+			if !dedupIndustryField(byIndustry[industry], field, maxStep) {
+				result.Unresolved = append(result.Unresolved, industry+"/"+field)
+			}
+		}
+	}
+
+	for _, w := range rows {
+		if w.sales != w.rec.SalesCurrentMonth {
+			result.Nudges = append(result.Nudges, Nudge{CompanyID: w.companyID(), ID: w.rec.ID, Field: "sales_current_month", Before: w.rec.SalesCurrentMonth, After: w.sales})
+		}
+		if w.retail != w.rec.RetailCurrentMonth {
+			result.Nudges = append(result.Nudges, Nudge{CompanyID: w.companyID(), ID: w.rec.ID, Field: "retail_current_month", Before: w.rec.RetailCurrentMonth, After: w.retail})
+		}
+	}
+	if result.Nudges == nil {
+		result.Nudges = []Nudge{}
+	}
+	result.Remaining = detectCollisions(rows)
+	return result
+}
+
+// dedupIndustryField 处理单个行业单个字段；无法对冲时回滚该字段的调整并返回 false
+func dedupIndustryField(rows []*wrWorking, field string, maxStep int) bool {
+	band := collisionBand(field)
+	keys := map[*wrWorking]float64{}
+	all := &keyIndex{}
+	for _, w := range rows {
+		if k, ok := w.collisionKey(field); ok {
+			keys[w] = k
+			all.insert(w, k)
+		}
+	}
+	// collides 值 v 是否与 self 以外任一企业雷同
+	collides := func(self *wrWorking, v float64) bool {
+		return all.near(self, v, band)
+	}
+
+	ordered := make([]*wrWorking, 0, len(keys))
+	for w := range keys {
+		ordered = append(ordered, w)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].rec.ID < ordered[j].rec.ID })
+
+	before := map[*wrWorking]float64{}
+	touched := map[*wrWorking]bool{}
+	var balance float64
+
+	// 按 ID 依次处理：与已处理企业雷同的企业微调到不与任何企业雷同的位置
+	settled := &keyIndex{}
+	for _, w := range ordered {
+		conflict := settled.near(w, keys[w], band)
+		if !conflict {
+			settled.insert(w, keys[w])
+			continue
+		}
+		orig := w.amount(field)
+		for step := 1; step <= maxStep; step++ {
+			moved := false
+			for _, sign := range []float64{1, -1} {
+				if !w.setAmount(field, orig+sign*float64(step)) {
+					continue
+				}
+				nk, ok := w.collisionKey(field)
+				if ok && !collides(w, nk) {
+					all.remove(w, keys[w])
+					all.insert(w, nk)
+					keys[w] = nk
+					before[w] = orig
+					touched[w] = true
+					balance += sign * float64(step)
+					moved = true
+					break
+				}
+				w.restore(field, orig)
+			}
+			if moved {
+				break
+			}
+		}
+		settled.insert(w, keys[w])
+	}
+
+	if balance == 0 {
+		return true
+	}
+
+	// 对冲：按金额从大到小选取未调整企业
+	candidates := make([]*wrWorking, 0, len(rows))
+	for _, w := range rows {
+		if !touched[w] {
+			candidates = append(candidates, w)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].amount(field) > candidates[j].amount(field) })
+	for _, w := range candidates {
+		orig := w.amount(field)
+		if !w.setAmount(field, orig-balance) {
+			continue
+		}
+		nk, ok := w.collisionKey(field)
+		if !ok || !collides(w, nk) {
+			return true
+		}
+		w.restore(field, orig)
+	}
+
+	for w, v := range before {
+		w.restore(field, v)
+	}
+	return false
+}
+
+// keyIndex 按 (检测值, ID) 排序的企业检测值，雷同检查只需二分查找最近的企业
+type keyIndex struct {
+	entries []keyEntry
+}
+
+type keyEntry struct {
+	v float64
+	w *wrWorking
+}
+
+func (x *keyIndex) search(v float64, id int64) int {
+	return sort.Search(len(x.entries), func(i int) bool {
+		e := x.entries[i]
+		return e.v > v || (e.v == v && e.w.rec.ID >= id)
+	})
+}
+
+func (x *keyIndex) insert(w *wrWorking, v float64) {
+	i := x.search(v, w.rec.ID)
+	x.entries = append(x.entries, keyEntry{})
+	copy(x.entries[i+1:], x.entries[i:])
+	x.entries[i] = keyEntry{v, w}
+}
+
+func (x *keyIndex) remove(w *wrWorking, v float64) {
+	for i := x.search(v, w.rec.ID); i < len(x.entries) && x.entries[i].v == v; i++ {
+		if x.entries[i].w == w {
+			x.entries = append(x.entries[:i], x.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// near 是否存在 self 以外的企业与 v 相差不超过 band（与 withinBand 口径一致）；
+// 从区间下界起至多检查两个相邻企业
+func (x *keyIndex) near(self *wrWorking, v, band float64) bool {
+	lo, hi := v-band-1e-9, v+band+1e-9
+	i := sort.Search(len(x.entries), func(i int) bool { return x.entries[i].v >= lo })
+	for ; i < len(x.entries) && x.entries[i].v <= hi; i++ {
+		if x.entries[i].w != self {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package rules
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"northstar/internal/model"
+)
+
+func TestPlanDedup_BreaksTiesAndKeepsIndustryTotal(t *testing.T) {
+	wr := []*model.WholesaleRetail{
+		{ID: 1, Name: "批发A", IndustryType: "wholesale", SalesCurrentMonth: 110, SalesLastYearMonth: 100},
+		{ID: 2, Name: "批发B", IndustryType: "wholesale", SalesCurrentMonth: 220, SalesLastYearMonth: 200},
+		{ID: 3, Name: "批发C", IndustryType: "wholesale", SalesCurrentMonth: 330, SalesLastYearMonth: 300},
+		{ID: 4, Name: "批发D", IndustryType: "wholesale", SalesCurrentMonth: 10000, SalesLastYearMonth: 9000},
+		{ID: 5, Name: "零售E", IndustryType: "retail", SalesCurrentMonth: 110, SalesLastYearMonth: 100},
+	}
+
+	groups := DetectCollisions(wr)
+	if len(groups) != 1 || groups[0].Field != CollisionSalesMonthRate || len(groups[0].Members) != 3 {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+
+	var before float64
+	for _, r := range wr {
+		if r.IndustryType == "wholesale" {
+			before += r.SalesCurrentMonth
+		}
+	}
+
+	result := PlanDedup(wr, 0)
+	if len(result.Remaining) != 0 || len(result.Unresolved) != 0 {
+		t.Fatalf("expected all collisions resolved, remaining=%+v unresolved=%v", result.Remaining, result.Unresolved)
+	}
+
+	after := before
+	for _, n := range result.Nudges {
+		if n.Field != "sales_current_month" {
+			t.Fatalf("unexpected nudge field: %+v", n)
+		}
+		if n.After != float64(int64(n.After)) {
+			t.Fatalf("nudge must be integer: %+v", n)
+		}
+		if n.CompanyID == "wr:5" {
+			t.Fatalf("retail company must not be nudged")
+		}
+		after += n.After - n.Before
+	}
+	if after != before {
+		t.Fatalf("industry total changed: before=%v after=%v", before, after)
+	}
+	if wr[1].SalesCurrentMonth != 220 {
+		t.Fatalf("PlanDedup must not mutate input records")
+	}
+}
+
+func TestDetectCollisions_WithinBand(t *testing.T) {
+	wr := []*model.WholesaleRetail{
+		{ID: 1, Name: "批发A", IndustryType: "wholesale", SalesCurrentMonth: 11234, SalesLastYearMonth: 10000},
+		{ID: 2, Name: "批发B", IndustryType: "wholesale", SalesCurrentMonth: 11280, SalesLastYearMonth: 10000},
+		{ID: 3, Name: "批发C", IndustryType: "wholesale", SalesCurrentMonth: 11500, SalesLastYearMonth: 10000},
+		{ID: 4, Name: "批发D", IndustryType: "wholesale", SalesCurrentMonth: 13000, SalesLastYearMonth: 10000},
+	}
+
+	// 12.34% 与 12.80% 不相等但相差不足 1 个百分点；15.00% 与 12.80% 相差 2.2 个百分点不雷同
+	groups := DetectCollisions(wr)
+	if len(groups) != 1 || len(groups[0].Members) != 2 || groups[0].Value != 12.34 {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if groups[0].Members[0].CompanyID != "wr:1" || groups[0].Members[1].CompanyID != "wr:2" ||
+		groups[0].Members[1].Value != 12.8 {
+		t.Fatalf("unexpected members: %+v", groups[0].Members)
+	}
+
+	// 相邻差距均在区间内的企业连成一组
+	wr[2].SalesCurrentMonth = 11380
+	groups = DetectCollisions(wr)
+	if len(groups) != 1 || len(groups[0].Members) != 3 {
+		t.Fatalf("chained rates should form one group: %+v", groups)
+	}
+}
+
+func TestDetectCollisions_RetailRatioUsesTwoDecimals(t *testing.T) {
+	wr := []*model.WholesaleRetail{
+		{ID: 1, Name: "批发A", IndustryType: "wholesale", SalesCurrentMonth: 1000, RetailCurrentMonth: 351},
+		{ID: 2, Name: "批发B", IndustryType: "wholesale", SalesCurrentMonth: 1000, RetailCurrentMonth: 359},
+		{ID: 3, Name: "批发C", IndustryType: "wholesale", SalesCurrentMonth: 2000, RetailCurrentMonth: 718},
+	}
+
+	// 零销比 35.10% 与 35.90% 不雷同；35.90% 与 35.90% 雷同
+	groups := DetectCollisions(wr)
+	if len(groups) != 1 || groups[0].Field != CollisionRetailRatio || groups[0].Value != 35.9 || len(groups[0].Members) != 2 {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if groups[0].Members[0].CompanyID != "wr:2" || groups[0].Members[1].CompanyID != "wr:3" {
+		t.Fatalf("unexpected members: %+v", groups[0].Members)
+	}
+}
+
+func TestPlanDedup_SeparatesRatesWithinBand(t *testing.T) {
+	wr := []*model.WholesaleRetail{
+		{ID: 1, Name: "批发A", IndustryType: "wholesale", SalesCurrentMonth: 1123, SalesLastYearMonth: 1000},
+		{ID: 2, Name: "批发B", IndustryType: "wholesale", SalesCurrentMonth: 1128, SalesLastYearMonth: 1000},
+		{ID: 3, Name: "批发C", IndustryType: "wholesale", SalesCurrentMonth: 5000, SalesLastYearMonth: 4000},
+	}
+
+	result := PlanDedup(wr, 0)
+	if len(result.Remaining) != 0 || len(result.Unresolved) != 0 {
+		t.Fatalf("expected near rates separated, remaining=%+v unresolved=%v", result.Remaining, result.Unresolved)
+	}
+
+	sales := map[string]float64{"wr:1": 1123, "wr:2": 1128, "wr:3": 5000}
+	var delta float64
+	for _, n := range result.Nudges {
+		sales[n.CompanyID] = n.After
+		delta += n.After - n.Before
+	}
+	if delta != 0 {
+		t.Fatalf("industry total changed by %v", delta)
+	}
+	rateA := (sales["wr:1"] - 1000) / 1000 * 100
+	rateB := (sales["wr:2"] - 1000) / 1000 * 100
+	if math.Abs(rateA-rateB) <= CollisionBand {
+		t.Fatalf("rates still within band: %.2f%% vs %.2f%%", rateA, rateB)
+	}
+}
+
+func BenchmarkPlanDedup_20kCompanies(b *testing.B) {
+	rng := rand.New(rand.NewSource(42))
+	wr := make([]*model.WholesaleRetail, 0, 20000)
+	for i := 0; i < 20000; i++ {
+		last := math.Round(1000 + rng.Float64()*99000)
+		cur := math.Round(last * (1.05 + rng.Float64()*0.1))
+		wr = append(wr, &model.WholesaleRetail{
+			ID: int64(i + 1), Name: "批发", IndustryType: []string{"wholesale", "retail"}[i%2],
+			SalesCurrentMonth: cur, SalesLastYearMonth: last,
+			RetailCurrentMonth: math.Round(cur * 0.3), RetailLastYearMonth: math.Round(last * 0.3),
+		})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PlanDedup(wr, 0)
+	}
+}
@@ -0,0 +1,223 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+// Severity 违规等级
+type Severity string
+
+const (
+	SeverityHard Severity = "hard" // 硬性约束：必须满足
+	SeveritySoft Severity = "soft" // 软性约束：尽量满足
+)
+
+// 规则编号（对应 prd/01 §4 二期复杂业务规则）
+const (
+	RuleIndustryRateBand   = "R01" // 四大行业当月同比/环比增速在 ±30% 内
+	RuleWholesaleRatio     = "R02" // 批发零销比上限
+	RuleRetailRatio        = "R03" // 零售零销比与大个体增速
+	RuleAccommodationMix   = "R04" // 住宿：客房 > 餐费
+	RuleCateringMix        = "R05" // 餐饮：客房 < 餐费；纯住餐营业额一致
+	RuleACRateDrift        = "R06" // 住餐增速个位数不变，小数变化 ≤1%
+	RuleIndividualLimit    = "R07" // 大个体/乡镇超市限制
+	RuleWholesaleRateDrift = "R08" // 批发增速个位数不变，小数变化 ≤1%
+	RuleNewCompanyCap      = "R09" // 新进企业同期数上限
+	RuleSpecialRateTarget  = "R10" // 小微与吃穿用靠近 30%
+)
+
+// Violation 单条规则违规
+type Violation struct {
+	RuleID       string   `json:"ruleId"`
+	Severity     Severity `json:"severity"`
+	CompanyID    string   `json:"companyId,omitempty"` // wr:<id> / ac:<id>；行业级规则为空
+	CompanyName  string   `json:"companyName,omitempty"`
+	CreditCode   string   `json:"creditCode,omitempty"`
+	IndustryType string   `json:"industryType,omitempty"`
+	Field        string   `json:"field"`
+	Value        float64  `json:"value"`
+	Limit        float64  `json:"limit"`
+	Message      string   `json:"message"`
+}
+
+// Summary 校验汇总
+type Summary struct {
+	Total     int `json:"total"`
+	Hard      int `json:"hard"`
+	Soft      int `json:"soft"`
+	Companies int `json:"companies"` // 存在违规的企业数
+}
+
+// Report 校验结果
+type Report struct {
+	Year       int         `json:"year"`
+	Month      int         `json:"month"`
+	Summary    Summary     `json:"summary"`
+	Violations []Violation `json:"violations"`
+}
+
+// HasHard 是否存在硬性违规
+func (r *Report) HasHard() bool {
+	return r != nil && r.Summary.Hard > 0
+}
+
+// ByCompany 按企业分组违规（行业级违规不包含在内）
+func (r *Report) ByCompany() map[string][]Violation {
+	out := map[string][]Violation{}
+	if r == nil {
+		return out
+	}
+	for _, v := range r.Violations {
+		if v.CompanyID == "" {
+			continue
+		}
+		out[v.CompanyID] = append(out[v.CompanyID], v)
+	}
+	return out
+}
+
+// Filter 按等级过滤，severity 为空时返回全部
+func (r *Report) Filter(severity Severity) []Violation {
+	if r == nil {
+		return nil
+	}
+	if severity == "" {
+		return r.Violations
+	}
+	out := make([]Violation, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		if v.Severity == severity {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Thresholds 规则阈值（金额单位：千元，比例/增速单位：%）
+type Thresholds struct {
+	IndustryRateBand float64 // 四大行业增速区间 ±N%
+
+	WholesaleRetailRatioMax  float64 // 批发零销比上限
+	IndividualRetailRatioMax float64 // 大个体零销比上限
+
+	GasStationRetailRatio     float64 // 乡镇加油站零销比目标值
+	GasStationRetailRatioBand float64 // 乡镇加油站零销比允许偏差
+	RetailRatioMin            float64 // 其他零售企业零销比下限（销售额≈零售额）
+	IndividualRateMax         float64 // 大个体当月增速上限
+
+	RateDriftMax float64 // 增速小数点后一位变化上限
+
+	TownshipSupermarketMonthMax float64 // 乡镇超市当月零售额上限
+
+	NewWholesaleLastYearMax float64 // 新进批发企业同期数上限
+	NewRetailLastYearMax    float64 // 新进零售企业同期数上限
+	NewACLastYearMax        float64 // 新进住餐企业同期数上限
+
+	SpecialRateTarget    float64 // 小微/吃穿用目标增速
+	SpecialRateTolerance float64 // 小微/吃穿用低于目标的允许偏差
+}
+
+// DefaultThresholds 默认阈值（prd/01 §4）
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		IndustryRateBand: 30,
+
+		WholesaleRetailRatioMax:  40,
+		IndividualRetailRatioMax: 30,
+
+		GasStationRetailRatio:     50,
+		GasStationRetailRatioBand: 10,
+		RetailRatioMin:            90,
+		IndividualRateMax:         20,
+
+		RateDriftMax: 1,
+
+		TownshipSupermarketMonthMax: 1000,
+
+		NewWholesaleLastYearMax: 20000,
+		NewRetailLastYearMax:    5000,
+		NewACLastYearMax:        2000,
+
+		SpecialRateTarget:    30,
+		SpecialRateTolerance: 5,
+	}
+}
+
+// Engine 业务规则引擎
+type Engine struct {
+	store      *store.Store
+	thresholds Thresholds
+}
+
+// NewEngine 创建规则引擎（使用默认阈值）
+func NewEngine(st *store.Store) *Engine {
+	return &Engine{store: st, thresholds: DefaultThresholds()}
+}
+
+// WithThresholds 替换阈值
+func (e *Engine) WithThresholds(th Thresholds) *Engine {
+	e.thresholds = th
+	return e
+}
+
+// Evaluate 校验指定月份已入库的企业数据
+func (e *Engine) Evaluate(year, month int) (*Report, error) {
+	wr, err := e.store.GetWRByYearMonth(store.WRQueryOptions{DataYear: &year, DataMonth: &month})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load wholesale_retail: %w", err)
+	}
+	ac, err := e.store.GetACByYearMonth(store.ACQueryOptions{DataYear: &year, DataMonth: &month})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accommodation_catering: %w", err)
+	}
+	return EvaluateRecords(year, month, wr, ac, e.thresholds), nil
+}
+
+// EvaluateRecords 校验内存中的企业记录（供导入、优化、导出复用）
+func EvaluateRecords(year, month int, wr []*model.WholesaleRetail, ac []*model.AccommodationCatering, th Thresholds) *Report {
+	c := &checker{year: year, month: month, th: th}
+
+	legalRates := legalPersonSalesRates(wr)
+	for _, r := range wr {
+		c.checkWR(r, legalRates)
+	}
+	for _, r := range ac {
+		c.checkAC(r)
+	}
+	c.checkIndustryAggregates(wr, ac)
+	c.checkSpecialRates(wr)
+
+	sort.SliceStable(c.violations, func(i, j int) bool {
+		a, b := c.violations[i], c.violations[j]
+		if a.Severity != b.Severity {
+			return a.Severity == SeverityHard
+		}
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		return a.CompanyID < b.CompanyID
+	})
+
+	report := &Report{Year: year, Month: month, Violations: c.violations}
+	if report.Violations == nil {
+		report.Violations = []Violation{}
+	}
+	companies := map[string]bool{}
+	for _, v := range report.Violations {
+		report.Summary.Total++
+		if v.Severity == SeverityHard {
+			report.Summary.Hard++
+		} else {
+			report.Summary.Soft++
+		}
+		if v.CompanyID != "" {
+			companies[v.CompanyID] = true
+		}
+	}
+	report.Summary.Companies = len(companies)
+	return report
+}
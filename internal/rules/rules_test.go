@@ -0,0 +1,132 @@
+package rules
+
+import (
+	"path/filepath"
+	"testing"
+
+	"northstar/internal/model"
+	"northstar/internal/store"
+)
+
+func hasViolation(r *Report, ruleID, companyID, field string) bool {
+	for _, v := range r.Violations {
+		if v.RuleID == ruleID && v.CompanyID == companyID && v.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEvaluateRecords_CompanyRules(t *testing.T) {
+	openYear, openMonth := 2025, 6
+	orig := 130.0
+	origDecimal, origSmall := 112.1, 112.1
+
+	wr := []*model.WholesaleRetail{
+		// 批发零销比 50% > 40%；增速由 30% 调为 10%
+		{ID: 1, Name: "批发A", CreditCode: "91A", IndustryType: "wholesale",
+			SalesCurrentMonth: 110, SalesLastYearMonth: 100, SalesPrevMonth: 105,
+			RetailCurrentMonth: 55, RetailLastYearMonth: 50,
+			OriginalSalesCurrentMonth: &orig},
+		// 大个体：增速 50% 超过 20% 与法人增速
+		{ID: 2, Name: "某某镇超市", CreditCode: "92B", IndustryType: "retail",
+			SalesCurrentMonth: 1500, SalesLastYearMonth: 1000, SalesPrevMonth: 1400,
+			RetailCurrentMonth: 1500, RetailLastYearMonth: 1000},
+		// 零售法人，零销比 100%
+		{ID: 3, Name: "零售C", CreditCode: "91C", IndustryType: "retail",
+			SalesCurrentMonth: 10000, SalesLastYearMonth: 10000, SalesPrevMonth: 10000,
+			RetailCurrentMonth: 10000, RetailLastYearMonth: 10000},
+		// 新进零售企业同期数超 500 万
+		{ID: 4, Name: "零售D", CreditCode: "91D", IndustryType: "retail",
+			SalesCurrentMonth: 100, SalesLastYearMonth: 100, SalesPrevMonth: 100,
+			RetailCurrentMonth: 100, RetailLastYearMonth: 100,
+			SalesLastYearCumulative: 6000, OpeningYear: &openYear, OpeningMonth: &openMonth},
+		// 批发增速由 12.1% 调为 12.9%：个位数不变，小数点后一位变化 8
+		{ID: 5, Name: "批发G", CreditCode: "91G", IndustryType: "wholesale",
+			SalesCurrentMonth: 112.9, SalesLastYearMonth: 100, SalesPrevMonth: 110,
+			OriginalSalesCurrentMonth: &origDecimal},
+		// 批发增速由 12.1% 调为 12.2%：在允许范围内
+		{ID: 6, Name: "批发H", CreditCode: "91H", IndustryType: "wholesale",
+			SalesCurrentMonth: 112.2, SalesLastYearMonth: 100, SalesPrevMonth: 110,
+			OriginalSalesCurrentMonth: &origSmall},
+	}
+	ac := []*model.AccommodationCatering{
+		// 住宿：客房 < 餐费
+		{ID: 1, Name: "酒店E", CreditCode: "91E", IndustryType: "accommodation",
+			RevenueCurrentMonth: 300, RevenueLastYearMonth: 300, RevenuePrevMonth: 300,
+			RoomCurrentMonth: 100, FoodCurrentMonth: 200},
+		// 纯餐饮：营业额应等于餐费
+		{ID: 2, Name: "餐馆F", CreditCode: "91F", IndustryType: "catering",
+			RevenueCurrentMonth: 120, RevenueLastYearMonth: 100, RevenuePrevMonth: 110,
+			FoodCurrentMonth: 100},
+	}
+
+	report := EvaluateRecords(2025, 12, wr, ac, DefaultThresholds())
+
+	cases := []struct {
+		rule, company, field string
+	}{
+		{RuleWholesaleRatio, "wr:1", "retail_ratio"},
+		{RuleWholesaleRateDrift, "wr:1", "sales_month_rate"},
+		{RuleWholesaleRateDrift, "wr:5", "sales_month_rate"},
+		{RuleIndividualLimit, "wr:2", "sales_month_rate"},
+		{RuleIndividualLimit, "wr:2", "retail_current_month"},
+		{RuleNewCompanyCap, "wr:4", "sales_last_year_cumulative"},
+		{RuleAccommodationMix, "ac:1", "room_current_month"},
+		{RuleCateringMix, "ac:2", "revenue_current_month"},
+	}
+	for _, tc := range cases {
+		if !hasViolation(report, tc.rule, tc.company, tc.field) {
+			t.Errorf("missing violation %s %s %s", tc.rule, tc.company, tc.field)
+		}
+	}
+	if hasViolation(report, RuleRetailRatio, "wr:3", "retail_ratio") {
+		t.Errorf("unexpected retail ratio violation for wr:3")
+	}
+	if hasViolation(report, RuleWholesaleRateDrift, "wr:6", "sales_month_rate") {
+		t.Errorf("unexpected rate drift violation for wr:6")
+	}
+	if got := len(report.ByCompany()["wr:3"]); got != 0 {
+		t.Errorf("expected wr:3 clean, got %d violations", got)
+	}
+	if report.Summary.Total != len(report.Violations) || report.Summary.Hard+report.Summary.Soft != report.Summary.Total {
+		t.Fatalf("inconsistent summary: %+v", report.Summary)
+	}
+	if len(report.Violations) > 0 && report.Violations[0].Severity != SeverityHard {
+		t.Fatalf("hard violations should be listed first")
+	}
+}
+
+func TestEngine_Evaluate_IndustryBand(t *testing.T) {
+	st, err := store.New(filepath.Join(t.TempDir(), "northstar.db"))
+	if err != nil {
+		t.Fatalf("init store: %v", err)
+	}
+	t.Cleanup(func() { _ = st.Close() })
+
+	if err := st.Exec(`
+		INSERT INTO wholesale_retail (
+			credit_code, name, industry_code, industry_type, company_scale, row_no,
+			data_year, data_month,
+			sales_prev_month, sales_current_month, sales_last_year_month,
+			retail_current_month, retail_last_year_month,
+			source_sheet, source_file
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, "91X", "批发X", "5101", "wholesale", 1, 1, 2025, 12, 100, 150, 100, 10, 10, "批发", "test.xlsx"); err != nil {
+		t.Fatalf("insert wr: %v", err)
+	}
+
+	report, err := NewEngine(st).Evaluate(2025, 12)
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	if !hasViolation(report, RuleIndustryRateBand, "", "sales_month_rate") {
+		t.Fatalf("expected 同比 band violation, got %+v", report.Violations)
+	}
+	if !hasViolation(report, RuleIndustryRateBand, "", "sales_mom_rate") {
+		t.Fatalf("expected 环比 band violation, got %+v", report.Violations)
+	}
+	if !report.HasHard() {
+		t.Fatalf("expected hard violations")
+	}
+}
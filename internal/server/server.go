@@ -1,15 +1,18 @@
 package server
 
 import (
+	"context"
 	"embed"
 	"io/fs"
 	"log"
 	"net/http"
 	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"northstar/internal/api/v3"
+	"northstar/internal/backup"
 	"northstar/internal/config"
 	"northstar/internal/store"
 )
@@ -22,6 +25,10 @@ type Server struct {
 	router *gin.Engine
 	store  *store.Store
 	v3     *v3.Handler
+
+	// stopBackups 停止定时备份；backupsDone 在定时备份协程退出后关闭
+	stopBackups context.CancelFunc
+	backupsDone chan struct{}
 }
 
 // NewServer 创建服务器
@@ -45,12 +52,26 @@ func NewServer(cfg *config.AppConfig) *Server {
 
 	// 创建 V3 API 处理器
 	v3Handler := v3.NewHandler(sqliteStore, cfg.Excel.TemplatePath)
+	v3Handler.SetBusinessConfig(cfg.Business)
+	v3Handler.SetKeepImportFiles(cfg.Data.KeepImportFiles)
+
+	// 数据库快照：导入/调整前自动备份，并按间隔定时备份
+	backups := backup.NewManager(sqliteStore, filepath.Join(dataDir, "backups"), cfg.Data.BackupKeep, cfg.Data.AutoBackup)
+	v3Handler.SetBackupManager(backups)
 
 	s := &Server{
 		router: gin.Default(),
 		store:  sqliteStore,
 		v3:     v3Handler,
 	}
+	if cfg.Data.AutoBackup && cfg.Data.BackupIntervalMinutes > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopBackups, s.backupsDone = cancel, make(chan struct{})
+		go func() {
+			defer close(s.backupsDone)
+			backups.Run(ctx, time.Duration(cfg.Data.BackupIntervalMinutes)*time.Minute)
+		}()
+	}
 
 	s.setupRoutes(devMode)
 
@@ -129,6 +150,15 @@ func (s *Server) SaveNow() error {
 	return nil
 }
 
+// Close 停止定时备份（等待进行中的快照完成）后关闭数据库
+func (s *Server) Close() error {
+	if s.stopBackups != nil {
+		s.stopBackups()
+		<-s.backupsDone
+	}
+	return s.store.Close()
+}
+
 // GetStore 获取存储（用于测试）
 func (s *Server) GetStore() *store.Store {
 	return s.store
@@ -12,7 +12,7 @@ func (s *Store) BatchInsertACSnapshot(records []*model.ACSnapshot) error {
 		return nil
 	}
 
-	tx, err := s.db.Begin()
+	tx, err := s.BeginTx()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -65,7 +65,7 @@ func (s *Store) BatchInsertACSnapshot(records []*model.ACSnapshot) error {
 
 // DeleteACSnapshotByYearMonth 删除指定年月的住餐快照数据
 func (s *Store) DeleteACSnapshotByYearMonth(year, month int) error {
-	_, err := s.db.Exec("DELETE FROM ac_snapshot WHERE snapshot_year = ? AND snapshot_month = ?", year, month)
+	_, err := s.conn().Exec("DELETE FROM ac_snapshot WHERE snapshot_year = ? AND snapshot_month = ?", year, month)
 	if err != nil {
 		return fmt.Errorf("failed to delete: %w", err)
 	}
@@ -14,7 +14,7 @@ func (s *Store) BatchInsertAC(records []*model.AccommodationCatering) error {
 		return nil
 	}
 
-	tx, err := s.db.Begin()
+	tx, err := s.BeginTx()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -149,7 +149,7 @@ func (s *Store) GetACByYearMonth(opts ACQueryOptions) ([]*model.AccommodationCat
 		}
 	}
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.conn().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query: %w", err)
 	}
@@ -176,7 +176,7 @@ func (s *Store) UpdateAC(id int64, updates map[string]interface{}) error {
 	query := fmt.Sprintf("UPDATE accommodation_catering SET %s WHERE id = ?",
 		strings.Join(setClauses, ", "))
 
-	_, err := s.db.Exec(query, args...)
+	_, err := s.conn().Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update: %w", err)
 	}
@@ -186,7 +186,7 @@ func (s *Store) UpdateAC(id int64, updates map[string]interface{}) error {
 
 // DeleteACByYearMonth 删除指定年月的住餐企业数据
 func (s *Store) DeleteACByYearMonth(year, month int) error {
-	_, err := s.db.Exec("DELETE FROM accommodation_catering WHERE data_year = ? AND data_month = ?",
+	_, err := s.conn().Exec("DELETE FROM accommodation_catering WHERE data_year = ? AND data_month = ?",
 		year, month)
 	if err != nil {
 		return fmt.Errorf("failed to delete: %w", err)
@@ -221,7 +221,7 @@ func (s *Store) CountAC(opts ACQueryOptions) (int, error) {
 	}
 
 	var count int
-	err := s.db.QueryRow(query, args...).Scan(&count)
+	err := s.conn().QueryRow(query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count: %w", err)
 	}
@@ -231,7 +231,7 @@ func (s *Store) CountAC(opts ACQueryOptions) (int, error) {
 
 // GetACByID 根据 ID 获取住餐企业
 func (s *Store) GetACByID(id int64) (*model.AccommodationCatering, error) {
-	row := s.db.QueryRow("SELECT * FROM accommodation_catering WHERE id = ?", id)
+	row := s.conn().QueryRow("SELECT * FROM accommodation_catering WHERE id = ?", id)
 	return s.scanACRow(row)
 }
 
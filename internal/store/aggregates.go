@@ -0,0 +1,54 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SumByIndustry 一次分组查询计算某月企业表按行业类型汇总的多个求和表达式。
+// exprs 为基于本表字段的 SQL 表达式（由调用方从固定字段名构造，不接受用户输入）；
+// 返回 行业类型 → 与 exprs 一一对应的合计。
+func (s *Store) SumByIndustry(table string, year, month int, exprs []string) (map[string][]float64, error) {
+	if table != "wholesale_retail" && table != "accommodation_catering" {
+		return nil, fmt.Errorf("unsupported aggregate table: %s", table)
+	}
+	out := map[string][]float64{}
+	if len(exprs) == 0 {
+		return out, nil
+	}
+
+	totals := make([]string, len(exprs))
+	for i, expr := range exprs {
+		totals[i] = "TOTAL(" + expr + ")"
+	}
+	rows, err := s.conn().Query(fmt.Sprintf(`
+		SELECT COALESCE(industry_type, ''), %s
+		FROM %s
+		WHERE data_year = ? AND data_month = ?
+		GROUP BY industry_type
+	`, strings.Join(totals, ", "), table), year, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var industryType string
+		sums := make([]float64, len(exprs))
+		dest := []interface{}{&industryType}
+		for i := range sums {
+			dest = append(dest, &sums[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s aggregate: %w", table, err)
+		}
+		if prev, ok := out[industryType]; ok {
+			// NULL 与空字符串归入同一组
+			for i := range sums {
+				sums[i] += prev[i]
+			}
+		}
+		out[industryType] = sums
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"modernc.org/sqlite"
+)
+
+// BackupTo 以 VACUUM INTO 生成当前数据库的一致性快照（目标文件不能已存在）
+func (s *Store) BackupTo(path string) error {
+	if s.tx != nil {
+		return fmt.Errorf("cannot back up inside a transaction")
+	}
+	if _, err := s.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// RestoreFrom 用快照文件在线覆盖当前数据库内容（SQLite 在线备份 API），
+// 完成后补跑迁移，已打开的方案 Store 全部关闭以免引用旧数据。
+func (s *Store) RestoreFrom(path string) error {
+	if s.tx != nil {
+		return fmt.Errorf("cannot restore inside a transaction")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup file not found: %w", err)
+	}
+
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	err = conn.Raw(func(dc interface{}) error {
+		r, ok := dc.(interface {
+			NewRestore(srcURI string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return fmt.Errorf("driver does not support online restore")
+		}
+		bk, err := r.NewRestore(path)
+		if err != nil {
+			return err
+		}
+		if _, err := bk.Step(-1); err != nil {
+			_ = bk.Finish()
+			return err
+		}
+		return bk.Finish()
+	})
+	conn.Close()
+	if err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+
+	if s.scenarios != nil {
+		s.scenarios.closeAll()
+	}
+	return s.migrate()
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"northstar/internal/model"
+)
+
+// ErrColumnAliasNotFound 列别名不存在
+var ErrColumnAliasNotFound = errors.New("column alias not found")
+
+const columnAliasColumns = "id, pattern, match_type, scope, db_field, time_type, COALESCE(note, ''), created_at, updated_at"
+
+func scanColumnAlias(scan func(dest ...interface{}) error) (*model.ColumnAlias, error) {
+	var a model.ColumnAlias
+	if err := scan(&a.ID, &a.Pattern, &a.MatchType, &a.Scope, &a.DBField, &a.TimeType, &a.Note, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListColumnAliases 列出全部列别名（按创建顺序）
+func (s *Store) ListColumnAliases() ([]model.ColumnAlias, error) {
+	rows, err := s.conn().Query("SELECT " + columnAliasColumns + " FROM column_aliases ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list column aliases: %w", err)
+	}
+	defer rows.Close()
+
+	out := []model.ColumnAlias{}
+	for rows.Next() {
+		a, err := scanColumnAlias(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan column alias: %w", err)
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+// GetColumnAlias 获取列别名
+func (s *Store) GetColumnAlias(id int64) (*model.ColumnAlias, error) {
+	a, err := scanColumnAlias(s.conn().QueryRow("SELECT "+columnAliasColumns+" FROM column_aliases WHERE id = ?", id).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrColumnAliasNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column alias: %w", err)
+	}
+	return a, nil
+}
+
+// CreateColumnAlias 新增列别名
+func (s *Store) CreateColumnAlias(a model.ColumnAlias) (*model.ColumnAlias, error) {
+	res, err := s.conn().Exec(`
+		INSERT INTO column_aliases (pattern, match_type, scope, db_field, time_type, note)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, a.Pattern, a.MatchType, a.Scope, a.DBField, a.TimeType, a.Note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create column alias: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column alias id: %w", err)
+	}
+	return s.GetColumnAlias(id)
+}
+
+// UpdateColumnAlias 更新列别名
+func (s *Store) UpdateColumnAlias(id int64, a model.ColumnAlias) (*model.ColumnAlias, error) {
+	res, err := s.conn().Exec(`
+		UPDATE column_aliases SET
+			pattern = ?, match_type = ?, scope = ?, db_field = ?, time_type = ?, note = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, a.Pattern, a.MatchType, a.Scope, a.DBField, a.TimeType, a.Note, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update column alias: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrColumnAliasNotFound
+	}
+	return s.GetColumnAlias(id)
+}
+
+// DeleteColumnAlias 删除列别名
+func (s *Store) DeleteColumnAlias(id int64) error {
+	res, err := s.conn().Exec("DELETE FROM column_aliases WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete column alias: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrColumnAliasNotFound
+	}
+	return nil
+}
@@ -9,7 +9,7 @@ import (
 // GetConfig 获取配置项
 func (s *Store) GetConfig(key string) (string, error) {
 	var value string
-	err := s.db.QueryRow("SELECT value FROM config WHERE key = ?", key).Scan(&value)
+	err := s.conn().QueryRow("SELECT value FROM config WHERE key = ?", key).Scan(&value)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", fmt.Errorf("config key not found: %s", key)
@@ -39,7 +39,7 @@ func (s *Store) GetConfigFloat(key string) (float64, error) {
 
 // SetConfig 设置配置项
 func (s *Store) SetConfig(key, value string) error {
-	_, err := s.db.Exec(`
+	_, err := s.conn().Exec(`
 		INSERT INTO config (key, value) VALUES (?, ?)
 		ON CONFLICT(key) DO UPDATE SET value = ?, updated_at = CURRENT_TIMESTAMP
 	`, key, value, value)
@@ -58,7 +58,7 @@ func (s *Store) SetConfigFloat(key string, value float64) error {
 
 // GetAllConfig 获取所有配置项
 func (s *Store) GetAllConfig() (map[string]string, error) {
-	rows, err := s.db.Query("SELECT key, value FROM config")
+	rows, err := s.conn().Query("SELECT key, value FROM config")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,534 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 修改来源
+const (
+	ChangeSourceManual   = "manual"
+	ChangeSourceOptimize = "optimize"
+	ChangeSourceReset    = "reset"
+	ChangeSourceDedup    = "dedup"
+	ChangeSourceImport   = "import"
+	ChangeSourcePromote  = "promote"
+	ChangeSourceUndo     = "undo"
+	ChangeSourceRedo     = "redo"
+)
+
+// 批次状态
+const (
+	BatchApplied   = "applied"
+	BatchUndone    = "undone"
+	BatchDiscarded = "discarded" // 撤销后又有新修改，不可再重做
+)
+
+var (
+	// ErrNothingToUndo 没有可撤销的批次
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo 没有可重做的批次
+	ErrNothingToRedo = errors.New("nothing to redo")
+	// ErrImportNotUndoable 导入批次替换了整月数据，无法按字段撤销
+	ErrImportNotUndoable = errors.New("import batches cannot be undone")
+)
+
+// trackedColumns 记录修改历史的可编辑字段（衍生增速由重算得到，不单独记录）
+var trackedColumns = map[string][]string{
+	"wholesale_retail": {
+		"sales_current_month", "sales_last_year_month", "sales_current_cumulative", "sales_last_year_cumulative",
+		"retail_current_month", "retail_last_year_month", "retail_current_cumulative", "retail_last_year_cumulative",
+		"is_small_micro", "is_eat_wear_use",
+	},
+	"accommodation_catering": {
+		"revenue_current_month", "revenue_last_year_month", "revenue_current_cumulative", "revenue_last_year_cumulative",
+		"room_current_month", "food_current_month", "goods_current_month",
+		"food_last_year_month", "goods_last_year_month",
+		"retail_current_month", "retail_last_year_month",
+		"is_small_micro", "is_eat_wear_use",
+	},
+}
+
+var trackedTables = []string{"wholesale_retail", "accommodation_catering"}
+
+// ChangeMeta 修改批次的来源信息
+type ChangeMeta struct {
+	Source string
+	Note   string
+	Actor  string
+}
+
+// ChangeBatch 一次操作产生的修改批次
+type ChangeBatch struct {
+	ID            int64     `json:"id"`
+	Source        string    `json:"source"`
+	Note          string    `json:"note"`
+	Actor         string    `json:"actor"`
+	DataYear      int       `json:"dataYear"`
+	DataMonth     int       `json:"dataMonth"`
+	Status        string    `json:"status"`
+	TargetBatchID *int64    `json:"targetBatchId,omitempty"`
+	ChangeCount   int       `json:"changeCount"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// ChangeEntry 单个字段的修改记录
+type ChangeEntry struct {
+	ID        int64     `json:"id"`
+	BatchID   int64     `json:"batchId"`
+	CompanyID string    `json:"companyId"`
+	Field     string    `json:"field"`
+	OldValue  *float64  `json:"oldValue"`
+	NewValue  *float64  `json:"newValue"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	table string
+	rowID int64
+}
+
+// cellKey 表/行/字段
+type cellKey struct {
+	table string
+	rowID int64
+	field string
+}
+
+type cellSnapshot map[cellKey]sql.NullFloat64
+
+// Track 在事务内执行 fn，并将 year/month 内可编辑字段的变化记录为一个批次；无变化时不记录，返回 nil 批次。
+// 前后各扫描整月企业，用于智能调整、重置、去重等批量修改；单企业修改用 TrackRows。
+func (s *Store) Track(meta ChangeMeta, year, month int, fn func(st *Store) error) (*ChangeBatch, error) {
+	return s.track(meta, year, month, func(st *Store) (cellSnapshot, error) {
+		return st.snapshotCells(year, month)
+	}, fn)
+}
+
+// TrackRows 同 Track，但只快照 table 中 ids 指定的企业行；fn 只能修改这些行
+func (s *Store) TrackRows(meta ChangeMeta, year, month int, table string, ids []int64, fn func(st *Store) error) (*ChangeBatch, error) {
+	rows := map[string][]int64{table: ids}
+	return s.track(meta, year, month, func(st *Store) (cellSnapshot, error) {
+		return st.snapshotRows(rows)
+	}, fn)
+}
+
+func (s *Store) track(meta ChangeMeta, year, month int, snapshot func(st *Store) (cellSnapshot, error), fn func(st *Store) error) (*ChangeBatch, error) {
+	var batch *ChangeBatch
+	err := s.Atomic(func(st *Store) error {
+		before, err := snapshot(st)
+		if err != nil {
+			return err
+		}
+		if err := fn(st); err != nil {
+			return err
+		}
+		after, err := snapshot(st)
+		if err != nil {
+			return err
+		}
+		batch, err = st.recordBatch(meta, year, month, nil, before, after)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// snapshotCells 快照 year/month 内全部企业的可编辑字段
+func (s *Store) snapshotCells(year, month int) (cellSnapshot, error) {
+	out := cellSnapshot{}
+	for _, table := range trackedTables {
+		if err := s.snapshotInto(out, table, "data_year = ? AND data_month = ?", year, month); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// snapshotRows 只快照指定企业行（表 → id）的可编辑字段
+func (s *Store) snapshotRows(rows map[string][]int64) (cellSnapshot, error) {
+	out := cellSnapshot{}
+	for _, table := range trackedTables {
+		ids := rows[table]
+		// 分批查询，避免超出 SQLite 参数个数上限
+		for start := 0; start < len(ids); start += 500 {
+			chunk := ids[start:min(start+500, len(ids))]
+			args := make([]interface{}, len(chunk))
+			for i, id := range chunk {
+				args[i] = id
+			}
+			where := "id IN (?" + strings.Repeat(", ?", len(chunk)-1) + ")"
+			if err := s.snapshotInto(out, table, where, args...); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *Store) snapshotInto(out cellSnapshot, table, where string, args ...interface{}) error {
+	cols := trackedColumns[table]
+	rows, err := s.conn().Query(
+		fmt.Sprintf("SELECT id, %s FROM %s WHERE %s", strings.Join(cols, ", "), table, where), args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		values := make([]sql.NullFloat64, len(cols))
+		dest := []interface{}{&id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		for i, c := range cols {
+			out[cellKey{table, id, c}] = values[i]
+		}
+	}
+	return rows.Err()
+}
+
+// recordBatch 写入批次与字段变化；新的普通修改会使已撤销批次不可再重做
+func (s *Store) recordBatch(meta ChangeMeta, year, month int, target *int64, before, after cellSnapshot) (*ChangeBatch, error) {
+	var keys []cellKey
+	for k, v := range after {
+		if old, ok := before[k]; !ok || old != v {
+			keys = append(keys, k)
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].table != keys[j].table {
+			return keys[i].table > keys[j].table // wholesale_retail 在前
+		}
+		if keys[i].rowID != keys[j].rowID {
+			return keys[i].rowID < keys[j].rowID
+		}
+		return keys[i].field < keys[j].field
+	})
+
+	batchID, err := s.insertBatch(meta, year, month, target)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := s.conn().Prepare(`
+		INSERT INTO change_log (batch_id, table_name, row_id, field, old_value, new_value)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare change log: %w", err)
+	}
+	defer stmt.Close()
+	for _, k := range keys {
+		if _, err := stmt.Exec(batchID, k.table, k.rowID, k.field, nullable(before[k]), nullable(after[k])); err != nil {
+			return nil, fmt.Errorf("failed to write change log: %w", err)
+		}
+	}
+	if _, err := s.conn().Exec("UPDATE change_batches SET change_count = ? WHERE id = ?", len(keys), batchID); err != nil {
+		return nil, fmt.Errorf("failed to update change batch: %w", err)
+	}
+	return s.GetChangeBatch(batchID)
+}
+
+func (s *Store) insertBatch(meta ChangeMeta, year, month int, target *int64) (int64, error) {
+	if meta.Source != ChangeSourceUndo && meta.Source != ChangeSourceRedo {
+		if _, err := s.conn().Exec(
+			"UPDATE change_batches SET status = ? WHERE status = ?", BatchDiscarded, BatchUndone,
+		); err != nil {
+			return 0, fmt.Errorf("failed to discard redo history: %w", err)
+		}
+	}
+	res, err := s.conn().Exec(`
+		INSERT INTO change_batches (source, note, actor, data_year, data_month, status, target_batch_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, meta.Source, meta.Note, meta.Actor, year, month, BatchApplied, target)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create change batch: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+func nullable(v sql.NullFloat64) interface{} {
+	if !v.Valid {
+		return nil
+	}
+	return v.Float64
+}
+
+// RecordImportBatch 将一次导入写入的当月数据记录为导入批次（原值为空）；导入批次是撤销的边界
+func (s *Store) RecordImportBatch(meta ChangeMeta, year, month int) (*ChangeBatch, error) {
+	meta.Source = ChangeSourceImport
+	var batch *ChangeBatch
+	err := s.Atomic(func(st *Store) error {
+		batchID, err := st.insertBatch(meta, year, month, nil)
+		if err != nil {
+			return err
+		}
+		total := 0
+		for _, table := range trackedTables {
+			for _, col := range trackedColumns[table] {
+				res, err := st.conn().Exec(fmt.Sprintf(`
+					INSERT INTO change_log (batch_id, table_name, row_id, field, old_value, new_value)
+					SELECT ?, '%s', id, '%s', NULL, %s FROM %s
+					WHERE data_year = ? AND data_month = ? AND %s IS NOT NULL AND %s != 0
+				`, table, col, col, table, col, col), batchID, year, month)
+				if err != nil {
+					return fmt.Errorf("failed to record import: %w", err)
+				}
+				n, _ := res.RowsAffected()
+				total += int(n)
+			}
+		}
+		if _, err := st.conn().Exec("UPDATE change_batches SET change_count = ? WHERE id = ?", total, batchID); err != nil {
+			return fmt.Errorf("failed to update change batch: %w", err)
+		}
+		batch, err = st.GetChangeBatch(batchID)
+		return err
+	})
+	return batch, err
+}
+
+const changeBatchColumns = "id, source, COALESCE(note, ''), COALESCE(actor, ''), data_year, data_month, status, target_batch_id, change_count, created_at"
+
+func scanChangeBatch(scan func(dest ...interface{}) error) (*ChangeBatch, error) {
+	var b ChangeBatch
+	var target sql.NullInt64
+	if err := scan(&b.ID, &b.Source, &b.Note, &b.Actor, &b.DataYear, &b.DataMonth, &b.Status, &target, &b.ChangeCount, &b.CreatedAt); err != nil {
+		return nil, err
+	}
+	if target.Valid {
+		b.TargetBatchID = &target.Int64
+	}
+	return &b, nil
+}
+
+// GetChangeBatch 获取修改批次
+func (s *Store) GetChangeBatch(id int64) (*ChangeBatch, error) {
+	b, err := scanChangeBatch(s.conn().QueryRow("SELECT "+changeBatchColumns+" FROM change_batches WHERE id = ?", id).Scan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change batch: %w", err)
+	}
+	return b, nil
+}
+
+// ListChangeBatches 按时间倒序列出修改批次
+func (s *Store) ListChangeBatches(limit, offset int) ([]*ChangeBatch, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.conn().Query(
+		"SELECT "+changeBatchColumns+" FROM change_batches ORDER BY id DESC LIMIT ? OFFSET ?", limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list change batches: %w", err)
+	}
+	defer rows.Close()
+
+	out := []*ChangeBatch{}
+	for rows.Next() {
+		b, err := scanChangeBatch(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change batch: %w", err)
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// ListEditsSinceImport 列出 year/month 最近一次导入之后仍生效的修改批次（重新导入会覆盖这些修改）
+func (s *Store) ListEditsSinceImport(year, month int) ([]*ChangeBatch, error) {
+	rows, err := s.conn().Query(`
+		SELECT `+changeBatchColumns+` FROM change_batches
+		WHERE data_year = ? AND data_month = ? AND status = ? AND source NOT IN (?, ?, ?)
+			AND id > COALESCE((
+				SELECT MAX(id) FROM change_batches
+				WHERE data_year = ? AND data_month = ? AND source = ?
+			), 0)
+		ORDER BY id
+	`, year, month, BatchApplied, ChangeSourceImport, ChangeSourceUndo, ChangeSourceRedo, year, month, ChangeSourceImport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edits since import: %w", err)
+	}
+	defer rows.Close()
+
+	out := []*ChangeBatch{}
+	for rows.Next() {
+		b, err := scanChangeBatch(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change batch: %w", err)
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// ChangeQueryOptions 修改记录查询选项
+type ChangeQueryOptions struct {
+	BatchID *int64
+	Table   string // 与 RowID 一起按企业过滤
+	RowID   *int64
+	Limit   int
+	Offset  int
+}
+
+// ListChanges 查询字段修改记录
+func (s *Store) ListChanges(opts ChangeQueryOptions) ([]*ChangeEntry, error) {
+	query := "SELECT id, batch_id, table_name, row_id, field, old_value, new_value, created_at FROM change_log WHERE 1=1"
+	var args []interface{}
+	if opts.BatchID != nil {
+		query += " AND batch_id = ?"
+		args = append(args, *opts.BatchID)
+	}
+	if opts.Table != "" && opts.RowID != nil {
+		query += " AND table_name = ? AND row_id = ?"
+		args = append(args, opts.Table, *opts.RowID)
+	}
+	query += " ORDER BY id"
+	if opts.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, opts.Limit, opts.Offset)
+	}
+
+	rows, err := s.conn().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+	defer rows.Close()
+
+	out := []*ChangeEntry{}
+	for rows.Next() {
+		var e ChangeEntry
+		var oldValue, newValue sql.NullFloat64
+		if err := rows.Scan(&e.ID, &e.BatchID, &e.table, &e.rowID, &e.Field, &oldValue, &newValue, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan change: %w", err)
+		}
+		e.CompanyID = companyIDFor(e.table, e.rowID)
+		if oldValue.Valid {
+			e.OldValue = &oldValue.Float64
+		}
+		if newValue.Valid {
+			e.NewValue = &newValue.Float64
+		}
+		out = append(out, &e)
+	}
+	return out, rows.Err()
+}
+
+func companyIDFor(table string, rowID int64) string {
+	if table == "accommodation_catering" {
+		return fmt.Sprintf("ac:%d", rowID)
+	}
+	return fmt.Sprintf("wr:%d", rowID)
+}
+
+// UndoLastBatch 撤销最近一个已生效的批次，撤销本身记录为 undo 批次
+func (s *Store) UndoLastBatch(actor string) (*ChangeBatch, error) {
+	target, err := scanChangeBatch(s.conn().QueryRow(
+		"SELECT "+changeBatchColumns+" FROM change_batches WHERE status = ? AND source NOT IN (?, ?) ORDER BY id DESC LIMIT 1",
+		BatchApplied, ChangeSourceUndo, ChangeSourceRedo,
+	).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNothingToUndo
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find batch to undo: %w", err)
+	}
+	if target.Source == ChangeSourceImport {
+		return nil, ErrImportNotUndoable
+	}
+	return s.replayBatch(target, ChangeSourceUndo, BatchUndone, actor, true)
+}
+
+// RedoLastBatch 重做最近一次撤销的批次
+func (s *Store) RedoLastBatch(actor string) (*ChangeBatch, error) {
+	target, err := scanChangeBatch(s.conn().QueryRow(`
+		SELECT `+changeBatchColumns+` FROM change_batches WHERE id = (
+			SELECT u.target_batch_id FROM change_batches u
+			JOIN change_batches t ON t.id = u.target_batch_id
+			WHERE u.source = ? AND t.status = ?
+			ORDER BY u.id DESC LIMIT 1
+		)`, ChangeSourceUndo, BatchUndone,
+	).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNothingToRedo
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find batch to redo: %w", err)
+	}
+	return s.replayBatch(target, ChangeSourceRedo, BatchApplied, actor, false)
+}
+
+// replayBatch 写回批次的原值（useOld）或新值，并更新批次状态
+func (s *Store) replayBatch(target *ChangeBatch, source, status, actor string, useOld bool) (*ChangeBatch, error) {
+	entries, err := s.ListChanges(ChangeQueryOptions{BatchID: &target.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	// 只快照批次涉及的企业行
+	touched := map[string][]int64{}
+	seen := map[cellKey]bool{}
+	for _, e := range entries {
+		if k := (cellKey{table: e.table, rowID: e.rowID}); !seen[k] {
+			seen[k] = true
+			touched[e.table] = append(touched[e.table], e.rowID)
+		}
+	}
+
+	meta := ChangeMeta{Source: source, Note: fmt.Sprintf("%s #%d", target.Source, target.ID), Actor: actor}
+	var batch *ChangeBatch
+	err = s.Atomic(func(st *Store) error {
+		before, err := st.snapshotRows(touched)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			v := e.NewValue
+			if useOld {
+				v = e.OldValue
+			}
+			var arg interface{}
+			if v != nil {
+				arg = *v
+			}
+			// 字段名来自 trackedColumns
+			if _, err := st.conn().Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", e.table, e.Field), arg, e.rowID); err != nil {
+				return fmt.Errorf("failed to replay change: %w", err)
+			}
+		}
+		after, err := st.snapshotRows(touched)
+		if err != nil {
+			return err
+		}
+		if batch, err = st.recordBatch(meta, target.DataYear, target.DataMonth, &target.ID, before, after); err != nil {
+			return err
+		}
+		if _, err := st.conn().Exec("UPDATE change_batches SET status = ? WHERE id = ?", status, target.ID); err != nil {
+			return fmt.Errorf("failed to update change batch: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		// 当前值已与目标一致，仍返回被操作的批次
+		return s.GetChangeBatch(target.ID)
+	}
+	return batch, nil
+}
@@ -1,10 +1,16 @@
 package store
 
-import "fmt"
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"northstar/internal/model"
+)
 
 // CreateImportLog 创建导入日志，返回 import_log_id
 func (s *Store) CreateImportLog(filename, filePath string, fileSize int64, fileHash string) (int64, error) {
-	res, err := s.db.Exec(`
+	res, err := s.conn().Exec(`
 		INSERT INTO import_logs (filename, file_path, file_size, file_hash, status)
 		VALUES (?, ?, ?, ?, 'processing')
 	`, filename, filePath, fileSize, fileHash)
@@ -20,7 +26,7 @@ func (s *Store) CreateImportLog(filename, filePath string, fileSize int64, fileH
 
 // UpdateImportLog 完成导入日志更新
 func (s *Store) UpdateImportLog(id int64, totalSheets, importedSheets, skippedSheets, totalRows, importedRows, errorRows int, status, errorMessage string) error {
-	_, err := s.db.Exec(`
+	_, err := s.conn().Exec(`
 		UPDATE import_logs SET
 			total_sheets = ?,
 			imported_sheets = ?,
@@ -39,3 +45,187 @@ func (s *Store) UpdateImportLog(id int64, totalSheets, importedSheets, skippedSh
 	return nil
 }
 
+const importLogColumns = `id, filename, file_path, file_size, file_hash,
+	COALESCE(total_sheets, 0), COALESCE(imported_sheets, 0), COALESCE(skipped_sheets, 0),
+	COALESCE(total_rows, 0), COALESCE(imported_rows, 0), COALESCE(error_rows, 0),
+	COALESCE(data_year, 0), COALESCE(data_month, 0),
+	COALESCE(status, ''), error_message, started_at, completed_at`
+
+func scanImportLog(scan func(dest ...interface{}) error) (*model.ImportLog, error) {
+	var l model.ImportLog
+	var filePath, fileHash, errMsg sql.NullString
+	var fileSize sql.NullInt64
+	var completedAt sql.NullTime
+	if err := scan(
+		&l.ID, &l.Filename, &filePath, &fileSize, &fileHash,
+		&l.TotalSheets, &l.ImportedSheets, &l.SkippedSheets,
+		&l.TotalRows, &l.ImportedRows, &l.ErrorRows,
+		&l.DataYear, &l.DataMonth,
+		&l.Status, &errMsg, &l.StartedAt, &completedAt,
+	); err != nil {
+		return nil, err
+	}
+	l.FilePath = filePath.String
+	l.FileSize = fileSize.Int64
+	l.FileHash = fileHash.String
+	l.ErrorMessage = errMsg.String
+	if completedAt.Valid {
+		l.CompletedAt = &completedAt.Time
+	}
+	return &l, nil
+}
+
+// SetImportLogYearMonth 记录导入产生的数据年月
+func (s *Store) SetImportLogYearMonth(id int64, year, month int) error {
+	if _, err := s.conn().Exec(
+		"UPDATE import_logs SET data_year = ?, data_month = ? WHERE id = ?", year, month, id,
+	); err != nil {
+		return fmt.Errorf("failed to update import log year/month: %w", err)
+	}
+	return nil
+}
+
+// SetImportLogOverrides 记录导入时人工确认的解析参数（JSON）
+func (s *Store) SetImportLogOverrides(id int64, overridesJSON string) error {
+	if _, err := s.conn().Exec(
+		"UPDATE import_logs SET overrides_json = ? WHERE id = ?", overridesJSON, id,
+	); err != nil {
+		return fmt.Errorf("failed to update import log overrides: %w", err)
+	}
+	return nil
+}
+
+// GetImportLogOverrides 获取导入时人工确认的解析参数（JSON），未指定时返回空字符串
+func (s *Store) GetImportLogOverrides(id int64) (string, error) {
+	var v sql.NullString
+	if err := s.conn().QueryRow("SELECT overrides_json FROM import_logs WHERE id = ?", id).Scan(&v); err != nil {
+		return "", fmt.Errorf("failed to get import log overrides: %w", err)
+	}
+	return v.String, nil
+}
+
+// GetImportLog 按 ID 获取导入日志，不存在时返回 nil
+func (s *Store) GetImportLog(id int64) (*model.ImportLog, error) {
+	l, err := scanImportLog(s.conn().QueryRow("SELECT "+importLogColumns+" FROM import_logs WHERE id = ?", id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import log: %w", err)
+	}
+	return l, nil
+}
+
+// FindCompletedImportByHash 查找相同文件哈希最近一次成功导入，未找到时返回 nil
+func (s *Store) FindCompletedImportByHash(hash string) (*model.ImportLog, error) {
+	if hash == "" {
+		return nil, nil
+	}
+	l, err := scanImportLog(s.conn().QueryRow(
+		"SELECT "+importLogColumns+" FROM import_logs WHERE file_hash = ? AND status = 'completed' ORDER BY id DESC LIMIT 1", hash,
+	).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find import by hash: %w", err)
+	}
+	return l, nil
+}
+
+// ListImportLogs 分页获取导入日志（最新在前），同时返回总数
+func (s *Store) ListImportLogs(limit, offset int) ([]model.ImportLog, int, error) {
+	var total int
+	if err := s.conn().QueryRow("SELECT COUNT(*) FROM import_logs").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count import logs: %w", err)
+	}
+
+	rows, err := s.conn().Query(
+		"SELECT "+importLogColumns+" FROM import_logs ORDER BY id DESC LIMIT ? OFFSET ?", limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list import logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []model.ImportLog{}
+	for rows.Next() {
+		l, err := scanImportLog(rows.Scan)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan import log: %w", err)
+		}
+		logs = append(logs, *l)
+	}
+	return logs, total, rows.Err()
+}
+
+// GetLastImportTime 最近一次成功导入的完成时间，从未导入时返回 nil
+func (s *Store) GetLastImportTime() (*time.Time, error) {
+	var t sql.NullTime
+	err := s.conn().QueryRow(
+		"SELECT completed_at FROM import_logs WHERE status = 'completed' ORDER BY id DESC LIMIT 1",
+	).Scan(&t)
+	if err == sql.ErrNoRows || (err == nil && !t.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last import time: %w", err)
+	}
+	return &t.Time, nil
+}
+
+// InsertImportIssues 写入导入行级问题
+func (s *Store) InsertImportIssues(importLogID int64, issues []model.RowIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	stmt, err := s.conn().Prepare(`
+		INSERT INTO import_row_issues (import_log_id, sheet_name, row_no, column_letter, header, raw_value, code, problem)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare import issue insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, i := range issues {
+		if _, err := stmt.Exec(importLogID, i.Sheet, i.Row, i.Column, i.Header, i.Value, i.Code, i.Problem); err != nil {
+			return fmt.Errorf("failed to insert import issue: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListImportIssues 分页获取导入行级问题（limit <= 0 时返回全部），同时返回总数
+func (s *Store) ListImportIssues(importLogID int64, limit, offset int) ([]model.RowIssue, int, error) {
+	var total int
+	if err := s.conn().QueryRow(
+		"SELECT COUNT(*) FROM import_row_issues WHERE import_log_id = ?", importLogID,
+	).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count import issues: %w", err)
+	}
+
+	query := `
+		SELECT sheet_name, row_no, COALESCE(column_letter, ''), COALESCE(header, ''),
+			COALESCE(raw_value, ''), code, problem
+		FROM import_row_issues WHERE import_log_id = ? ORDER BY id`
+	args := []interface{}{importLogID}
+	if limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+	}
+	rows, err := s.conn().Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list import issues: %w", err)
+	}
+	defer rows.Close()
+
+	issues := []model.RowIssue{}
+	for rows.Next() {
+		var i model.RowIssue
+		if err := rows.Scan(&i.Sheet, &i.Row, &i.Column, &i.Header, &i.Value, &i.Code, &i.Problem); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan import issue: %w", err)
+		}
+		issues = append(issues, i)
+	}
+	return issues, total, rows.Err()
+}
@@ -0,0 +1,295 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Migration 版本化的数据库结构迁移
+//
+// 约定：schema.sql 为版本 1 的基线结构，之后的结构变更（新增表/列）一律追加为新的迁移，
+// 不再修改 schema.sql，以保证已有用户数据库能逐版本升级。
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+}
+
+// AppliedMigration 已执行的迁移
+type AppliedMigration struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// MigrationStatus 数据库结构版本状态
+type MigrationStatus struct {
+	CurrentVersion int                `json:"currentVersion"`
+	LatestVersion  int                `json:"latestVersion"`
+	Applied        []AppliedMigration `json:"applied"`
+	Pending        []Migration        `json:"pending"`
+}
+
+// migrations 按版本升序排列，版本号只增不改
+var migrations = []Migration{
+	{Version: 1, Name: "baseline", Up: migrateBaseline},
+	{Version: 2, Name: "scenarios", Up: execEmbedded("migrations/0002_scenarios.sql")},
+	{Version: 3, Name: "change_history", Up: execEmbedded("migrations/0003_change_history.sql")},
+	{Version: 4, Name: "import_issues", Up: execEmbedded("migrations/0004_import_issues.sql")},
+	{Version: 5, Name: "import_log_month", Up: execEmbedded("migrations/0005_import_log_month.sql")},
+	{Version: 6, Name: "column_aliases", Up: execEmbedded("migrations/0006_column_aliases.sql")},
+	{Version: 7, Name: "sheet_profiles", Up: execEmbedded("migrations/0007_sheet_profiles.sql")},
+	{Version: 8, Name: "sheet_header_rows", Up: execEmbedded("migrations/0008_sheet_header_rows.sql")},
+	{Version: 9, Name: "data_version", Up: execEmbedded("migrations/0009_data_version.sql")},
+	{Version: 10, Name: "import_log_overrides", Up: execEmbedded("migrations/0010_import_log_overrides.sql")},
+}
+
+// Migrations 返回全部迁移（按版本升序）
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+func execEmbedded(name string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		content, err := schemaFS.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if _, err := tx.Exec(string(content)); err != nil {
+			return fmt.Errorf("failed to execute %s: %w", name, err)
+		}
+		return nil
+	}
+}
+
+const createMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`
+
+// migrate 依次执行未应用的迁移，每个迁移在独立事务中执行
+func (s *Store) migrate() error {
+	if _, err := s.db.Exec(createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := s.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) applyMigration(m Migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.Up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SchemaVersion 当前数据库结构版本（0 表示尚未执行任何迁移）
+func (s *Store) SchemaVersion() (int, error) {
+	var v sql.NullInt64
+	if err := s.conn().QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&v); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(v.Int64), nil
+}
+
+// InspectMigrations 只读检查数据库文件的结构版本与待执行迁移（不创建、不修改数据库）
+func InspectMigrations(dbPath string) (*MigrationStatus, error) {
+	status := &MigrationStatus{LatestVersion: migrations[len(migrations)-1].Version}
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		status.Pending = Migrations()
+		return status, nil
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	var exists int
+	if err := db.QueryRow(
+		"SELECT COUNT(1) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'",
+	).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to inspect database: %w", err)
+	}
+	if exists > 0 {
+		rows, err := db.Query("SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var a AppliedMigration
+			if err := rows.Scan(&a.Version, &a.Name, &a.AppliedAt); err != nil {
+				return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+			}
+			status.Applied = append(status.Applied, a)
+			if a.Version > status.CurrentVersion {
+				status.CurrentVersion = a.Version
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range migrations {
+		if m.Version > status.CurrentVersion {
+			status.Pending = append(status.Pending, m)
+		}
+	}
+	return status, nil
+}
+
+// migrateBaseline 建立基线结构。
+// 早期版本直接执行 schema.sql 建库，其表可能缺少后来加入的列（索引会引用这些列），
+// 因此先为已存在的表补齐列，再执行 schema.sql 创建缺失的表与索引。
+func migrateBaseline(tx *sql.Tx) error {
+	if err := addMissingBaselineColumns(tx); err != nil {
+		return err
+	}
+	return execEmbedded("schema.sql")(tx)
+}
+
+// addMissingBaselineColumns 为已存在的表补齐 schema.sql 中定义的列
+func addMissingBaselineColumns(tx *sql.Tx) error {
+	content, err := schemaFS.ReadFile("schema.sql")
+	if err != nil {
+		return fmt.Errorf("failed to read schema.sql: %w", err)
+	}
+
+	ref, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return err
+	}
+	defer ref.Close()
+	ref.SetMaxOpenConns(1)
+	if _, err := ref.Exec(string(content)); err != nil {
+		return fmt.Errorf("failed to build reference schema: %w", err)
+	}
+
+	tables, err := listTables(ref)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		want, err := columnInfos(ref, table)
+		if err != nil {
+			return err
+		}
+		have, err := columnInfos(tx, table)
+		if err != nil {
+			return err
+		}
+		if len(have) == 0 {
+			continue // 表不存在，由 schema.sql 创建
+		}
+		existing := map[string]bool{}
+		for _, c := range have {
+			existing[c.name] = true
+		}
+		for _, c := range want {
+			if existing[c.name] {
+				continue
+			}
+			if _, err := tx.Exec(c.addColumnSQL(table)); err != nil {
+				return fmt.Errorf("failed to add %s.%s: %w", table, c.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+type columnInfo struct {
+	name    string
+	colType string
+	notNull bool
+	dflt    sql.NullString
+}
+
+// addColumnSQL ALTER TABLE 不支持非常量默认值，也不支持无默认值的 NOT NULL 列，此时放宽约束
+func (c columnInfo) addColumnSQL(table string) string {
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, c.name, c.colType)
+	if c.dflt.Valid && !strings.Contains(strings.ToUpper(c.dflt.String), "CURRENT_") {
+		stmt += " DEFAULT " + c.dflt.String
+		if c.notNull {
+			stmt += " NOT NULL"
+		}
+	}
+	return stmt
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func listTables(q queryer) ([]string, error) {
+	rows, err := q.QueryContext(context.Background(),
+		"SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+func columnInfos(q queryer, table string) ([]columnInfo, error) {
+	rows, err := q.QueryContext(context.Background(), fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []columnInfo
+	for rows.Next() {
+		var (
+			cid     int
+			c       columnInfo
+			notNull int
+			pk      int
+		)
+		if err := rows.Scan(&cid, &c.name, &c.colType, &notNull, &c.dflt, &pk); err != nil {
+			return nil, err
+		}
+		c.notNull = notNull == 1
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- 0002 scenarios - 命名方案（某月企业数据的独立副本，数据存放于独立 SQLite 文件）
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS scenarios (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,                   -- 方案名称
+    data_year INTEGER NOT NULL,                  -- 方案对应年份
+    data_month INTEGER NOT NULL,                 -- 方案对应月份
+    file_path TEXT NOT NULL,                     -- 方案数据库文件
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
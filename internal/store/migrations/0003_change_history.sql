@@ -0,0 +1,29 @@
+-- ============================================================================
+-- 0003 change_batches / change_log - 企业数据修改历史（只追加）
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS change_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL,                        -- manual/optimize/reset/dedup/import/undo/redo
+    note TEXT,                                   -- 说明 (如调整目标)
+    actor TEXT,                                  -- 操作人/来源地址
+    data_year INTEGER NOT NULL,
+    data_month INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'applied',      -- applied/undone/discarded
+    target_batch_id INTEGER,                     -- undo/redo 作用的批次
+    change_count INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS change_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    batch_id INTEGER NOT NULL,
+    table_name TEXT NOT NULL,                    -- wholesale_retail/accommodation_catering
+    row_id INTEGER NOT NULL,
+    field TEXT NOT NULL,
+    old_value REAL,
+    new_value REAL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cl_batch ON change_log(batch_id);
+CREATE INDEX IF NOT EXISTS idx_cl_row ON change_log(table_name, row_id);
@@ -0,0 +1,17 @@
+-- ============================================================================
+-- 0004 import_row_issues - 导入行级问题（定位到单元格）
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS import_row_issues (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    import_log_id INTEGER NOT NULL,              -- 关联 import_logs.id
+    sheet_name TEXT NOT NULL,
+    row_no INTEGER NOT NULL,                     -- Excel 行号（从 1 开始）
+    column_letter TEXT,                          -- 列字母，整行问题时为空
+    header TEXT,                                 -- 列头原文
+    raw_value TEXT,                              -- 单元格原始值
+    code TEXT NOT NULL,                          -- invalid_number/missing_credit_code/unknown_industry_code/duplicate_company
+    problem TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_iri_import ON import_row_issues(import_log_id, id);
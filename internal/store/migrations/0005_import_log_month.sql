@@ -0,0 +1,7 @@
+-- ============================================================================
+-- 0005 import_logs 记录导入产生的数据年月（用于重复上传检测）
+-- ============================================================================
+ALTER TABLE import_logs ADD COLUMN data_year INTEGER;
+ALTER TABLE import_logs ADD COLUMN data_month INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_il_file_hash ON import_logs(file_hash);
@@ -0,0 +1,14 @@
+-- ============================================================================
+-- 0006 column_aliases - 用户维护的列别名（优先于内置字段映射规则）
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS column_aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    pattern TEXT NOT NULL,                       -- 列名 pattern
+    match_type TEXT NOT NULL DEFAULT 'contains', -- exact/contains/regex
+    scope TEXT NOT NULL DEFAULT '',              -- wholesale_retail/accommodation_catering，空表示两者
+    db_field TEXT NOT NULL,                      -- 数据库字段或时间敏感指标
+    time_type TEXT NOT NULL DEFAULT '',          -- 时间口径，空表示按列名推断
+    note TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,13 @@
+-- ============================================================================
+-- 0007 sheet_profiles - 学习到的 Sheet 识别档案（表头签名 → Sheet 类型）
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS sheet_profiles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    signature TEXT NOT NULL UNIQUE,              -- 规范化表头签名
+    sheet_type TEXT NOT NULL,
+    sheet_name TEXT,                             -- 学习时的 Sheet 名
+    headers_json TEXT,                           -- 学习时的表头 JSON 数组
+    hit_count INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
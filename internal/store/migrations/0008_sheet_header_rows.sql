@@ -0,0 +1,5 @@
+-- ============================================================================
+-- 0008 sheets_meta 记录识别到的表头行（Excel 行号，从 1 开始）
+-- ============================================================================
+ALTER TABLE sheets_meta ADD COLUMN header_row_start INTEGER;
+ALTER TABLE sheets_meta ADD COLUMN header_row_end INTEGER;
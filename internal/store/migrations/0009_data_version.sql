@@ -0,0 +1,55 @@
+-- ============================================================================
+-- 0009 data_version - 企业数据与配置项的数据版本
+-- 任一连接写入企业表或配置项时由触发器递增，随事务提交/回滚；用作指标缓存的版本号
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS data_version (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    version INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT OR IGNORE INTO data_version (id, version) VALUES (1, 0);
+
+CREATE TRIGGER IF NOT EXISTS wr_data_version_insert AFTER INSERT ON wholesale_retail
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS wr_data_version_update AFTER UPDATE ON wholesale_retail
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS wr_data_version_delete AFTER DELETE ON wholesale_retail
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS ac_data_version_insert AFTER INSERT ON accommodation_catering
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS ac_data_version_update AFTER UPDATE ON accommodation_catering
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS ac_data_version_delete AFTER DELETE ON accommodation_catering
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS config_data_version_insert AFTER INSERT ON config
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS config_data_version_update AFTER UPDATE ON config
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS config_data_version_delete AFTER DELETE ON config
+BEGIN
+    UPDATE data_version SET version = version + 1 WHERE id = 1;
+END;
@@ -0,0 +1,4 @@
+-- ============================================================================
+-- 0010 import_logs 记录人工确认的解析参数（重新导入时沿用）
+-- ============================================================================
+ALTER TABLE import_logs ADD COLUMN overrides_json TEXT;
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrate_FreshDatabaseReachesLatestVersion(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "northstar.db")
+	st, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	v, err := st.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion: %v", err)
+	}
+	latest := migrations[len(migrations)-1].Version
+	if v != latest {
+		t.Fatalf("version=%d, want %d", v, latest)
+	}
+	st.Close()
+
+	status, err := InspectMigrations(dbPath)
+	if err != nil {
+		t.Fatalf("InspectMigrations: %v", err)
+	}
+	if status.CurrentVersion != latest || len(status.Pending) != 0 || len(status.Applied) != len(migrations) {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	// 重复打开不应重复执行迁移
+	st, err = New(dbPath)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer st.Close()
+	var n int
+	if err := st.db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != len(migrations) {
+		t.Fatalf("schema_migrations rows=%d, want %d", n, len(migrations))
+	}
+}
+
+func TestMigrate_UpgradesLegacyDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "northstar.db")
+
+	// 模拟早期版本：无 schema_migrations，企业表缺少后加入的列
+	legacy, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := legacy.Exec(`
+		CREATE TABLE wholesale_retail (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			data_year INTEGER NOT NULL,
+			data_month INTEGER NOT NULL
+		);
+		INSERT INTO wholesale_retail (name, data_year, data_month) VALUES ('老数据', 2025, 12);
+	`); err != nil {
+		t.Fatal(err)
+	}
+	legacy.Close()
+
+	status, err := InspectMigrations(dbPath)
+	if err != nil {
+		t.Fatalf("InspectMigrations: %v", err)
+	}
+	if status.CurrentVersion != 0 || len(status.Pending) != len(migrations) {
+		t.Fatalf("unexpected legacy status: %+v", status)
+	}
+
+	st, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer st.Close()
+
+	var name string
+	var sales sql.NullFloat64
+	if err := st.db.QueryRow(
+		"SELECT name, sales_current_month FROM wholesale_retail WHERE data_year = 2025",
+	).Scan(&name, &sales); err != nil {
+		t.Fatalf("query upgraded table: %v", err)
+	}
+	if name != "老数据" {
+		t.Fatalf("name=%q", name)
+	}
+	if _, err := st.ListScenarios(); err != nil {
+		t.Fatalf("scenarios table missing after upgrade: %v", err)
+	}
+}
@@ -14,7 +14,7 @@ type YearMonthStat struct {
 
 // ListAvailableYearMonths 列出当前数据库中存在数据的年月（按年/月倒序）
 func (s *Store) ListAvailableYearMonths() ([]YearMonthStat, error) {
-	rows, err := s.db.Query(`
+	rows, err := s.conn().Query(`
 		WITH ym AS (
 			SELECT DISTINCT data_year AS y, data_month AS m FROM wholesale_retail
 			UNION
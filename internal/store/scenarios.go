@@ -0,0 +1,348 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrScenarioNotFound 方案不存在
+var ErrScenarioNotFound = errors.New("scenario not found")
+
+// Scenario 命名方案：某月批零/住餐企业数据的独立副本
+type Scenario struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	DataYear  int       `json:"dataYear"`
+	DataMonth int       `json:"dataMonth"`
+	FilePath  string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// scenarioTables 方案复制/提升的企业数据表
+var scenarioTables = []string{"wholesale_retail", "accommodation_catering"}
+
+// scenarioCache 已打开的方案 Store（按方案 ID）
+type scenarioCache struct {
+	mu     sync.Mutex
+	stores map[int64]*Store
+}
+
+func newScenarioCache() *scenarioCache {
+	return &scenarioCache{stores: map[int64]*Store{}}
+}
+
+func (c *scenarioCache) closeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, st := range c.stores {
+		_ = st.Close()
+		delete(c.stores, id)
+	}
+}
+
+// CreateScenario 将 from 方案（为空表示工作数据）当前月份的企业数据复制为新方案
+func (s *Store) CreateScenario(name, from string) (*Scenario, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("scenario name is required")
+	}
+	if s.scenarios == nil {
+		return nil, fmt.Errorf("scenarios are not available on this store")
+	}
+
+	source := s
+	if from != "" {
+		var err error
+		if source, _, err = s.OpenScenario(from); err != nil {
+			return nil, err
+		}
+	}
+	year, month, err := source.GetCurrentYearMonth()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.conn().Exec(
+		"INSERT INTO scenarios (name, data_year, data_month, file_path) VALUES (?, ?, ?, '')",
+		name, year, month,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scenario: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scenario id: %w", err)
+	}
+
+	path := filepath.Join(filepath.Dir(s.path), "scenarios", fmt.Sprintf("scenario_%d.db", id))
+	sc, err := s.forkScenario(id, path, source, year, month)
+	if err != nil {
+		_, _ = s.conn().Exec("DELETE FROM scenarios WHERE id = ?", id)
+		_ = os.Remove(path)
+		return nil, err
+	}
+	sc.Name = name
+	return sc, nil
+}
+
+func (s *Store) forkScenario(id int64, path string, source *Store, year, month int) (*Scenario, error) {
+	_ = os.Remove(path)
+	scn, err := New(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = withAttached(scn, source.path, "src", func(tx *sql.Tx) error {
+		for _, table := range scenarioTables {
+			if err := copyMonthRows(tx, "src", "main", table, year, month); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec("INSERT OR REPLACE INTO main.config SELECT * FROM src.config"); err != nil {
+			return fmt.Errorf("failed to copy config: %w", err)
+		}
+		return nil
+	})
+	if err == nil {
+		err = scn.SetCurrentYearMonth(year, month)
+	}
+	if err == nil {
+		_, err = s.conn().Exec("UPDATE scenarios SET file_path = ? WHERE id = ?", path, id)
+	}
+	if err != nil {
+		_ = scn.Close()
+		return nil, fmt.Errorf("failed to fork scenario: %w", err)
+	}
+
+	s.scenarios.mu.Lock()
+	s.scenarios.stores[id] = scn
+	s.scenarios.mu.Unlock()
+
+	return s.GetScenarioByID(id)
+}
+
+// ListScenarios 列出全部方案
+func (s *Store) ListScenarios() ([]Scenario, error) {
+	rows, err := s.conn().Query("SELECT id, name, data_year, data_month, file_path, created_at FROM scenarios ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scenarios: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Scenario{}
+	for rows.Next() {
+		var sc Scenario
+		if err := rows.Scan(&sc.ID, &sc.Name, &sc.DataYear, &sc.DataMonth, &sc.FilePath, &sc.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scenario: %w", err)
+		}
+		out = append(out, sc)
+	}
+	return out, rows.Err()
+}
+
+// GetScenario 按名称获取方案
+func (s *Store) GetScenario(name string) (*Scenario, error) {
+	return s.scanScenario(s.conn().QueryRow(
+		"SELECT id, name, data_year, data_month, file_path, created_at FROM scenarios WHERE name = ?", name,
+	))
+}
+
+// GetScenarioByID 按 ID 获取方案
+func (s *Store) GetScenarioByID(id int64) (*Scenario, error) {
+	return s.scanScenario(s.conn().QueryRow(
+		"SELECT id, name, data_year, data_month, file_path, created_at FROM scenarios WHERE id = ?", id,
+	))
+}
+
+func (s *Store) scanScenario(row *sql.Row) (*Scenario, error) {
+	var sc Scenario
+	if err := row.Scan(&sc.ID, &sc.Name, &sc.DataYear, &sc.DataMonth, &sc.FilePath, &sc.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScenarioNotFound
+		}
+		return nil, fmt.Errorf("failed to get scenario: %w", err)
+	}
+	return &sc, nil
+}
+
+// OpenScenario 打开方案对应的 Store；返回的 Store 可直接用于查询、调整、计算与导出
+func (s *Store) OpenScenario(name string) (*Store, *Scenario, error) {
+	sc, err := s.GetScenario(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.scenarios == nil {
+		return nil, nil, fmt.Errorf("scenarios are not available on this store")
+	}
+
+	s.scenarios.mu.Lock()
+	defer s.scenarios.mu.Unlock()
+	if st, ok := s.scenarios.stores[sc.ID]; ok {
+		return st, sc, nil
+	}
+	if _, err := os.Stat(sc.FilePath); err != nil {
+		return nil, nil, fmt.Errorf("scenario data file missing: %w", err)
+	}
+	st, err := New(sc.FilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.scenarios.stores[sc.ID] = st
+	return st, sc, nil
+}
+
+// DeleteScenario 删除方案及其数据文件
+func (s *Store) DeleteScenario(name string) error {
+	sc, err := s.GetScenario(name)
+	if err != nil {
+		return err
+	}
+	if s.scenarios != nil {
+		s.scenarios.mu.Lock()
+		if st, ok := s.scenarios.stores[sc.ID]; ok {
+			_ = st.Close()
+			delete(s.scenarios.stores, sc.ID)
+		}
+		s.scenarios.mu.Unlock()
+	}
+	if _, err := s.conn().Exec("DELETE FROM scenarios WHERE id = ?", sc.ID); err != nil {
+		return fmt.Errorf("failed to delete scenario: %w", err)
+	}
+	if err := os.Remove(sc.FilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove scenario file: %w", err)
+	}
+	return nil
+}
+
+// PromoteScenario 用方案数据替换工作数据中对应月份的企业数据（方案保留），并将可编辑字段的变化记录为提升批次。
+// 方案与工作数据共享企业 id，撤销该批次即可写回原值；方案中新增或删除的企业行不在撤销范围内。
+func (s *Store) PromoteScenario(meta ChangeMeta, name string) (*Scenario, *ChangeBatch, error) {
+	_, sc, err := s.OpenScenario(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta.Source = ChangeSourcePromote
+	if meta.Note == "" {
+		meta.Note = sc.Name
+	}
+	var batch *ChangeBatch
+	err = withAttached(s, sc.FilePath, "scn", func(tx *sql.Tx) error {
+		st := &Store{db: s.db, path: s.path, tx: tx}
+		before, err := st.snapshotCells(sc.DataYear, sc.DataMonth)
+		if err != nil {
+			return err
+		}
+		for _, table := range scenarioTables {
+			if _, err := tx.Exec(
+				fmt.Sprintf("DELETE FROM main.%s WHERE data_year = ? AND data_month = ?", table),
+				sc.DataYear, sc.DataMonth,
+			); err != nil {
+				return err
+			}
+			if err := copyMonthRows(tx, "scn", "main", table, sc.DataYear, sc.DataMonth); err != nil {
+				return err
+			}
+		}
+		after, err := st.snapshotCells(sc.DataYear, sc.DataMonth)
+		if err != nil {
+			return err
+		}
+		batch, err = st.recordBatch(meta, sc.DataYear, sc.DataMonth, nil, before, after)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to promote scenario: %w", err)
+	}
+	return sc, batch, nil
+}
+
+// withAttached 在独占连接上挂载另一个数据库文件并在事务内执行 fn
+func withAttached(s *Store, path, alias string, fn func(tx *sql.Tx) error) error {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+alias, path); err != nil {
+		return fmt.Errorf("failed to attach database: %w", err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE "+alias)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// copyMonthRows 按列名复制某月数据（保留 id，便于跨方案对比同一企业）
+func copyMonthRows(tx *sql.Tx, from, to, table string, year, month int) error {
+	toCols, err := tableColumns(tx, to, table)
+	if err != nil {
+		return err
+	}
+	fromCols, err := tableColumns(tx, from, table)
+	if err != nil {
+		return err
+	}
+	// 两侧表结构可能因迁移先后不同，只复制共有列
+	present := map[string]bool{}
+	for _, c := range fromCols {
+		present[c] = true
+	}
+	var cols []string
+	for _, c := range toCols {
+		if present[c] {
+			cols = append(cols, c)
+		}
+	}
+	list := strings.Join(cols, ", ")
+	query := fmt.Sprintf(
+		"INSERT INTO %s.%s (%s) SELECT %s FROM %s.%s WHERE data_year = ? AND data_month = ?",
+		to, table, list, list, from, table,
+	)
+	if _, err := tx.Exec(query, year, month); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", table, err)
+	}
+	return nil
+}
+
+func tableColumns(tx *sql.Tx, schema, table string) ([]string, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA %s.table_info(%s)", schema, table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
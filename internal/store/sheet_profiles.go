@@ -0,0 +1,110 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"northstar/internal/model"
+)
+
+// ErrSheetProfileNotFound 识别档案不存在
+var ErrSheetProfileNotFound = errors.New("sheet profile not found")
+
+const sheetProfileColumns = "id, signature, sheet_type, COALESCE(sheet_name, ''), COALESCE(headers_json, ''), hit_count, created_at, updated_at"
+
+func scanSheetProfile(scan func(dest ...interface{}) error) (*model.SheetProfile, error) {
+	var p model.SheetProfile
+	var headers string
+	if err := scan(&p.ID, &p.Signature, &p.SheetType, &p.SheetName, &headers, &p.HitCount, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.Headers = []string{}
+	if headers != "" {
+		_ = json.Unmarshal([]byte(headers), &p.Headers)
+	}
+	return &p, nil
+}
+
+// ListSheetProfiles 列出全部识别档案
+func (s *Store) ListSheetProfiles() ([]model.SheetProfile, error) {
+	rows, err := s.conn().Query("SELECT " + sheetProfileColumns + " FROM sheet_profiles ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sheet profiles: %w", err)
+	}
+	defer rows.Close()
+
+	out := []model.SheetProfile{}
+	for rows.Next() {
+		p, err := scanSheetProfile(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sheet profile: %w", err)
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+// GetSheetProfile 获取识别档案
+func (s *Store) GetSheetProfile(id int64) (*model.SheetProfile, error) {
+	p, err := scanSheetProfile(s.conn().QueryRow("SELECT "+sheetProfileColumns+" FROM sheet_profiles WHERE id = ?", id).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSheetProfileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sheet profile: %w", err)
+	}
+	return p, nil
+}
+
+// SaveSheetProfile 按签名新增或覆盖识别档案
+func (s *Store) SaveSheetProfile(p model.SheetProfile) error {
+	_, err := s.conn().Exec(`
+		INSERT INTO sheet_profiles (signature, sheet_type, sheet_name, headers_json)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(signature) DO UPDATE SET
+			sheet_type = excluded.sheet_type,
+			sheet_name = excluded.sheet_name,
+			headers_json = excluded.headers_json,
+			updated_at = CURRENT_TIMESTAMP
+	`, p.Signature, p.SheetType, p.SheetName, BuildColumnsJSON(p.Headers))
+	if err != nil {
+		return fmt.Errorf("failed to save sheet profile: %w", err)
+	}
+	return nil
+}
+
+// UpdateSheetProfileType 修改识别档案的 Sheet 类型
+func (s *Store) UpdateSheetProfileType(id int64, sheetType string) (*model.SheetProfile, error) {
+	res, err := s.conn().Exec(
+		"UPDATE sheet_profiles SET sheet_type = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", sheetType, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update sheet profile: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrSheetProfileNotFound
+	}
+	return s.GetSheetProfile(id)
+}
+
+// IncrementSheetProfileHits 记录识别档案命中
+func (s *Store) IncrementSheetProfileHits(id int64) error {
+	if _, err := s.conn().Exec("UPDATE sheet_profiles SET hit_count = hit_count + 1 WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to update sheet profile hits: %w", err)
+	}
+	return nil
+}
+
+// DeleteSheetProfile 删除识别档案
+func (s *Store) DeleteSheetProfile(id int64) error {
+	res, err := s.conn().Exec("DELETE FROM sheet_profiles WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete sheet profile: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSheetProfileNotFound
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 
@@ -9,19 +10,21 @@ import (
 
 // InsertSheetMeta 写入 Sheet 元信息（用于追溯与容错）
 func (s *Store) InsertSheetMeta(meta model.SheetMeta) error {
-	_, err := s.db.Exec(`
+	_, err := s.conn().Exec(`
 		INSERT INTO sheets_meta (
 			sheet_name, sheet_type, confidence,
 			total_rows, total_columns,
+			header_row_start, header_row_end,
 			imported_rows,
 			columns_json, column_mapping_json,
 			status, error_message,
 			import_log_id,
 			source_file
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		meta.SheetName, meta.SheetType, meta.Confidence,
 		meta.TotalRows, meta.TotalColumns,
+		meta.HeaderRowStart, meta.HeaderRowEnd,
 		meta.ImportedRows,
 		meta.ColumnsJSON, meta.ColumnMappingJSON,
 		meta.Status, meta.ErrorMessage,
@@ -43,3 +46,45 @@ func BuildColumnsJSON(columns []string) string {
 	return string(b)
 }
 
+// ListSheetMetasByImport 获取某次导入的 Sheet 元信息（按处理顺序）
+func (s *Store) ListSheetMetasByImport(importLogID int64) ([]model.SheetMeta, error) {
+	rows, err := s.conn().Query(`
+		SELECT id, sheet_name, COALESCE(sheet_type, ''), COALESCE(confidence, 0),
+			COALESCE(total_rows, 0), COALESCE(total_columns, 0), COALESCE(imported_rows, 0),
+			COALESCE(header_row_start, 0), COALESCE(header_row_end, 0),
+			columns_json, column_mapping_json,
+			COALESCE(status, ''), error_message,
+			import_log_id, source_file, created_at
+		FROM sheets_meta WHERE import_log_id = ? ORDER BY id
+	`, importLogID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sheets_meta: %w", err)
+	}
+	defer rows.Close()
+
+	metas := []model.SheetMeta{}
+	for rows.Next() {
+		var m model.SheetMeta
+		var columns, mapping, errMsg, sourceFile sql.NullString
+		var logID sql.NullInt64
+		if err := rows.Scan(
+			&m.ID, &m.SheetName, &m.SheetType, &m.Confidence,
+			&m.TotalRows, &m.TotalColumns, &m.ImportedRows,
+			&m.HeaderRowStart, &m.HeaderRowEnd,
+			&columns, &mapping,
+			&m.Status, &errMsg,
+			&logID, &sourceFile, &m.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan sheets_meta: %w", err)
+		}
+		m.ColumnsJSON = columns.String
+		m.ColumnMappingJSON = mapping.String
+		m.ErrorMessage = errMsg.String
+		m.SourceFile = sourceFile.String
+		if logID.Valid {
+			m.ImportLogID = &logID.Int64
+		}
+		metas = append(metas, m)
+	}
+	return metas, rows.Err()
+}
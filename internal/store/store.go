@@ -10,12 +10,33 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-//go:embed schema.sql
+//go:embed schema.sql migrations/*.sql
 var schemaFS embed.FS
 
 // Store SQLite 数据库存储层
 type Store struct {
-	db *sql.DB
+	db   *sql.DB
+	path string
+	// tx 非空表示预演 Store：所有读写都在该事务内进行，结束后整体回滚
+	tx         *sql.Tx
+	savepoints int
+
+	scenarios *scenarioCache
+}
+
+// conn 普通连接与预演事务的共同接口
+type conn interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+func (s *Store) conn() conn {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
 }
 
 // New 创建新的 Store 实例
@@ -41,61 +62,171 @@ func New(dbPath string) (*Store, error) {
 	db.SetMaxOpenConns(1) // SQLite 建议单连接
 	db.SetMaxIdleConns(1)
 
-	store := &Store{db: db}
+	store := &Store{db: db, path: dbPath, scenarios: newScenarioCache()}
 
-	// 初始化数据库结构
-	if err := store.initSchema(); err != nil {
+	// 初始化/升级数据库结构
+	if err := store.migrate(); err != nil {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
 	return store, nil
 }
 
-// initSchema 初始化数据库结构
-func (s *Store) initSchema() error {
-	schemaSQL, err := schemaFS.ReadFile("schema.sql")
-	if err != nil {
-		return fmt.Errorf("failed to read schema.sql: %w", err)
-	}
-
-	// 执行建表语句
-	if _, err := s.db.Exec(string(schemaSQL)); err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
-	}
-
-	return nil
-}
-
 // Close 关闭数据库连接
 func (s *Store) Close() error {
+	if s.tx != nil {
+		// 预演 Store 共享底层连接，由 DryRun 负责回滚
+		return nil
+	}
+	if s.scenarios != nil {
+		s.scenarios.closeAll()
+	}
 	if s.db != nil {
 		return s.db.Close()
 	}
 	return nil
 }
 
+// Path 数据库文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
 // DB 获取原始数据库连接（用于事务等高级操作）
 func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
+// Tx 事务句柄；在预演 Store 上以保存点实现，提交只释放保存点
+type Tx struct {
+	tx        *sql.Tx
+	savepoint string
+	done      bool
+}
+
 // BeginTx 开始事务
-func (s *Store) BeginTx() (*sql.Tx, error) {
-	return s.db.Begin()
+func (s *Store) BeginTx() (*Tx, error) {
+	if s.tx == nil {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		return &Tx{tx: tx}, nil
+	}
+
+	s.savepoints++
+	name := fmt.Sprintf("sp_%d", s.savepoints)
+	if _, err := s.tx.Exec("SAVEPOINT " + name); err != nil {
+		return nil, err
+	}
+	return &Tx{tx: s.tx, savepoint: name}, nil
+}
+
+// Exec 在事务内执行 SQL 语句
+func (t *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.Exec(query, args...)
+}
+
+// Prepare 在事务内预编译 SQL 语句
+func (t *Tx) Prepare(query string) (*sql.Stmt, error) {
+	return t.tx.Prepare(query)
+}
+
+// Commit 提交事务
+func (t *Tx) Commit() error {
+	if t.savepoint == "" {
+		return t.tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	_, err := t.tx.Exec("RELEASE " + t.savepoint)
+	return err
+}
+
+// Rollback 回滚事务；已提交或已回滚时返回 sql.ErrTxDone
+func (t *Tx) Rollback() error {
+	if t.savepoint == "" {
+		return t.tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if _, err := t.tx.Exec("ROLLBACK TO " + t.savepoint); err != nil {
+		return err
+	}
+	_, err := t.tx.Exec("RELEASE " + t.savepoint)
+	return err
+}
+
+// DryRun 在回滚事务内执行 fn：fn 收到的预演 Store 可正常读写，返回后所有修改都被丢弃。
+// 预演期间独占数据库连接，其他请求将等待其结束。
+func (s *Store) DryRun(fn func(preview *Store) error) error {
+	if s.tx != nil {
+		return fmt.Errorf("dry run cannot be nested")
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin dry run: %w", err)
+	}
+	defer tx.Rollback()
+
+	return fn(&Store{db: s.db, path: s.path, tx: tx})
+}
+
+// Atomic 在事务内执行 fn，fn 返回错误时整体回滚；已处于事务中的 Store 以保存点嵌套
+func (s *Store) Atomic(fn func(st *Store) error) error {
+	if s.tx != nil {
+		tx, err := s.BeginTx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := fn(s); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{db: s.db, path: s.path, tx: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 // Exec 执行 SQL 语句
 func (s *Store) Exec(query string, args ...interface{}) error {
-	_, err := s.db.Exec(query, args...)
+	_, err := s.conn().Exec(query, args...)
 	return err
 }
 
 // QueryRow 查询单行
 func (s *Store) QueryRow(query string, args ...interface{}) *sql.Row {
-	return s.db.QueryRow(query, args...)
+	return s.conn().QueryRow(query, args...)
 }
 
 // Query 查询多行
 func (s *Store) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return s.db.Query(query, args...)
+	return s.conn().Query(query, args...)
+}
+
+// DataVersion 企业数据与配置项的数据版本（data_version 表，由触发器在每次写入时递增），可作为缓存的数据版本。
+// 版本随数据一起提交或回滚，与连接无关，重连或其他进程写入后依然可靠。
+func (s *Store) DataVersion() (int64, error) {
+	var n int64
+	if err := s.conn().QueryRow(`SELECT version FROM data_version WHERE id = 1`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to read data version: %w", err)
+	}
+	return n, nil
 }
@@ -14,7 +14,7 @@ func (s *Store) BatchInsertWR(records []*model.WholesaleRetail) error {
 		return nil
 	}
 
-	tx, err := s.db.Begin()
+	tx, err := s.BeginTx()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -143,7 +143,7 @@ func (s *Store) GetWRByYearMonth(opts WRQueryOptions) ([]*model.WholesaleRetail,
 		}
 	}
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.conn().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query: %w", err)
 	}
@@ -170,7 +170,7 @@ func (s *Store) UpdateWR(id int64, updates map[string]interface{}) error {
 	query := fmt.Sprintf("UPDATE wholesale_retail SET %s WHERE id = ?",
 		strings.Join(setClauses, ", "))
 
-	_, err := s.db.Exec(query, args...)
+	_, err := s.conn().Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update: %w", err)
 	}
@@ -180,7 +180,7 @@ func (s *Store) UpdateWR(id int64, updates map[string]interface{}) error {
 
 // DeleteWRByYearMonth 删除指定年月的批零企业数据
 func (s *Store) DeleteWRByYearMonth(year, month int) error {
-	_, err := s.db.Exec("DELETE FROM wholesale_retail WHERE data_year = ? AND data_month = ?",
+	_, err := s.conn().Exec("DELETE FROM wholesale_retail WHERE data_year = ? AND data_month = ?",
 		year, month)
 	if err != nil {
 		return fmt.Errorf("failed to delete: %w", err)
@@ -215,7 +215,7 @@ func (s *Store) CountWR(opts WRQueryOptions) (int, error) {
 	}
 
 	var count int
-	err := s.db.QueryRow(query, args...).Scan(&count)
+	err := s.conn().QueryRow(query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count: %w", err)
 	}
@@ -225,7 +225,7 @@ func (s *Store) CountWR(opts WRQueryOptions) (int, error) {
 
 // GetWRByID 根据 ID 获取批零企业
 func (s *Store) GetWRByID(id int64) (*model.WholesaleRetail, error) {
-	row := s.db.QueryRow("SELECT * FROM wholesale_retail WHERE id = ?", id)
+	row := s.conn().QueryRow("SELECT * FROM wholesale_retail WHERE id = ?", id)
 	return s.scanWRRow(row)
 }
 
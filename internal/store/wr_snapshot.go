@@ -12,7 +12,7 @@ func (s *Store) BatchInsertWRSnapshot(records []*model.WRSnapshot) error {
 		return nil
 	}
 
-	tx, err := s.db.Begin()
+	tx, err := s.BeginTx()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -62,7 +62,7 @@ func (s *Store) BatchInsertWRSnapshot(records []*model.WRSnapshot) error {
 
 // DeleteWRSnapshotByYearMonth 删除指定年月的批零快照数据
 func (s *Store) DeleteWRSnapshotByYearMonth(year, month int) error {
-	_, err := s.db.Exec("DELETE FROM wr_snapshot WHERE snapshot_year = ? AND snapshot_month = ?", year, month)
+	_, err := s.conn().Exec("DELETE FROM wr_snapshot WHERE snapshot_year = ? AND snapshot_month = ?", year, month)
 	if err != nil {
 		return fmt.Errorf("failed to delete: %w", err)
 	}
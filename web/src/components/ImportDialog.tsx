@@ -28,7 +28,7 @@ interface ProgressEvent {
 }
 
 export default function ImportDialog({ open, onClose, onSuccess }: ImportDialogProps) {
-  const [file, setFile] = useState<File | null>(null)
+  const [files, setFiles] = useState<File[]>([])
   const [clearExisting, setClearExisting] = useState(true)
   const [importing, setImporting] = useState(false)
   const [progress, setProgress] = useState<ProgressEvent[]>([])
@@ -40,9 +40,10 @@ export default function ImportDialog({ open, onClose, onSuccess }: ImportDialogP
   const logEndRef = useRef<HTMLDivElement>(null)
 
   const handleFileChange = (e: React.ChangeEvent<HTMLInputElement>) => {
-    const selectedFile = e.target.files?.[0]
-    if (selectedFile) {
-      setFile(selectedFile)
+    // 多个文件时每个 CSV 作为一个 Sheet
+    const selected = Array.from(e.target.files ?? [])
+    if (selected.length > 0) {
+      setFiles(selected)
       setError(null)
     }
   }
@@ -58,7 +59,7 @@ export default function ImportDialog({ open, onClose, onSuccess }: ImportDialogP
   }, [doneSheets, totalSheets])
 
   const handleImport = async () => {
-    if (!file) {
+    if (files.length === 0) {
       setError('请选择文件')
       return
     }
@@ -72,7 +73,7 @@ export default function ImportDialog({ open, onClose, onSuccess }: ImportDialogP
 
     try {
       const formData = new FormData()
-      formData.append('file', file)
+      files.forEach((f) => formData.append('file', f))
       formData.append('clearExisting', clearExisting ? 'true' : 'false')
       formData.append('updateConfigYM', 'true')
 
@@ -156,7 +157,7 @@ export default function ImportDialog({ open, onClose, onSuccess }: ImportDialogP
   }
 
   const handleReset = () => {
-    setFile(null)
+    setFiles([])
     setProgress([])
     setCompleted(false)
     setError(null)
@@ -188,13 +189,15 @@ export default function ImportDialog({ open, onClose, onSuccess }: ImportDialogP
                   id="file"
                   ref={fileInputRef}
                   type="file"
-                  accept=".xlsx,.xls"
+                  accept=".xlsx,.xls,.csv"
+                  multiple
                   onChange={handleFileChange}
                 />
-                {file && (
+                {files.length > 0 && (
                   <p className="text-sm text-muted-foreground">
-                    已选择: <span className="font-medium text-foreground">{file.name}</span> ·{' '}
-                    {(file.size / 1024 / 1024).toFixed(2)} MB
+                    已选择:{' '}
+                    <span className="font-medium text-foreground">{files.map((f) => f.name).join('、')}</span> ·{' '}
+                    {(files.reduce((sum, f) => sum + f.size, 0) / 1024 / 1024).toFixed(2)} MB
                   </p>
                 )}
               </div>
@@ -217,7 +220,7 @@ export default function ImportDialog({ open, onClose, onSuccess }: ImportDialogP
               <div className="flex gap-2">
                 <Button
                   onClick={handleImport}
-                  disabled={!file || importing}
+                  disabled={files.length === 0 || importing}
                   className="flex-1"
                 >
                   <Upload className="w-4 h-4 mr-2" />